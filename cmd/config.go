@@ -60,6 +60,12 @@ func init() {
 	viper.BindPFlag("TCPServer.Host", configCmd.Flags().Lookup("tcpHost"))
 	viper.BindPFlag("TCPServer.Port", configCmd.Flags().Lookup("tcpPort"))
 
+	// Debugger
+	configCmd.Flags().StringVar(&conf.Config.Debugger.Host, "debuggerHost", "127.0.0.1", "Contracts debugger host in VDE mode")
+	configCmd.Flags().IntVar(&conf.Config.Debugger.Port, "debuggerPort", 0, "Contracts debugger port in VDE mode, 0 disables the debugger")
+	viper.BindPFlag("Debugger.Host", configCmd.Flags().Lookup("debuggerHost"))
+	viper.BindPFlag("Debugger.Port", configCmd.Flags().Lookup("debuggerPort"))
+
 	// HTTP Server
 	configCmd.Flags().StringVar(&conf.Config.HTTP.Host, "httpHost", "127.0.0.1", "Node HTTP host")
 	configCmd.Flags().IntVar(&conf.Config.HTTP.Port, "httpPort", 7079, "Node HTTP port")
@@ -135,8 +141,21 @@ func init() {
 	configCmd.Flags().StringVar(&conf.Config.TLSCert, "tls-cert", "", "Filepath to the fullchain of certificates")
 	configCmd.Flags().StringVar(&conf.Config.TLSKey, "tls-key", "", "Filepath to the private key")
 	configCmd.Flags().Int64Var(&conf.Config.MaxPageGenerationTime, "mpgt", 1000, "Max page generation time in ms")
+	configCmd.Flags().Int64Var(&conf.Config.MaxReorgDepth, "maxReorgDepth", 0, "Max count of blocks which can be rolled back in the case of fork, the node halts on deeper forks (0 means no limit)")
+	configCmd.Flags().Int64Var(&conf.Config.FinalityPercent, "finalityPercent", 0,
+		fmt.Sprintf("Percent of other full nodes which must confirm the block to consider it final (default %d)", consts.DefaultFinalityPercent),
+	)
 	configCmd.Flags().StringSliceVar(&conf.Config.NodesAddr, "nodesAddr", []string{}, "List of addresses for downloading blockchain")
 	configCmd.Flags().StringVar(&conf.Config.RunningMode, "runMode", "PublicBlockchain", "Node running mode")
+	configCmd.Flags().Int64Var(&conf.Config.Snapshot.Interval, "snapshotInterval", 0, "Interval of blocks between state snapshots (0 disables snapshots)")
+	configCmd.Flags().IntVar(&conf.Config.Snapshot.Keep, "snapshotKeep", 2, "Count of stored state snapshots")
+	configCmd.Flags().Int64Var(&conf.Config.Pruning.Blocks, "pruningBlocks", 0, "Count of the last blocks which keep rollback data (0 disables pruning)")
+	configCmd.Flags().BoolVar(&conf.Config.Pruning.Bodies, "pruningBodies", false, "Remove bodies of pruned blocks")
+	configCmd.Flags().Int64Var(&conf.Config.Archive.Interval, "archiveInterval", 0, "Interval of blocks between checkpoints of historical state (0 disables archive mode)")
+	configCmd.Flags().Int64Var(&conf.Config.Mempool.MaxSize, "mempoolMaxSize", 32<<20, "Max size of pending transactions in bytes")
+	configCmd.Flags().Int64Var(&conf.Config.Mempool.MaxAge, "mempoolMaxAge", 3600, "Max time in seconds which the transaction can wait for the block")
+	configCmd.Flags().StringSliceVar(&conf.Config.Peers.Seeds, "peerSeeds", []string{}, "List of addresses of nodes for the discovery of peers")
+	configCmd.Flags().StringVar(&conf.Config.Peers.PublicAddress, "peerPublicAddress", "", "Public TCP address of the node which is announced to peers")
 
 	viper.BindPFlag("PidFilePath", configCmd.Flags().Lookup("pid"))
 	viper.BindPFlag("LockFilePath", configCmd.Flags().Lookup("lock"))
@@ -147,7 +166,18 @@ func init() {
 	viper.BindPFlag("TLSCert", configCmd.Flags().Lookup("tls-cert"))
 	viper.BindPFlag("TLSKey", configCmd.Flags().Lookup("tls-key"))
 	viper.BindPFlag("MaxPageGenerationTime", configCmd.Flags().Lookup("mpgt"))
+	viper.BindPFlag("MaxReorgDepth", configCmd.Flags().Lookup("maxReorgDepth"))
+	viper.BindPFlag("FinalityPercent", configCmd.Flags().Lookup("finalityPercent"))
 	viper.BindPFlag("TempDir", configCmd.Flags().Lookup("tempDir"))
 	viper.BindPFlag("NodesAddr", configCmd.Flags().Lookup("nodesAddr"))
 	viper.BindPFlag("RunningMode", configCmd.Flags().Lookup("runMode"))
+	viper.BindPFlag("Snapshot.Interval", configCmd.Flags().Lookup("snapshotInterval"))
+	viper.BindPFlag("Snapshot.Keep", configCmd.Flags().Lookup("snapshotKeep"))
+	viper.BindPFlag("Pruning.Blocks", configCmd.Flags().Lookup("pruningBlocks"))
+	viper.BindPFlag("Pruning.Bodies", configCmd.Flags().Lookup("pruningBodies"))
+	viper.BindPFlag("Archive.Interval", configCmd.Flags().Lookup("archiveInterval"))
+	viper.BindPFlag("Mempool.MaxSize", configCmd.Flags().Lookup("mempoolMaxSize"))
+	viper.BindPFlag("Mempool.MaxAge", configCmd.Flags().Lookup("mempoolMaxAge"))
+	viper.BindPFlag("Peers.Seeds", configCmd.Flags().Lookup("peerSeeds"))
+	viper.BindPFlag("Peers.PublicAddress", configCmd.Flags().Lookup("peerPublicAddress"))
 }
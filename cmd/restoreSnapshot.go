@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/daemons"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/utils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// restoreSnapshotCmd represents the restoreSnapshot command
+var restoreSnapshotCmd = &cobra.Command{
+	Use:    "restoreSnapshot",
+	Short:  "Restore the state from the snapshot which is agreed by nodes",
+	PreRun: loadConfigWKey,
+	Run: func(cmd *cobra.Command, args []string) {
+		f := utils.LockOrDie(conf.Config.LockFilePath)
+		defer f.Unlock()
+
+		if err := model.GormInit(
+			conf.Config.DB.Host,
+			conf.Config.DB.Port,
+			conf.Config.DB.User,
+			conf.Config.DB.Password,
+			conf.Config.DB.Name,
+		); err != nil {
+			log.WithError(err).Fatal("init db")
+			return
+		}
+		logger := log.WithFields(log.Fields{})
+
+		// the first block contains the nodes which the snapshot is requested from
+		if err := daemons.InitialLoad(logger); err != nil {
+			log.WithError(err).Fatal("loading first block")
+			return
+		}
+		if err := syspar.SysUpdate(nil); err != nil {
+			log.WithError(err).Error("can't read system parameters")
+		}
+		if err := daemons.RestoreSnapshot(context.Background(), logger); err != nil {
+			log.WithError(err).Fatal("restoring snapshot")
+			return
+		}
+		log.Info("Snapshot has been restored, the node continues from its block after start")
+	},
+}
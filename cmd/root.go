@@ -22,9 +22,11 @@ func init() {
 		generateKeysCmd,
 		initDatabaseCmd,
 		rollbackCmd,
+		restoreSnapshotCmd,
 		startCmd,
 		configCmd,
 		stopNetworkCmd,
+		testCmd,
 	)
 
 	// This flags are visible for all child commands
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/contracttest"
+	"github.com/GenesisCommunity/go-genesis/packages/debugger"
+	"github.com/GenesisCommunity/go-genesis/packages/smart"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	testKeyID     int64
+	testEcosystem int64
+	testBlockTime int64
+	testRun       string
+	testDebug     string
+)
+
+// testCmd represents the test command
+var testCmd = &cobra.Command{
+	Use:    "test [files]",
+	Short:  "Running tests of contracts",
+	Args:   cobra.MinimumNArgs(1),
+	PreRun: loadConfig,
+	Run: func(cmd *cobra.Command, args []string) {
+		env, err := contracttest.NewEnv(conf.Config.DB, testKeyID)
+		if err != nil {
+			log.WithError(err).Fatal("creating test environment")
+			return
+		}
+		env.EcosystemID = testEcosystem
+		env.BlockTime = testBlockTime
+		if len(testDebug) > 0 {
+			srv := debugger.Start(smart.GetVM(), testDebug)
+			log.WithFields(log.Fields{"addr": testDebug}).Info("waiting for debugger client")
+			<-srv.Ready()
+		}
+
+		if err = env.LoadFiles(args...); err != nil {
+			env.Close()
+			log.WithError(err).Fatal("loading contracts")
+			return
+		}
+		var failed int
+		results := env.Run(testRun)
+		for _, result := range results {
+			if result.Passed {
+				fmt.Printf("PASS %s (fuel %d, %v)\n", result.Name, result.Fuel, result.Duration)
+			} else {
+				failed++
+				fmt.Printf("FAIL %s (fuel %d, %v)\n\t%s\n", result.Name, result.Fuel, result.Duration, result.Error)
+			}
+		}
+		env.Close()
+		fmt.Printf("%d passed, %d failed\n", len(results)-failed, failed)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	testCmd.Flags().Int64Var(&testKeyID, "keyID", 1, "value of $key_id in tests")
+	testCmd.Flags().Int64Var(&testEcosystem, "ecosystem", 1, "value of $ecosystem_id in tests")
+	testCmd.Flags().Int64Var(&testBlockTime, "blockTime", 0, "value of $block_time in tests, the current time by default")
+	testCmd.Flags().StringVar(&testDebug, "debug", "", "tcp address of the debugger, tests wait for the client of Debug Adapter Protocol")
+	testCmd.Flags().StringVar(&testRun, "run", "", "run only tests which contain the specified string in the name")
+}
@@ -211,7 +211,8 @@ func waitTx(hash string) (int64, error) {
 			return converter.StrToInt64(ret.BlockID), fmt.Errorf(ret.Result)
 		}
 		if ret.Message != nil {
-			errtext, err := json.Marshal(ret.Message)
+			// only the type and the text of the error are compared, the position depends on the source
+			errtext, err := json.Marshal(&txstatusError{Type: ret.Message.Type, Error: ret.Message.Error})
 			if err != nil {
 				return 0, err
 			}
@@ -0,0 +1,150 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/GenesisCommunity/go-genesis/packages/archive"
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// startArchiveQuery starts the transaction which is used for the reconstruction of the state at the block
+func startArchiveQuery(w http.ResponseWriter, blockID int64, logger *log.Entry) (*model.DbTransaction, error) {
+	if conf.Config.IsSupportingVDE() {
+		logger.WithFields(log.Fields{"type": consts.ParameterExceeded, "block_id": blockID}).Error("historical state of VDE")
+		return nil, errorAPI(w, `E_ARCHIVE`, http.StatusBadRequest, blockID)
+	}
+	transaction, err := model.StartReadOnlyTransaction()
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("starting read only transaction")
+		return nil, errorAPI(w, `E_QUERY`, http.StatusInternalServerError)
+	}
+	return transaction, nil
+}
+
+func archiveError(w http.ResponseWriter, err error, blockID int64, logger *log.Entry) error {
+	if err == archive.ErrPrunedBlock || err == archive.ErrFutureBlock {
+		logger.WithFields(log.Fields{"type": consts.ParameterExceeded, "error": err, "block_id": blockID}).Error("getting historical state")
+		return errorAPI(w, `E_ARCHIVE`, http.StatusBadRequest, blockID)
+	}
+	logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": blockID}).Error("getting historical state")
+	return errorAPI(w, `E_QUERY`, http.StatusInternalServerError)
+}
+
+// filterColumns leaves only the specified columns of the reconstructed row
+func filterColumns(row map[string]string, columns string) map[string]string {
+	if len(columns) == 0 {
+		return row
+	}
+	result := make(map[string]string)
+	for _, col := range strings.Split(columns, `,`) {
+		col = strings.Trim(converter.EscapeName(strings.TrimSpace(col)), `"`)
+		if val, ok := row[col]; ok {
+			result[col] = val
+		}
+	}
+	return result
+}
+
+// archiveTable returns the escaped name of the table from the request if the table exists
+func archiveTable(w http.ResponseWriter, data *apiData, logger *log.Entry) (string, error) {
+	table := strings.Trim(converter.EscapeName(getPrefix(data)+`_`+data.params[`name`].(string)), `"`)
+	if !model.IsTable(table) {
+		logger.WithFields(log.Fields{"type": consts.NotFound, "table": table}).Error("table not found")
+		return ``, errorAPI(w, `E_TABLENOTFOUND`, http.StatusBadRequest, data.params[`name`].(string))
+	}
+	return table, nil
+}
+
+func archiveRow(w http.ResponseWriter, data *apiData, blockID int64, logger *log.Entry) error {
+	table, err := archiveTable(w, data, logger)
+	if err != nil {
+		return err
+	}
+	transaction, err := startArchiveQuery(w, blockID, logger)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+
+	row, err := archive.GetRow(transaction, table, converter.StrToInt64(data.params[`id`].(string)), blockID)
+	if err != nil {
+		return archiveError(w, err, blockID, logger)
+	}
+	if row == nil {
+		row = make(map[string]string)
+	}
+	data.result = &rowResult{Value: filterColumns(row, data.params[`columns`].(string))}
+	return nil
+}
+
+func archiveList(w http.ResponseWriter, data *apiData, blockID int64, limit int, logger *log.Entry) error {
+	table, err := archiveTable(w, data, logger)
+	if err != nil {
+		return err
+	}
+	transaction, err := startArchiveQuery(w, blockID, logger)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+
+	count, list, err := archive.GetRows(transaction, table, blockID, data.params[`offset`].(int64), int64(limit))
+	if err != nil {
+		return archiveError(w, err, blockID, logger)
+	}
+	if columns := data.params[`columns`].(string); len(columns) > 0 {
+		for i, row := range list {
+			list[i] = filterColumns(row, `id,`+columns)
+		}
+	}
+	data.result = &listResult{
+		Count: converter.Int64ToStr(count), List: list,
+	}
+	return nil
+}
+
+func archiveEcosystemParam(w http.ResponseWriter, data *apiData, sp *model.StateParameter, blockID int64, logger *log.Entry) error {
+	transaction, err := startArchiveQuery(w, blockID, logger)
+	if err != nil {
+		return err
+	}
+	defer transaction.Rollback()
+
+	row, err := archive.GetRow(transaction, sp.TableName(), sp.ID, blockID)
+	if err != nil {
+		return archiveError(w, err, blockID, logger)
+	}
+	if row == nil || row[`name`] != sp.Name {
+		logger.WithFields(log.Fields{"type": consts.NotFound, "key": sp.Name, "block_id": blockID}).Error("state parameter not found")
+		return errorAPI(w, `E_PARAMNOTFOUND`, http.StatusBadRequest, sp.Name)
+	}
+	data.result = &paramValue{ID: row[`id`], Name: row[`name`], Value: row[`value`], Conditions: row[`conditions`]}
+	return nil
+}
@@ -57,6 +57,8 @@ type getBlockInfoResult struct {
 	Time          int64  `json:"time"`
 	Tx            int32  `json:"tx_count"`
 	RollbacksHash []byte `json:"rollbacks_hash"`
+	Confirmations int64  `json:"confirmations"`
+	Final         bool   `json:"final"`
 }
 
 func getBlockInfo(w http.ResponseWriter, r *http.Request, data *apiData, logger *log.Entry) (err error) {
@@ -71,6 +73,12 @@ func getBlockInfo(w http.ResponseWriter, r *http.Request, data *apiData, logger
 		log.WithFields(log.Fields{"type": consts.NotFound, "id": blockID}).Error("block with id not found")
 		return errorAPI(w, `E_NOTFOUND`, http.StatusNotFound)
 	}
-	data.result = &getBlockInfoResult{Hash: block.Hash, EcosystemID: block.EcosystemID, KeyID: block.KeyID, Time: block.Time, Tx: block.Tx, RollbacksHash: block.RollbacksHash}
+	confirmations, final, err := getFinality(blockID)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block confirmations")
+		return errorAPI(w, err, http.StatusInternalServerError)
+	}
+	data.result = &getBlockInfoResult{Hash: block.Hash, EcosystemID: block.EcosystemID, KeyID: block.KeyID, Time: block.Time, Tx: block.Tx, RollbacksHash: block.RollbacksHash,
+		Confirmations: confirmations, Final: final}
 	return nil
 }
@@ -360,6 +360,7 @@ func (c *contractHandlers) contract(w http.ResponseWriter, r *http.Request, data
 		PayOver:        data.params[`payover`].(string),
 		SignedBy:       signedBy,
 		Data:           idata,
+		Replaces:       data.params[`replaces`].([]byte),
 	}
 	serializedData, err := msgpack.Marshal(toSerialize)
 	if err != nil {
@@ -394,6 +395,9 @@ func blockchainUpdatingState(w http.ResponseWriter, r *http.Request, data *apiDa
 	case service.PauseTypeStopingNetwork:
 		reason = "E_STOPPING"
 		break
+	case service.PauseTypeDeepReorg:
+		reason = "E_HALTED"
+		break
 	}
 
 	return errorAPI(w, reason, http.StatusServiceUnavailable)
@@ -48,6 +48,9 @@ func ecosystemParam(w http.ResponseWriter, r *http.Request, data *apiData, logge
 		logger.WithFields(log.Fields{"type": consts.NotFound, "key": name}).Error("state parameter not found")
 		return errorAPI(w, `E_PARAMNOTFOUND`, http.StatusBadRequest, name)
 	}
+	if blockID := data.params[`block`].(int64); blockID > 0 {
+		return archiveEcosystemParam(w, data, sp, blockID, logger)
+	}
 
 	data.result = &paramValue{ID: converter.Int64ToStr(sp.ID), Name: sp.Name, Value: sp.Value, Conditions: sp.Conditions}
 	return
@@ -23,12 +23,14 @@ package api
 
 var (
 	apiErrors = map[string]string{
+		`E_ARCHIVE`:         `State at block %d is not available`,
 		`E_CONTRACT`:        `There is not %s contract`,
 		`E_DBNIL`:           `DB is nil`,
 		`E_DELETEDKEY`:      `The key is deleted`,
 		`E_ECOSYSTEM`:       `Ecosystem %d doesn't exist`,
 		`E_EMPTYPUBLIC`:     `Public key is undefined`,
 		`E_EMPTYSIGN`:       `Signature is undefined`,
+		`E_HALTED`:          `Node is halted because of the deep fork`,
 		`E_HASHWRONG`:       `Hash is incorrect`,
 		`E_HASHNOTFOUND`:    `Hash has not been found`,
 		`E_HEAVYPAGE`:       `This page is heavy`,
@@ -37,9 +39,11 @@ var (
 		`E_LIMITFORSIGN`:    `Length of forsign is too big (%d)`,
 		`E_LIMITTXSIZE`:     `The size of tx is too big (%d)`,
 		`E_NOTFOUND`:        `Page not found`,
+		`E_NOTHALTED`:       `Node is not halted`,
 		`E_NOTINSTALLED`:    `Apla is not installed`,
 		`E_PARAMNOTFOUND`:   `Parameter %s has not been found`,
 		`E_PERMISSION`:      `Permission denied`,
+		`E_PRUNED`:          `Body of block %d has been pruned`,
 		`E_QUERY`:           `DB query is wrong`,
 		`E_RECOVERED`:       `API recovered`,
 		`E_REFRESHTOKEN`:    `Refresh token is not valid`,
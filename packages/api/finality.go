@@ -0,0 +1,93 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// requiredConfirmations returns the count of other full nodes which must confirm the block to consider it final
+func requiredConfirmations() int64 {
+	return confirmationsCount(int64(len(syspar.GetRemoteHosts())), conf.Config.FinalityPercent)
+}
+
+// confirmationsCount returns the percent of nodes rounded up. At least one confirmation is required
+// so blocks aren't final without confirmations when there are no other nodes
+func confirmationsCount(nodes, percent int64) int64 {
+	if percent <= 0 {
+		percent = consts.DefaultFinalityPercent
+	}
+	count := (nodes*percent + 99) / 100
+	if count < 1 {
+		count = 1
+	}
+	return count
+}
+
+// getFinality returns the count of confirmations of the block and true if the block is final
+func getFinality(blockID int64) (int64, bool, error) {
+	count, err := model.GetBlockConfirmations(blockID)
+	if err != nil {
+		return 0, false, err
+	}
+	return count, count >= requiredConfirmations(), nil
+}
+
+type finalizedResult struct {
+	BlockID       int64  `json:"block_id"`
+	Hash          string `json:"hash"`
+	Time          int64  `json:"time"`
+	Confirmations int64  `json:"confirmations"`
+	Required      int64  `json:"required"`
+}
+
+func getFinalized(w http.ResponseWriter, r *http.Request, data *apiData, logger *log.Entry) error {
+	required := requiredConfirmations()
+	confirmation := &model.Confirmation{}
+	found, err := confirmation.GetGoodBlock(int(required))
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting last final block")
+		return errorAPI(w, err, http.StatusInternalServerError)
+	}
+	result := &finalizedResult{Required: required}
+	if found {
+		block := &model.Block{}
+		if _, err = block.Get(confirmation.BlockID); err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block")
+			return errorAPI(w, err, http.StatusInternalServerError)
+		}
+		result.BlockID = block.ID
+		result.Hash = hex.EncodeToString(block.Hash)
+		result.Time = block.Time
+		result.Confirmations = int64(confirmation.Good)
+	}
+	data.result = result
+	return nil
+}
@@ -0,0 +1,49 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package api
+
+import (
+	"testing"
+)
+
+func TestConfirmationsCount(t *testing.T) {
+	cases := []struct {
+		nodes, percent, count int64
+	}{
+		{0, 0, 1},
+		{1, 0, 1},
+		{1, 67, 1},
+		{2, 67, 2},
+		{3, 0, 3},
+		{4, 67, 3},
+		{10, 67, 7},
+		{10, 50, 5},
+		{10, 100, 10},
+		{10, -1, 7},
+	}
+	for _, v := range cases {
+		if count := confirmationsCount(v.nodes, v.percent); count != v.count {
+			t.Errorf("nodes %d, percent %d: expected %d, got %d", v.nodes, v.percent, v.count, count)
+		}
+	}
+}
@@ -22,7 +22,6 @@
 package api
 
 import (
-	"encoding/json"
 	"net/http"
 
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
@@ -50,8 +49,8 @@ func getHistory(w http.ResponseWriter, r *http.Request, data *apiData, logger *l
 		if tx.Data == "" {
 			continue
 		}
-		rollback := map[string]string{}
-		if err := json.Unmarshal([]byte(tx.Data), &rollback); err != nil {
+		rollback, _, err := model.ParseRollbackData(tx.Data)
+		if err != nil {
 			logger.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling rollbackTx.Data from JSON")
 			return errorAPI(w, err, http.StatusInternalServerError)
 		}
@@ -58,6 +58,9 @@ func list(w http.ResponseWriter, r *http.Request, data *apiData, logger *log.Ent
 	} else {
 		limit = 25
 	}
+	if blockID := data.params[`block`].(int64); blockID > 0 {
+		return archiveList(w, data, blockID, limit, logger)
+	}
 	list, err := model.GetAll(`select `+cols+` from `+table+` order by id desc`+
 		fmt.Sprintf(` offset %d `, data.params[`offset`].(int64)), limit)
 	if err != nil {
@@ -23,6 +23,8 @@ package api
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/GenesisCommunity/go-genesis/packages/converter"
@@ -61,3 +63,23 @@ func TestList(t *testing.T) {
 		}
 	}
 }
+
+func TestArchiveQuotedName(t *testing.T) {
+	if err := keyLogin(1); err != nil {
+		t.Error(err)
+		return
+	}
+	name := url.PathEscape(`keys" WHERE false; DROP TABLE "1_keys`)
+	var ret listResult
+	err := sendGet(`list/`+name+`?block=1`, nil, &ret)
+	if err == nil || !strings.Contains(err.Error(), `E_TABLENOTFOUND`) {
+		t.Error(err)
+		return
+	}
+	var row rowResult
+	err = sendGet(`row/`+name+`/1?block=1`, nil, &row)
+	if err == nil || !strings.Contains(err.Error(), `E_TABLENOTFOUND`) {
+		t.Error(err)
+		return
+	}
+}
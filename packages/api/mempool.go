@@ -0,0 +1,82 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/mempool"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type mempoolItem struct {
+	Hash     string `json:"hash"`
+	KeyID    string `json:"key_id"`
+	Contract string `json:"contract"`
+	Time     int64  `json:"time"`
+	Added    int64  `json:"added"`
+	Size     int    `json:"size"`
+	HighRate int64  `json:"high_rate"`
+	Rate     string `json:"rate"`
+}
+
+type mempoolResult struct {
+	Count int           `json:"count"`
+	Size  int           `json:"size"`
+	List  []mempoolItem `json:"list"`
+}
+
+func getMempool(w http.ResponseWriter, r *http.Request, data *apiData, logger *log.Entry) error {
+	limit := int(data.params[`limit`].(int64))
+	if limit <= 0 {
+		limit = 25
+	}
+	offset := int(data.params[`offset`].(int64))
+	if offset < 0 {
+		offset = 0
+	}
+	list, size := mempool.List()
+	result := &mempoolResult{Count: len(list), Size: size, List: []mempoolItem{}}
+	if offset < len(list) {
+		list = list[offset:]
+		if len(list) > limit {
+			list = list[:limit]
+		}
+		for _, item := range list {
+			result.List = append(result.List, mempoolItem{
+				Hash:     hex.EncodeToString(item.Hash),
+				KeyID:    converter.Int64ToStr(item.KeyID),
+				Contract: item.Contract,
+				Time:     item.Time,
+				Added:    item.Added,
+				Size:     item.Size,
+				HighRate: item.HighRate,
+				Rate:     item.Rate.String(),
+			})
+		}
+	}
+	data.result = result
+	return nil
+}
@@ -160,6 +160,7 @@ func (h *contractHandlers) prepareContract(w http.ResponseWriter, r *http.Reques
 	smartTx.TokenEcosystem = data.params[`token_ecosystem`].(int64)
 	smartTx.MaxSum = data.params[`max_sum`].(string)
 	smartTx.PayOver = data.params[`payover`].(string)
+	smartTx.Replaces = data.params[`replaces`].([]byte)
 	if data.params[`signed_by`] != nil {
 		smartTx.SignedBy = data.params[`signed_by`].(int64)
 	}
@@ -0,0 +1,106 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/service"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type reorgResult struct {
+	ID         int64    `json:"id"`
+	Time       int64    `json:"time"`
+	OldBlockID int64    `json:"old_block_id"`
+	OldHash    string   `json:"old_hash"`
+	NewBlockID int64    `json:"new_block_id"`
+	NewHash    string   `json:"new_hash"`
+	Depth      int64    `json:"depth"`
+	TxHashes   []string `json:"tx_hashes"`
+}
+
+type reorgsResult struct {
+	List []reorgResult `json:"list"`
+}
+
+type resumeResult struct {
+	BlockID int64 `json:"block_id"`
+}
+
+func newReorgResult(item *model.Reorg) reorgResult {
+	result := reorgResult{
+		ID:         item.ID,
+		Time:       item.Time,
+		OldBlockID: item.OldBlockID,
+		OldHash:    hex.EncodeToString(item.OldHash),
+		NewBlockID: item.NewBlockID,
+		NewHash:    hex.EncodeToString(item.NewHash),
+		Depth:      item.Depth,
+		TxHashes:   []string{},
+	}
+	if len(item.TxHashes) > 0 {
+		result.TxHashes = strings.Split(item.TxHashes, `,`)
+	}
+	return result
+}
+
+func getReorgs(w http.ResponseWriter, r *http.Request, data *apiData, logger *log.Entry) error {
+	limit := data.params[`limit`].(int64)
+	if limit <= 0 {
+		limit = 25
+	}
+	reorgs, err := model.GetReorgs(data.params[`offset`].(int64), limit)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting reorgs")
+		return errorAPI(w, err, http.StatusInternalServerError)
+	}
+	result := &reorgsResult{List: make([]reorgResult, len(reorgs))}
+	for i := range reorgs {
+		result.List[i] = newReorgResult(&reorgs[i])
+	}
+	data.result = result
+	return nil
+}
+
+// resumeNode resumes the node which has been halted by the deep fork, the fork is rolled back after that.
+// Only the key of the node can resume it
+func resumeNode(w http.ResponseWriter, r *http.Request, data *apiData, logger *log.Entry) error {
+	if data.keyId != conf.Config.KeyID {
+		logger.WithFields(log.Fields{"type": consts.AccessDenied, "key_id": data.keyId}).Error("resuming node by other key")
+		return errorAPI(w, `E_PERMISSION`, http.StatusUnauthorized)
+	}
+	blockID, ok := service.ResumeAfterDeepReorg()
+	if !ok {
+		logger.WithFields(log.Fields{"type": consts.InvalidObject}).Error("resuming node which is not halted")
+		return errorAPI(w, `E_NOTHALTED`, http.StatusBadRequest)
+	}
+	logger.WithFields(log.Fields{"block_id": blockID}).Warn("node has been resumed after the deep fork")
+	data.result = &resumeResult{BlockID: blockID}
+	return nil
+}
@@ -0,0 +1,73 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/service"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorgs(t *testing.T) {
+	var ret reorgsResult
+	assert.NoError(t, sendGet(`reorgs?limit=2`, nil, &ret))
+	assert.True(t, len(ret.List) <= 2)
+	for _, item := range ret.List {
+		assert.NotNil(t, item.TxHashes)
+		assert.True(t, item.Depth > 0)
+	}
+}
+
+func TestNewReorgResult(t *testing.T) {
+	result := newReorgResult(&model.Reorg{ID: 1, OldBlockID: 10, OldHash: []byte{1, 2}, NewBlockID: 11, Depth: 2})
+	assert.Equal(t, reorgResult{ID: 1, OldBlockID: 10, OldHash: `0102`, NewBlockID: 11, Depth: 2, TxHashes: []string{}}, result)
+	result = newReorgResult(&model.Reorg{TxHashes: `aa,bb`})
+	assert.Equal(t, []string{`aa`, `bb`}, result.TxHashes)
+}
+
+func TestResumeNode(t *testing.T) {
+	defer func(keyID int64) { conf.Config.KeyID = keyID }(conf.Config.KeyID)
+	conf.Config.KeyID = 100
+	logger := log.WithFields(log.Fields{})
+
+	w := httptest.NewRecorder()
+	assert.EqualError(t, resumeNode(w, nil, &apiData{keyId: 200}, logger), `Permission denied`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = httptest.NewRecorder()
+	assert.EqualError(t, resumeNode(w, nil, &apiData{keyId: 100}, logger), `Node is not halted`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	service.HaltOnDeepReorg(50)
+	data := &apiData{keyId: 100}
+	assert.NoError(t, resumeNode(httptest.NewRecorder(), nil, data, logger))
+	assert.Equal(t, &resumeResult{BlockID: 50}, data.result)
+	assert.False(t, service.IsNodePaused())
+	assert.True(t, service.IsDeepReorgAccepted(50))
+}
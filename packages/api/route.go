@@ -58,8 +58,8 @@ func Route(route *hr.Router) {
 	get(`contract/:name`, ``, authWallet, getContract)
 	get(`contracts`, `?limit ?offset:int64`, authWallet, getContracts)
 	get(`getuid`, ``, getUID)
-	get(`list/:name`, `?limit ?offset:int64,?columns:string`, authWallet, list)
-	get(`row/:name/:id`, `?columns:string`, authWallet, row)
+	get(`list/:name`, `?limit ?offset ?block:int64,?columns:string`, authWallet, list)
+	get(`row/:name/:id`, `?columns:string,?block:int64`, authWallet, row)
 	get(`interface/page/:name`, ``, authWallet, getPageRow)
 	get(`interface/menu/:name`, ``, authWallet, getMenuRow)
 	get(`interface/block/:name`, ``, authWallet, getBlockInterfaceRow)
@@ -76,27 +76,32 @@ func Route(route *hr.Router) {
 	post(`content/menu/:name`, `?lang:string`, authWallet, getMenu)
 	post(`content/hash/:name`, ``, getPageHash)
 	post(`login`, `?pubkey signature:hex,?key_id ?mobile:string,?ecosystem ?expire ?role_id:int64`, login)
-	post(`prepare/:name`, `?token_ecosystem:int64,?max_sum ?payover:string`, authWallet, contractHandlers.prepareContract)
+	post(`prepare/:name`, `?token_ecosystem:int64,?max_sum ?payover:string,?replaces:hex`, authWallet, contractHandlers.prepareContract)
 	post(`prepareMultiple`, `data:string`, authWallet, contractHandlers.prepareMultipleContract)
 	post(`txstatusMultiple`, `data:string`, authWallet, txstatusMulti)
-	post(`contract/:request_id`, `?pubkey signature:hex, time:string, ?token_ecosystem:int64,?max_sum ?payover:string`, authWallet, blockchainUpdatingState, contractHandlers.contract)
+	post(`contract/:request_id`, `?pubkey signature ?replaces:hex, time:string, ?token_ecosystem:int64,?max_sum ?payover:string`, authWallet, blockchainUpdatingState, contractHandlers.contract)
 	post(`contractMultiple/:request_id`, `data:string`, authWallet, blockchainUpdatingState, contractHandlers.contractMulti)
 	post(`refresh`, `token:string,?expire:int64`, refresh)
 	post(`test/:name`, ``, getTest)
 	post(`content`, `template ?source:string`, jsonContent)
 	post(`updnotificator`, `ids:string`, updateNotificator)
-	get(`ecosystemparam/:name`, `?ecosystem:int64`, authWallet, ecosystemParam)
+	get(`ecosystemparam/:name`, `?ecosystem ?block:int64`, authWallet, ecosystemParam)
 	methodRoute(route, `POST`, `node/:name`, `?token_ecosystem:int64,?max_sum ?payover:string`, contractHandlers.nodeContract)
 
 	if !conf.Config.IsSupportingVDE() {
 		get(`txstatus/:hash`, ``, authWallet, txstatus)
 		get(`txstatusMultiple`, `data:string`, authWallet, txstatusMulti)
+		get(`txproof/:hash`, ``, getTxProof)
 		get(`appparam/:appid/:name`, `?ecosystem:int64`, authWallet, appParam)
 		get(`appparams/:appid`, `?ecosystem:int64,?names:string`, authWallet, appParams)
 		get(`history/:table/:id`, ``, authWallet, getHistory)
 		get(`balance/:wallet`, `?ecosystem:int64`, authWallet, balance)
 		get(`block/:id`, ``, getBlockInfo)
 		get(`maxblockid`, ``, getMaxBlockID)
+		get(`reorgs`, `?limit ?offset:int64`, getReorgs)
+		post(`reorgs/resume`, ``, authWallet, resumeNode)
+		get(`finalized`, ``, getFinalized)
+		get(`mempool`, `?limit ?offset:int64`, getMempool)
 
 		get(`ecosystemparams`, `?ecosystem:int64,?names:string`, authWallet, ecosystemParams)
 		get(`systemparams`, `?names:string`, authWallet, systemParams)
@@ -36,6 +36,9 @@ type rowResult struct {
 }
 
 func row(w http.ResponseWriter, r *http.Request, data *apiData, logger *log.Entry) (err error) {
+	if blockID := data.params[`block`].(int64); blockID > 0 {
+		return archiveRow(w, data, blockID, logger)
+	}
 	cols := `*`
 	if len(data.params[`columns`].(string)) > 0 {
 		cols = converter.EscapeName(data.params[`columns`].(string))
@@ -979,3 +979,37 @@ func TestPageHistory(t *testing.T) {
 		t.Error(fmt.Errorf(`wrong tree %s`, RawToString(retTemp.Tree)))
 	}
 }
+
+func TestDBDelete(t *testing.T) {
+	assert.NoError(t, keyLogin(1))
+
+	name := randName(`tdel`)
+	form := url.Values{"Name": {name}, "ApplicationId": {"1"}, "Columns": {`[{"name":"MyName","type":"varchar",
+		"conditions":"true"}]`},
+		"Permissions": {`{"insert": "true", "update" : "true", "new_column": "true"}`}}
+	assert.NoError(t, postTx(`NewTable`, &form))
+
+	form = url.Values{`Value`: {`contract del` + name + ` {
+		action {
+			DBInsert("` + name + `", "myname", "first")
+			DBDelete("` + name + `", 1)
+			$result = Str(Len(DBFind("` + name + `")))
+		}
+	}`}, `Conditions`: {`true`}, "ApplicationId": {"1"}}
+	assert.NoError(t, postTx(`NewContract`, &form))
+
+	assert.EqualError(t, postTx(`del`+name, &url.Values{}), `{"type":"panic","error":"Access denied"}`)
+
+	form = url.Values{"Name": {name}, "InsertPerm": {`true`}, "UpdatePerm": {`true`},
+		"NewColumnPerm": {`true`}, "DeletePerm": {`false`}}
+	assert.NoError(t, postTx(`EditTable`, &form))
+	assert.EqualError(t, postTx(`del`+name, &url.Values{}), `{"type":"panic","error":"Access denied"}`)
+
+	form = url.Values{"Name": {name}, "InsertPerm": {`true`}, "UpdatePerm": {`true`},
+		"NewColumnPerm": {`true`}, "DeletePerm": {`true`}}
+	assert.NoError(t, postTx(`EditTable`, &form))
+
+	_, msg, err := postTxResult(`del`+name, &url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, `0`, msg)
+}
@@ -0,0 +1,138 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/protocol"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// errPrunedBody is returned if the body of the block has been removed by pruning
+	errPrunedBody = errors.New("Body of block has been pruned")
+	// errTxNotInBlock is returned if the block doesn't contain the transaction
+	errTxNotInBlock = errors.New("Transaction has not been found in block")
+)
+
+type txProofResult struct {
+	BlockID   int64                      `json:"block_id"`
+	BlockHash string                     `json:"block_hash"`
+	Header    string                     `json:"header"` // its state root is the state after the previous block
+	MrklRoot  string                     `json:"mrkl_root"`
+	Data      string                     `json:"data"`
+	Proof     []protocol.MerkleProofItem `json:"proof"`
+}
+
+// getTxProof returns the header of the block with the transaction and the merkle path of the transaction
+func getTxProof(w http.ResponseWriter, r *http.Request, data *apiData, logger *log.Entry) error {
+	hash, err := hex.DecodeString(data.params[`hash`].(string))
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.ConversionError, "error": err}).Error("decoding tx hash from hex")
+		return errorAPI(w, `E_HASHWRONG`, http.StatusBadRequest)
+	}
+	ts := &model.TransactionStatus{}
+	found, err := ts.Get(hash)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting transaction status by hash")
+		return errorAPI(w, err, http.StatusInternalServerError)
+	}
+	if !found || ts.BlockID == 0 {
+		logger.WithFields(log.Fields{"type": consts.NotFound, "hash": data.params[`hash`]}).Error("transaction is not in blockchain")
+		return errorAPI(w, `E_HASHNOTFOUND`, http.StatusBadRequest)
+	}
+	block := &model.Block{}
+	found, err = block.Get(ts.BlockID)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block")
+		return errorAPI(w, err, http.StatusInternalServerError)
+	}
+	if !found {
+		logger.WithFields(log.Fields{"type": consts.NotFound, "id": ts.BlockID}).Error("block with id not found")
+		return errorAPI(w, `E_NOTFOUND`, http.StatusNotFound)
+	}
+
+	result, err := txProof(block, hash)
+	switch err {
+	case nil:
+	case errPrunedBody:
+		logger.WithFields(log.Fields{"type": consts.NotFound, "block_id": block.ID}).Error("body of block has been pruned")
+		return errorAPI(w, `E_PRUNED`, http.StatusNotFound, block.ID)
+	case errTxNotInBlock:
+		logger.WithFields(log.Fields{"type": consts.NotFound, "block_id": block.ID, "hash": data.params[`hash`]}).Error("transaction not found in block")
+		return errorAPI(w, `E_HASHNOTFOUND`, http.StatusBadRequest)
+	default:
+		return errorAPI(w, err, http.StatusInternalServerError)
+	}
+	data.result = result
+	return nil
+}
+
+// txProof returns the merkle path of the transaction with the hash in the block
+func txProof(block *model.Block, hash []byte) (*txProofResult, error) {
+	if len(block.Data) == 0 {
+		return nil, errPrunedBody
+	}
+	binHeader, txs, err := protocol.SplitBlockData(block.Data)
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, tx := range txs {
+		txHash, err := crypto.Hash(tx)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("hashing transaction")
+			return nil, err
+		}
+		if bytes.Equal(txHash, hash) {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, errTxNotInBlock
+	}
+	mrklSlice, err := protocol.MerkleTreeItems(txs)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := protocol.MerkleTreeProof(mrklSlice, index)
+	if err != nil {
+		return nil, err
+	}
+	return &txProofResult{
+		BlockID:   block.ID,
+		BlockHash: hex.EncodeToString(block.Hash),
+		Header:    hex.EncodeToString(binHeader),
+		MrklRoot:  string(protocol.MerkleTreeRoot(mrklSlice)),
+		Data:      hex.EncodeToString(txs[index]),
+		Proof:     proof,
+	}, nil
+}
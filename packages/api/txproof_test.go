@@ -0,0 +1,56 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/protocol"
+)
+
+func TestTxProofPrunedBlock(t *testing.T) {
+	txs := [][]byte{[]byte("first tx"), []byte("second tx")}
+	var data bytes.Buffer
+	data.Write(converter.DecToBin(1, 2))
+	data.Write(converter.DecToBin(10, 4))
+	data.Write(converter.DecToBin(1500000000, 4))
+	data.Write(converter.DecToBin(1, 4))
+	data.Write(converter.EncodeLenInt64InPlace(1))
+	data.Write(converter.DecToBin(0, 1))
+	data.Write(converter.EncodeLengthPlusData([]byte("signature")))
+	for _, tx := range txs {
+		data.Write(converter.EncodeLengthPlusData(tx))
+	}
+	hash, err := crypto.Hash(txs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := &model.Block{ID: 10, Data: data.Bytes()}
+	result, err := txProof(block, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, err := protocol.MerkleTreeItems(txs[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := protocol.MerkleProofRoot(items[0], result.Proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(root) != result.MrklRoot || result.Data != hex.EncodeToString(txs[1]) {
+		t.Errorf("wrong proof %+v", result)
+	}
+	if _, err = txProof(block, []byte("unknown")); err != errTxNotInBlock {
+		t.Errorf("unknown transaction: %v", err)
+	}
+
+	block.Data = nil
+	if _, err = txProof(block, hash); err != errPrunedBody {
+		t.Errorf("pruned block: %v", err)
+	}
+}
@@ -34,14 +34,27 @@ import (
 )
 
 type txstatusError struct {
-	Type  string `json:"type,omitempty"`
-	Error string `json:"error,omitempty"`
+	Type  string   `json:"type,omitempty"`
+	Error string   `json:"error,omitempty"`
+	Func  string   `json:"func,omitempty"`
+	Line  uint32   `json:"line,omitempty"`
+	Stack []string `json:"stack,omitempty"`
 }
 
 type txstatusResult struct {
 	BlockID string         `json:"blockid"`
 	Message *txstatusError `json:"errmsg,omitempty"`
 	Result  string         `json:"result"`
+	// Reverted is the id of the block which has contained the transaction and has been rolled back
+	Reverted string `json:"reverted,omitempty"`
+	// Requeued is true if the reverted transaction is waiting for the new block
+	Requeued bool `json:"requeued,omitempty"`
+	// Confirmations is the count of nodes which have confirmed the block of the transaction
+	Confirmations int64 `json:"confirmations"`
+	// Final is true if the block has been confirmed by enough nodes and the transaction can't be reverted
+	Final bool `json:"final"`
+	// Fuel is the breakdown of the fuel spent by the contract and its nested calls
+	Fuel json.RawMessage `json:"fuel,omitempty"`
 }
 
 func getTxStatus(hash string, w http.ResponseWriter, logger *log.Entry) (*txstatusResult, error) {
@@ -60,9 +73,20 @@ func getTxStatus(hash string, w http.ResponseWriter, logger *log.Entry) (*txstat
 		logger.WithFields(log.Fields{"type": consts.NotFound, "key": []byte(converter.HexToBin(hash))}).Error("getting transaction status by hash")
 		return nil, errorAPI(w, `E_HASHNOTFOUND`, http.StatusBadRequest)
 	}
+	if ts.RevertedBlockID > 0 {
+		status.Reverted = converter.Int64ToStr(ts.RevertedBlockID)
+		status.Requeued = ts.BlockID == 0 && len(ts.Error) == 0
+	}
 	if ts.BlockID > 0 {
 		status.BlockID = converter.Int64ToStr(ts.BlockID)
 		status.Result = ts.Error
+		if len(ts.Fuel) > 0 {
+			status.Fuel = json.RawMessage(ts.Fuel)
+		}
+		if status.Confirmations, status.Final, err = getFinality(ts.BlockID); err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block confirmations")
+			return nil, errorAPI(w, err, http.StatusInternalServerError)
+		}
 	} else if len(ts.Error) > 0 {
 		if err := json.Unmarshal([]byte(ts.Error), &status.Message); err != nil {
 			logger.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "text": ts.Error, "error": err}).Warn("unmarshalling txstatus error")
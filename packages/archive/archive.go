@@ -0,0 +1,309 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package archive
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrPrunedBlock is returned if rollback data of the block have been removed
+	ErrPrunedBlock = errors.New("state of the block has been pruned")
+	// ErrFutureBlock is returned if the block hasn't been generated yet
+	ErrFutureBlock = errors.New("block has not been generated yet")
+	// ErrTableName is returned if the name of the table isn't a plain identifier
+	ErrTableName = errors.New("wrong name of table")
+)
+
+// IsEnabled returns true if the node is working in the archive mode
+func IsEnabled() bool {
+	return conf.Config.Archive.Interval > 0
+}
+
+// IsTime returns true if the checkpoint must be saved after the block
+func IsTime(blockID int64) bool {
+	return IsEnabled() && blockID%conf.Config.Archive.Interval == 0
+}
+
+// Checkpoint saves the values of rows which have been changed since the previous checkpoint.
+// It limits the count of rollback records which are applied for the reconstruction of a row
+func Checkpoint(transaction *model.DbTransaction, blockID int64) error {
+	changed, err := model.GetChangedRows(transaction, ``, blockID-conf.Config.Archive.Interval, blockID)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting changed rows")
+		return err
+	}
+	tables := make(map[string][]int64)
+	for _, item := range changed {
+		if !model.IsStateTable(item.NameTable) {
+			continue
+		}
+		tables[item.NameTable] = append(tables[item.NameTable], converter.StrToInt64(item.TableID))
+	}
+	for table, ids := range tables {
+		rows, err := getCurrentRows(transaction, table, ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			checkpoint := &model.ArchiveCheckpoint{
+				BlockID:   blockID,
+				NameTable: table,
+				TableID:   converter.Int64ToStr(id),
+			}
+			if row, ok := rows[id]; ok {
+				data, err := json.Marshal(row)
+				if err != nil {
+					log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling checkpoint row")
+					return err
+				}
+				checkpoint.Data = string(data)
+			}
+			if err = checkpoint.Create(transaction); err != nil {
+				log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("creating checkpoint")
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// GetRow returns the values of the row as they were after the block. It returns nil if the row didn't exist
+func GetRow(transaction *model.DbTransaction, tableName string, id, blockID int64) (map[string]string, error) {
+	if err := checkTableName(tableName); err != nil {
+		return nil, err
+	}
+	if err := checkBlock(transaction, blockID); err != nil {
+		return nil, err
+	}
+	rows, err := getRows(transaction, tableName, []int64{id}, blockID)
+	if err != nil {
+		return nil, err
+	}
+	if row, ok := rows[id]; ok {
+		return decodeRow(tableName, row), nil
+	}
+	return nil, nil
+}
+
+// GetRows returns the count of rows of the table after the block and the rows in the descending order of id
+func GetRows(transaction *model.DbTransaction, tableName string, blockID, offset, limit int64) (int64, []map[string]string, error) {
+	if err := checkTableName(tableName); err != nil {
+		return 0, nil, err
+	}
+	if err := checkBlock(transaction, blockID); err != nil {
+		return 0, nil, err
+	}
+	changed, err := model.GetChangedRows(transaction, tableName, blockID, 0)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": tableName}).Error("getting changed rows")
+		return 0, nil, err
+	}
+	ids := make([]int64, len(changed))
+	for i, item := range changed {
+		ids[i] = converter.StrToInt64(item.TableID)
+	}
+	count, err := model.GetRecordsCountTx(transaction, tableName)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": tableName}).Error("getting count of rows")
+		return 0, nil, err
+	}
+
+	// the rows which have been changed after the block are replaced with their previous values
+	query := fmt.Sprintf(`SELECT * FROM "%s"`, tableName)
+	args := []interface{}{}
+	if len(ids) > 0 {
+		current, err := getCurrentRows(transaction, tableName, ids)
+		if err != nil {
+			return 0, nil, err
+		}
+		count -= int64(len(current))
+		query += ` WHERE id NOT IN (?)`
+		args = append(args, ids)
+	}
+	list, err := model.GetAllTransaction(transaction, query+fmt.Sprintf(` ORDER BY id DESC LIMIT %d`, offset+limit), -1, args...)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": tableName}).Error("getting rows")
+		return 0, nil, err
+	}
+	if len(ids) > 0 {
+		rows, err := getRows(transaction, tableName, ids, blockID)
+		if err != nil {
+			return 0, nil, err
+		}
+		count += int64(len(rows))
+		for _, row := range rows {
+			list = append(list, decodeRow(tableName, row))
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return converter.StrToInt64(list[i][`id`]) > converter.StrToInt64(list[j][`id`])
+		})
+	}
+	if offset >= int64(len(list)) {
+		return count, []map[string]string{}, nil
+	}
+	if end := offset + limit; end < int64(len(list)) {
+		list = list[:end]
+	}
+	return count, list[offset:], nil
+}
+
+// checkTableName checks that the name can be inserted in queries as a quoted identifier
+func checkTableName(tableName string) error {
+	if len(tableName) == 0 || converter.EscapeName(tableName) != `"`+tableName+`"` {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "table": tableName}).Error("wrong name of table")
+		return ErrTableName
+	}
+	return nil
+}
+
+func checkBlock(transaction *model.DbTransaction, blockID int64) error {
+	infoBlock := &model.InfoBlock{}
+	if _, err := infoBlock.GetTx(transaction); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting info block")
+		return err
+	}
+	if blockID < infoBlock.PrunedBlockID {
+		return ErrPrunedBlock
+	}
+	if blockID > infoBlock.BlockID {
+		return ErrFutureBlock
+	}
+	return nil
+}
+
+// getCurrentRows returns the current values of the rows, the values of binary columns are encoded in hex
+// the same way as in rollback records
+func getCurrentRows(transaction *model.DbTransaction, tableName string, ids []int64) (map[int64]map[string]string, error) {
+	list, err := model.GetAllTransaction(transaction, fmt.Sprintf(`SELECT * FROM "%s" WHERE id IN (?)`, tableName), -1, ids)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": tableName}).Error("getting current rows")
+		return nil, err
+	}
+	rows := make(map[int64]map[string]string, len(list))
+	for _, row := range list {
+		for k, v := range row {
+			if converter.IsByteColumn(tableName, k) && v != "" && v != "NULL" {
+				row[k] = hex.EncodeToString([]byte(v))
+			}
+		}
+		rows[converter.StrToInt64(row[`id`])] = row
+	}
+	return rows, nil
+}
+
+// getRows reconstructs the rows after the block. The rollback records are applied to the nearest
+// checkpoint of the row or to the current values if the row has no checkpoint after the block
+func getRows(transaction *model.DbTransaction, tableName string, ids []int64, blockID int64) (map[int64]map[string]string, error) {
+	rows, err := getCurrentRows(transaction, tableName, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		var toID int64
+		tableID := converter.Int64ToStr(id)
+		row := rows[id]
+		checkpoint := &model.ArchiveCheckpoint{}
+		found, err := checkpoint.GetNext(transaction, tableName, tableID, blockID)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": tableName}).Error("getting checkpoint")
+			return nil, err
+		}
+		if found {
+			toID = checkpoint.BlockID
+			row = nil
+			if len(checkpoint.Data) > 0 {
+				if err = json.Unmarshal([]byte(checkpoint.Data), &row); err != nil {
+					log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling checkpoint row")
+					return nil, err
+				}
+			}
+		}
+		rollbackTxs, err := model.GetRowRollbackTxs(transaction, tableName, tableID, blockID, toID)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": tableName}).Error("getting rollback records of row")
+			return nil, err
+		}
+		if row, err = applyRollback(row, rollbackTxs); err != nil {
+			return nil, err
+		}
+		if row == nil {
+			delete(rows, id)
+		} else {
+			rows[id] = row
+		}
+	}
+	return rows, nil
+}
+
+// applyRollback returns the previous values of the row. The rollback records must be in the reverse order
+func applyRollback(row map[string]string, rollbackTxs []model.RollbackTx) (map[string]string, error) {
+	for _, rollbackTx := range rollbackTxs {
+		// the row has been inserted
+		if len(rollbackTx.Data) == 0 {
+			row = nil
+			continue
+		}
+		values, deleted, err := model.ParseRollbackData(rollbackTx.Data)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling rollback data")
+			return nil, err
+		}
+		// the data of deleted row contain all values
+		if deleted {
+			row = values
+			continue
+		}
+		prev := make(map[string]string, len(row))
+		for k, v := range row {
+			prev[k] = v
+		}
+		for k, v := range values {
+			prev[k] = v
+		}
+		row = prev
+	}
+	return row, nil
+}
+
+func decodeRow(tableName string, row map[string]string) map[string]string {
+	for k, v := range row {
+		if !converter.IsByteColumn(tableName, k) || v == "" || v == "NULL" {
+			continue
+		}
+		if data, err := hex.DecodeString(v); err == nil {
+			row[k] = string(data)
+		}
+	}
+	return row
+}
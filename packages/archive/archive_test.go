@@ -0,0 +1,101 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package archive
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+)
+
+func TestApplyRollback(t *testing.T) {
+	current := map[string]string{`id`: `5`, `name`: `c`, `amount`: `30`}
+	cases := []struct {
+		rollbackTxs []model.RollbackTx
+		want        map[string]string
+	}{
+		{nil, current},
+		{
+			[]model.RollbackTx{{Data: `{"amount":"20"}`}, {Data: `{"name":"a","amount":"10"}`}},
+			map[string]string{`id`: `5`, `name`: `a`, `amount`: `10`},
+		},
+		{
+			[]model.RollbackTx{{Data: `{"amount":"20"}`}, {Data: ``}},
+			nil,
+		},
+		{
+			[]model.RollbackTx{{Data: ``}, {Data: `{"op":"delete","row":{"id":"5","name":"b","amount":"1"}}`}, {Data: `{"amount":"0"}`}},
+			map[string]string{`id`: `5`, `name`: `b`, `amount`: `0`},
+		},
+		{
+			// the update of the column id isn't the deleted row
+			[]model.RollbackTx{{Data: `{"id":"4","amount":"20"}`}},
+			map[string]string{`id`: `4`, `name`: `c`, `amount`: `20`},
+		},
+		{
+			// the update of the columns op and row isn't the deleted row either
+			[]model.RollbackTx{{Data: `{"op":"delete","row":"b"}`}},
+			map[string]string{`id`: `5`, `name`: `c`, `amount`: `30`, `op`: `delete`, `row`: `b`},
+		},
+		{
+			// the deleted row of the table without id
+			[]model.RollbackTx{{Data: ``}, {Data: `{"op":"delete","row":{"name":"b"}}`}},
+			map[string]string{`name`: `b`},
+		},
+	}
+	for i, item := range cases {
+		row, err := applyRollback(current, item.rollbackTxs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(row, item.want) {
+			t.Errorf("case %d: got %v, want %v", i, row, item.want)
+		}
+	}
+	if current[`amount`] != `30` {
+		t.Error(`current row has been changed`)
+	}
+}
+
+func TestDecodeRow(t *testing.T) {
+	row := decodeRow(`1_keys`, map[string]string{`id`: `1`, `pub`: `616263`, `amount`: `10`})
+	if row[`pub`] != `abc` || row[`amount`] != `10` {
+		t.Errorf("wrong decoded row %v", row)
+	}
+}
+
+func TestCheckTableName(t *testing.T) {
+	for _, name := range []string{`1_keys`, `1_my-table`} {
+		if err := checkTableName(name); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	for _, name := range []string{``, `1_keys" WHERE false; DROP TABLE "1_keys`, `1_keys"`, `1_keys t`, `1_keys,1_pages`} {
+		if _, err := GetRow(nil, name, 1, 1); err != ErrTableName {
+			t.Errorf("GetRow %q: got %v, want %v", name, err, ErrTableName)
+		}
+		if _, _, err := GetRows(nil, name, 1, 0, 10); err != ErrTableName {
+			t.Errorf("GetRows %q: got %v, want %v", name, err, ErrTableName)
+		}
+	}
+}
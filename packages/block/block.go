@@ -2,13 +2,18 @@ package block
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/GenesisCommunity/go-genesis/packages/archive"
 	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
 	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/mempool"
 	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/pruning"
+	"github.com/GenesisCommunity/go-genesis/packages/snapshot"
 	"github.com/GenesisCommunity/go-genesis/packages/transaction"
 	"github.com/GenesisCommunity/go-genesis/packages/transaction/custom"
 	"github.com/GenesisCommunity/go-genesis/packages/utils"
@@ -24,8 +29,11 @@ type Block struct {
 	BinData      []byte
 	Transactions []*transaction.Transaction
 	SysUpdate    bool
-	GenBlock     bool // it equals true when we are generating a new block
-	StopCount    int  // The count of good tx in the block
+	GenBlock     bool   // it equals true when we are generating a new block
+	StopCount    int    // The count of good tx in the block
+	StateRoot    []byte // the state root after playing the block
+	// PrevStateRoot is the state root of the previous block if it hasn't been written into the blockchain yet
+	PrevStateRoot []byte
 }
 
 func (b Block) String() string {
@@ -88,6 +96,18 @@ func (b *Block) PlaySafe() error {
 		return err
 	}
 
+	if err := b.CalcStateRoot(dbTransaction); err != nil {
+		dbTransaction.Rollback()
+		return err
+	}
+
+	if archive.IsTime(b.Header.BlockID) {
+		if err := archive.Checkpoint(dbTransaction, b.Header.BlockID); err != nil {
+			dbTransaction.Rollback()
+			return err
+		}
+	}
+
 	if err := UpdBlockInfo(dbTransaction, b); err != nil {
 		dbTransaction.Rollback()
 		return err
@@ -99,6 +119,7 @@ func (b *Block) PlaySafe() error {
 	}
 
 	dbTransaction.Commit()
+	mempool.DropReplaced(b.Transactions)
 	if b.SysUpdate {
 		b.SysUpdate = false
 		if err = syspar.SysUpdate(nil); err != nil {
@@ -106,6 +127,14 @@ func (b *Block) PlaySafe() error {
 			return err
 		}
 	}
+	if snapshot.IsTime(b.Header.BlockID) {
+		if err = snapshot.Create(b.Header.BlockID); err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("starting snapshot")
+		}
+	}
+	if pruning.IsTime(b.Header.BlockID) {
+		pruning.Start(b.Header.BlockID)
+	}
 	return nil
 }
 
@@ -214,8 +243,15 @@ func (b *Block) Play(dbTransaction *model.DbTransaction) error {
 		}
 
 		// update status
+		var fuel []byte
+		if t.TxFuelInfo != nil {
+			if fuel, err = json.Marshal(t.TxFuelInfo); err != nil {
+				logger.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err, "tx_hash": t.TxHash}).Error("marshalling fuel info")
+				return err
+			}
+		}
 		ts := &model.TransactionStatus{}
-		if err := ts.UpdateBlockMsg(t.DbTransaction, b.Header.BlockID, msg, t.TxHash); err != nil {
+		if err := ts.UpdateBlockMsg(t.DbTransaction, b.Header.BlockID, msg, string(fuel), t.TxHash); err != nil {
 			logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "tx_hash": t.TxHash}).Error("updating transaction status block id")
 			return err
 		}
@@ -245,6 +281,10 @@ func (b *Block) Check() error {
 		return nil
 	}
 
+	if err := b.checkStateRoot(); err != nil {
+		return err
+	}
+
 	// is this block too early? Allowable error = error_time
 	if b.PrevHeader != nil {
 		if b.Header.BlockID != b.PrevHeader.BlockID+1 {
@@ -325,8 +365,7 @@ func (b *Block) CheckHash() (bool, error) {
 			return false, utils.ErrInfo(fmt.Errorf("empty nodePublicKey"))
 		}
 		// check the signature
-		forSign := fmt.Sprintf("0,%d,%x,%d,%d,%d,%d,%s", b.Header.BlockID, b.PrevHeader.Hash,
-			b.Header.Time, b.Header.EcosystemID, b.Header.KeyID, b.Header.NodePosition, b.MrklRoot)
+		forSign := b.Header.ForSign(b.PrevHeader.Hash, b.MrklRoot)
 
 		resultCheckSign, err := utils.CheckSign([][]byte{nodePublicKey}, forSign, b.Header.Sign, true)
 		if err != nil {
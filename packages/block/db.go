@@ -19,10 +19,7 @@ import (
 func UpdBlockInfo(dbTransaction *model.DbTransaction, block *Block) error {
 	blockID := block.Header.BlockID
 	// for the local tests
-	forSha := fmt.Sprintf("%d,%x,%s,%d,%d,%d,%d", blockID, block.PrevHeader.Hash, block.MrklRoot,
-		block.Header.Time, block.Header.EcosystemID, block.Header.KeyID, block.Header.NodePosition)
-
-	hash, err := crypto.DoubleHash([]byte(forSha))
+	hash, err := crypto.DoubleHash([]byte(block.Header.ForSha(block.PrevHeader.Hash, block.MrklRoot)))
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Fatal("double hashing block")
 	}
@@ -105,6 +102,7 @@ func InsertIntoBlockchain(transaction *model.DbTransaction, block *Block) error
 		Time:          block.Header.Time,
 		RollbacksHash: rollbackTxsHash,
 		Tx:            int32(len(block.Transactions)),
+		StateRoot:     block.StateRoot,
 	}
 	blockTimeCalculator, err := utils.BuildBlockTimeCalculator(nil)
 	if err != nil {
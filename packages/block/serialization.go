@@ -35,11 +35,8 @@ func MarshallBlock(header *utils.BlockData, trData [][]byte, prevHash []byte, ke
 		}
 		mrklRoot := utils.MerkleTreeRoot(mrklArray)
 
-		forSign := fmt.Sprintf("0,%d,%x,%d,%d,%d,%d,%s",
-			header.BlockID, prevHash, header.Time, header.EcosystemID, header.KeyID, header.NodePosition, mrklRoot)
-
 		var err error
-		signed, err = crypto.Sign(key, forSign)
+		signed, err = crypto.Sign(key, header.ForSign(prevHash, mrklRoot))
 		if err != nil {
 			logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("signing blocko")
 			return nil, err
@@ -54,6 +51,9 @@ func MarshallBlock(header *utils.BlockData, trData [][]byte, prevHash []byte, ke
 	buf.Write(converter.DecToBin(header.EcosystemID, 4))
 	buf.Write(converter.EncodeLenInt64InPlace(header.KeyID))
 	buf.Write(converter.DecToBin(header.NodePosition, 1))
+	if header.Version >= consts.BlockVersionStateRoot {
+		buf.Write(converter.EncodeLengthPlusData(header.StateRoot))
+	}
 	buf.Write(converter.EncodeLengthPlusData(signed))
 	// data
 	buf.Write(blockDataTx)
@@ -0,0 +1,151 @@
+package block
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CalcStateRoot calculates the state root after playing the block. The root is the hash of
+// the state root of the previous block and the current values of rows which have been changed by the block.
+// The blocks before state_root_block_id have the empty state root. The calculated root is stored
+// in block_chain and is committed by the header of the next block
+func (b *Block) CalcStateRoot(dbTransaction *model.DbTransaction) error {
+	if !syspar.HasStateRoot(b.Header.BlockID) {
+		b.StateRoot = []byte{}
+		return nil
+	}
+	logger := b.GetLogger()
+	prevRoot, err := b.getPrevStateRoot(dbTransaction)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting state root of previous block")
+		return err
+	}
+	rollbackTx := &model.RollbackTx{}
+	rollbackTxs, err := rollbackTx.GetBlockRollbackTransactions(dbTransaction, b.Header.BlockID)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block rollback txs")
+		return err
+	}
+	changes, err := getStateChanges(dbTransaction, rollbackTxs)
+	if err != nil {
+		return err
+	}
+	changesHash, err := crypto.Hash(changes)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("hashing state changes")
+		return err
+	}
+	b.StateRoot, err = crypto.DoubleHash(append(append([]byte{}, prevRoot...), changesHash...))
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("double hashing state root")
+		return err
+	}
+	return nil
+}
+
+func (b *Block) getPrevStateRoot(dbTransaction *model.DbTransaction) ([]byte, error) {
+	if b.Header.BlockID <= syspar.GetStateRootBlockID() {
+		// the chain of state roots starts from the empty root
+		return []byte{}, nil
+	}
+	if b.PrevStateRoot != nil {
+		return b.PrevStateRoot, nil
+	}
+	return model.GetStateRoot(dbTransaction, b.Header.BlockID-1)
+}
+
+// checkStateRoot compares the state root in the header with our state root after the previous block.
+// The header is signed before its transactions are played so the state root lags one block behind:
+// the divergence of block N is detected when block N+1 is checked
+func (b *Block) checkStateRoot() error {
+	hasStateRoot := syspar.HasStateRoot(b.Header.BlockID)
+	if hasStateRoot != (b.Header.Version >= consts.BlockVersionStateRoot) {
+		b.GetLogger().WithFields(log.Fields{"type": consts.BlockError, "version": b.Header.Version}).Error("incorrect block version")
+		return utils.ErrInfo(fmt.Errorf("incorrect version %d of block %d", b.Header.Version, b.Header.BlockID))
+	}
+	if !hasStateRoot {
+		return nil
+	}
+	prevRoot, err := b.getPrevStateRoot(nil)
+	if err != nil {
+		b.GetLogger().WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting state root of previous block")
+		return utils.ErrInfo(err)
+	}
+	if !bytes.Equal(prevRoot, b.Header.StateRoot) {
+		b.GetLogger().WithFields(log.Fields{"type": consts.BlockError, "diverged_block_id": b.Header.BlockID - 1,
+			"state_root": fmt.Sprintf("%x", prevRoot), "header_state_root": fmt.Sprintf("%x", b.Header.StateRoot)}).Error("state diverges after block")
+		return utils.ErrInfo(fmt.Errorf("state root of block %d does not match", b.Header.BlockID-1))
+	}
+	return nil
+}
+
+// getStateChanges returns the current values of the rows which are listed in rollback_tx of the block.
+// The rows are sorted by the name of the table and id so all nodes get the same data
+func getStateChanges(dbTransaction *model.DbTransaction, rollbackTxs []model.RollbackTx) ([]byte, error) {
+	rows := make(map[string]map[int64]bool)
+	schemas := make(map[string][]string)
+	for _, item := range rollbackTxs {
+		if !model.IsStateTable(item.NameTable) {
+			continue
+		}
+		if item.TableID == model.RollbackSchemaID {
+			schemas[item.NameTable] = append(schemas[item.NameTable], item.Data)
+			continue
+		}
+		if rows[item.NameTable] == nil {
+			rows[item.NameTable] = make(map[int64]bool)
+		}
+		rows[item.NameTable][converter.StrToInt64(item.TableID)] = true
+	}
+
+	tables := make([]string, 0, len(rows)+len(schemas))
+	for table := range rows {
+		tables = append(tables, table)
+	}
+	for table := range schemas {
+		if rows[table] == nil {
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+
+	var buf bytes.Buffer
+	for _, table := range tables {
+		sort.Strings(schemas[table])
+		for _, schema := range schemas[table] {
+			fmt.Fprintf(&buf, "%s,%s,%s\n", table, model.RollbackSchemaID, schema)
+		}
+		if rows[table] == nil {
+			continue
+		}
+		ids := make([]int64, 0, len(rows[table]))
+		for id := range rows[table] {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		values, err := model.GetRowsJSON(dbTransaction, table, ids)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("getting changed rows")
+			return nil, err
+		}
+		for _, id := range ids {
+			value, ok := values[id]
+			if !ok {
+				// the row has been deleted
+				value = `null`
+			}
+			fmt.Fprintf(&buf, "%s,%d,%s\n", table, id, value)
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -93,6 +93,35 @@ type TokenMovementConfig struct {
 	Subject  string
 }
 
+// SnapshotConfig represents parameters of state snapshots
+type SnapshotConfig struct {
+	Interval int64 // the interval of blocks between snapshots, 0 disables snapshots
+	Keep     int   // the count of the last snapshots which are stored
+}
+
+// PruningConfig represents parameters of the pruning mode
+type PruningConfig struct {
+	Blocks int64 // the count of the last blocks which keep rollback data, 0 disables pruning
+	Bodies bool  // the bodies of pruned blocks are removed too
+}
+
+// ArchiveConfig represents parameters of the archive mode
+type ArchiveConfig struct {
+	Interval int64 // the interval of blocks between checkpoints of changed rows, 0 disables the archive mode
+}
+
+// MempoolConfig represents parameters of the pool of pending transactions
+type MempoolConfig struct {
+	MaxSize int64 // the max size of pending transactions in bytes
+	MaxAge  int64 // the max time in seconds which the transaction can wait for the block
+}
+
+// PeersConfig represents parameters of the discovery of peers
+type PeersConfig struct {
+	Seeds         []string // the addresses of nodes which are asked for peers when the address book is empty
+	PublicAddress string   // the tcp address of the node which is announced to other peers, empty address isn't announced
+}
+
 // GlobalConfig is storing all startup config as global struct
 type GlobalConfig struct {
 	KeyID        int64  `toml:"-"`
@@ -112,15 +141,23 @@ type GlobalConfig struct {
 	RunningMode       string
 
 	MaxPageGenerationTime int64 // in milliseconds
+	MaxReorgDepth         int64 // the max count of blocks which can be rolled back in the case of fork, 0 means no limit
+	FinalityPercent       int64 // the percent of other full nodes which must confirm the block to consider it final
 
 	TCPServer HostPort
 	HTTP      HostPort
+	Debugger  HostPort // the address of the debugger of contracts in VDE mode, 0 port disables the debugger
 
 	DB            DBConfig
 	StatsD        StatsDConfig
 	Centrifugo    CentrifugoConfig
 	Log           LogConfig
 	TokenMovement TokenMovementConfig
+	Snapshot      SnapshotConfig
+	Pruning       PruningConfig
+	Archive       ArchiveConfig
+	Mempool       MempoolConfig
+	Peers         PeersConfig
 
 	NodesAddr []string
 }
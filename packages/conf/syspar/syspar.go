@@ -83,6 +83,8 @@ const (
 	NodeBanTime = `node_ban_time`
 	// LocalNodeBanTime is value of local ban time for bad nodes (in ms)
 	LocalNodeBanTime = `local_node_ban_time`
+	// StateRootBlockID is the id of the first block which has the state root, 0 means the state root is off
+	StateRootBlockID = `state_root_block_id`
 )
 
 var (
@@ -448,6 +450,17 @@ func GetRbBlocks1() int64 {
 	return SysInt64(RbBlocks1)
 }
 
+// GetStateRootBlockID returns the id of the first block which has the state root
+func GetStateRootBlockID() int64 {
+	return SysInt64(StateRootBlockID)
+}
+
+// HasStateRoot returns true if the state root is calculated for the block
+func HasStateRoot(blockID int64) bool {
+	stateRootBlockID := GetStateRootBlockID()
+	return stateRootBlockID > 0 && blockID >= stateRootBlockID
+}
+
 // HasSys returns boolean whether this system parameter exists
 func HasSys(name string) bool {
 	mutex.RLock()
@@ -0,0 +1,27 @@
+package syspar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasStateRoot(t *testing.T) {
+	defer delete(cache, StateRootBlockID)
+
+	cases := []struct {
+		value   string
+		blockID int64
+		has     bool
+	}{
+		{value: ``, blockID: 100, has: false},
+		{value: `0`, blockID: 100, has: false},
+		{value: `100`, blockID: 99, has: false},
+		{value: `100`, blockID: 100, has: true},
+		{value: `100`, blockID: 101, has: true},
+	}
+	for _, v := range cases {
+		cache[StateRootBlockID] = v.value
+		assert.Equal(t, v.has, HasStateRoot(v.blockID), "state_root_block_id=%q block %d", v.value, v.blockID)
+	}
+}
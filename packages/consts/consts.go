@@ -26,11 +26,14 @@ import (
 )
 
 // VERSION is current version
-const VERSION = "0.9.4"
+const VERSION = "0.9.15"
 
 // BLOCK_VERSION is block version
 const BLOCK_VERSION = 1
 
+// BlockVersionStateRoot is the first version of block which contains the state root
+const BlockVersionStateRoot = 2
+
 // NETWORK_ID is id of network
 const NETWORK_ID = 1
 
@@ -129,6 +132,9 @@ const DefaultPidFilename = "go-genesis.pid"
 // DefaultLockFilename is default filename of lock file
 const DefaultLockFilename = "go-genesis.lock"
 
+// DefaultFinalityPercent is the default percent of other full nodes which must confirm the block to consider it final
+const DefaultFinalityPercent = 67
+
 // FirstBlockFilename name of first block binary file
 const FirstBlockFilename = "1block"
 
@@ -165,6 +171,9 @@ const TxRequestExpire = 1 * time.Minute
 // DefaultTempDirName is default name of temporary directory
 const DefaultTempDirName = "genesis-temp"
 
+// SnapshotsDirName is name of directory of state snapshots
+const SnapshotsDirName = "snapshots"
+
 // DefaultVDE allways is 1
 const DefaultVDE = 1
 
@@ -0,0 +1,79 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package contracttest
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/GenesisCommunity/go-genesis/packages/script"
+)
+
+// registerAsserts adds the assert functions to the virtual machine
+func registerAsserts(vm *script.VM) {
+	vm.Extend(&script.ExtendData{Objects: map[string]interface{}{
+		"AssertEqual":    AssertEqual,
+		"AssertNotEqual": AssertNotEqual,
+		"AssertTrue":     AssertTrue,
+		"AssertFalse":    AssertFalse,
+	}})
+}
+
+func isEqual(expected, actual interface{}) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+// AssertEqual returns an error if the values are not equal. The values of different types
+// are compared as strings
+func AssertEqual(expected, actual interface{}) error {
+	if !isEqual(expected, actual) {
+		return fmt.Errorf(`assertion failed: expected %v, got %v`, expected, actual)
+	}
+	return nil
+}
+
+// AssertNotEqual returns an error if the values are equal
+func AssertNotEqual(expected, actual interface{}) error {
+	if isEqual(expected, actual) {
+		return fmt.Errorf(`assertion failed: unexpected %v`, actual)
+	}
+	return nil
+}
+
+// AssertTrue returns an error if the condition is false
+func AssertTrue(cond bool) error {
+	if !cond {
+		return fmt.Errorf(`assertion failed: condition is false`)
+	}
+	return nil
+}
+
+// AssertFalse returns an error if the condition is true
+func AssertFalse(cond bool) error {
+	if cond {
+		return fmt.Errorf(`assertion failed: condition is true`)
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+// MIT License
+//
+// # Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package contracttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/script"
+	"github.com/GenesisCommunity/go-genesis/packages/smart"
+	"github.com/GenesisCommunity/go-genesis/packages/utils"
+	"github.com/GenesisCommunity/go-genesis/packages/utils/tx"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// TestPrefix is the prefix of the names of test contracts
+	TestPrefix = `Test`
+
+	// settings of test contracts which override the default values
+	setKeyID       = `key_id`
+	setEcosystemID = `ecosystem_id`
+	setBlockTime   = `block_time`
+)
+
+// Env is the environment for running tests of contracts. It uses the temporary schema
+// of the database which is dropped on closing
+type Env struct {
+	KeyID       int64 // the default value of $key_id
+	EcosystemID int64 // the default value of $ecosystem_id
+	BlockTime   int64 // the default value of $block_time, the current time is used if it equals 0
+
+	cfg    conf.DBConfig
+	schema string
+	tests  []string
+}
+
+// Result is the result of running the test contract
+type Result struct {
+	Name     string
+	Passed   bool
+	Error    string
+	Fuel     int64
+	Duration time.Duration
+}
+
+// NewEnv creates the temporary schema in the database, fills it like the first block does
+// and loads the system contracts into the virtual machine
+func NewEnv(cfg conf.DBConfig, keyID int64) (*Env, error) {
+	env := &Env{
+		KeyID:       keyID,
+		EcosystemID: 1,
+		cfg:         cfg,
+		schema:      fmt.Sprintf(`contracttest_%d`, time.Now().UnixNano()),
+	}
+	if err := model.GormInit(cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name); err != nil {
+		return nil, err
+	}
+	err := model.DBConn.Exec(`CREATE SCHEMA "` + env.schema + `"`).Error
+	model.GormClose()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "schema": env.schema}).Error("creating test schema")
+		return nil, err
+	}
+	if err = model.GormInitSchema(cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, env.schema); err != nil {
+		env.dropSchema()
+		return nil, err
+	}
+	if err = env.init(); err != nil {
+		env.Close()
+		return nil, err
+	}
+	registerAsserts(smart.GetVM())
+	return env, nil
+}
+
+func (env *Env) init() error {
+	if err := model.ExecSchema(); err != nil {
+		return err
+	}
+	if err := model.ExecSchemaEcosystem(nil, 1, env.KeyID, ``, env.KeyID); err != nil {
+		return err
+	}
+	sp := &model.StateParameter{}
+	sp.SetTablePrefix(`1`)
+	if _, err := sp.Get(nil, model.ParamMoneyDigit); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting ecosystem param")
+		return err
+	}
+	amount := decimal.New(consts.FounderAmount, int32(converter.StrToInt64(sp.Value))).String()
+	err := model.DBConn.Exec(`insert into "1_keys" (id,pub,amount) values(?, ?, ?)`, env.KeyID, []byte{}, amount).Error
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("inserting founder key")
+		return err
+	}
+	if err = syspar.SysUpdate(nil); err != nil {
+		return err
+	}
+	return smart.LoadContracts(nil)
+}
+
+// Close drops the temporary schema and closes the connection to the database
+func (env *Env) Close() {
+	model.GormClose()
+	env.dropSchema()
+}
+
+func (env *Env) dropSchema() {
+	cfg := env.cfg
+	if err := model.GormInit(cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name); err != nil {
+		return
+	}
+	if err := model.DBConn.Exec(`DROP SCHEMA "` + env.schema + `" CASCADE`).Error; err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "schema": env.schema}).Error("dropping test schema")
+	}
+	model.GormClose()
+}
+
+// LoadFiles compiles the contracts from the specified files into the virtual machine.
+// The contracts whose names start with Test are collected as tests
+func (env *Env) LoadFiles(files ...string) error {
+	vm := smart.GetVM()
+	vm.Extern = true
+	defer smart.ExternOff()
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err, "file": file}).Error("reading contract file")
+			return err
+		}
+		if err = env.Load(string(data)); err != nil {
+			return fmt.Errorf(`%s: %v`, file, err)
+		}
+	}
+	return nil
+}
+
+// Load compiles the source code of contracts into the virtual machine
+func (env *Env) Load(src string) error {
+	names, err := script.ContractsList(src)
+	if err != nil {
+		return err
+	}
+	if err = smart.Compile(src, &script.OwnerInfo{StateID: uint32(env.EcosystemID)}); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, TestPrefix) && smart.GetContract(name, uint32(env.EcosystemID)) != nil {
+			env.tests = append(env.tests, name)
+		}
+	}
+	return nil
+}
+
+// Tests returns the names of the loaded test contracts
+func (env *Env) Tests() []string {
+	return env.tests
+}
+
+// Run runs the loaded test contracts. If filter is not empty then only the tests
+// which contain it in the name are run
+func (env *Env) Run(filter string) []*Result {
+	results := make([]*Result, 0, len(env.tests))
+	for _, name := range env.tests {
+		if len(filter) > 0 && !strings.Contains(name, filter) {
+			continue
+		}
+		results = append(results, env.RunTest(name))
+	}
+	return results
+}
+
+// RunTest runs the specified test contract in the transaction which is rolled back
+// after finishing, so tests don't affect each other
+func (env *Env) RunTest(name string) *Result {
+	result := &Result{Name: name}
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	contract := smart.GetContract(name, uint32(env.EcosystemID))
+	if contract == nil {
+		result.Error = fmt.Sprintf(`unknown contract %s`, name)
+		return result
+	}
+	info := contract.Block.Info.(*script.ContractInfo)
+	keyID, ecosystemID, blockTime := env.KeyID, env.EcosystemID, env.BlockTime
+	if blockTime == 0 {
+		blockTime = time.Now().Unix()
+	}
+	for key, val := range info.Settings {
+		switch key {
+		case setKeyID:
+			keyID = converter.StrToInt64(fmt.Sprint(val))
+		case setEcosystemID:
+			ecosystemID = converter.StrToInt64(fmt.Sprint(val))
+		case setBlockTime:
+			blockTime = converter.StrToInt64(fmt.Sprint(val))
+		}
+	}
+
+	dbTransaction, err := model.StartTransaction()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer dbTransaction.Rollback()
+
+	sc := smart.SmartContract{
+		TxSmart: tx.SmartContract{
+			Header: tx.Header{
+				Type:        int(info.ID),
+				Time:        blockTime,
+				EcosystemID: ecosystemID,
+				KeyID:       keyID,
+			},
+		},
+		TxData:        make(map[string]interface{}),
+		TxContract:    contract,
+		BlockData:     &utils.BlockData{BlockID: 1, Time: blockTime, EcosystemID: ecosystemID, KeyID: keyID},
+		DbTransaction: dbTransaction,
+	}
+	_, err = sc.RunContract(smart.CallInit | smart.CallCondition | smart.CallAction)
+	result.Fuel = sc.TxFuel
+	if err != nil {
+		result.Error = errorText(err)
+		return result
+	}
+	result.Passed = true
+	return result
+}
+
+// errorText returns the text of the contract error with the position where it has occurred
+func errorText(err error) string {
+	var vmErr script.VMError
+	if json.Unmarshal([]byte(err.Error()), &vmErr) != nil || len(vmErr.Error) == 0 {
+		return err.Error()
+	}
+	if len(vmErr.Func) == 0 {
+		return vmErr.Error
+	}
+	return fmt.Sprintf(`%s [%s:%d]`, vmErr.Error, vmErr.Func, vmErr.Line)
+}
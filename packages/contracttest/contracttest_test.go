@@ -0,0 +1,110 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package contracttest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/smart"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestAsserts(t *testing.T) {
+	if err := AssertEqual(int64(10), decimal.New(10, 0)); err != nil {
+		t.Error(err)
+	}
+	if err := AssertEqual(`10`, int64(10)); err != nil {
+		t.Error(err)
+	}
+	if err := AssertEqual(int64(1), int64(2)); err == nil || err.Error() != `assertion failed: expected 1, got 2` {
+		t.Errorf(`wrong error %v`, err)
+	}
+	if err := AssertNotEqual(`a`, `b`); err != nil {
+		t.Error(err)
+	}
+	if err := AssertNotEqual(`a`, `a`); err == nil {
+		t.Error(`AssertNotEqual must fail`)
+	}
+	if err := AssertTrue(true); err != nil {
+		t.Error(err)
+	}
+	if err := AssertTrue(false); err == nil {
+		t.Error(`AssertTrue must fail`)
+	}
+	if err := AssertFalse(false); err != nil {
+		t.Error(err)
+	}
+	if err := AssertFalse(true); err == nil {
+		t.Error(`AssertFalse must fail`)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	env := &Env{EcosystemID: 1}
+	registerAsserts(smart.GetVM())
+	err := env.Load(`contract Sum {
+		data {
+			A int
+			B int
+		}
+		action {
+			$result = $A + $B
+		}
+	}
+	func TestHelper() int {
+		return 1
+	}
+	contract TestSum {
+		settings {
+			key_id = 100
+		}
+		action {
+			AssertEqual(3, Sum("A,B", 1, 2))
+		}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tests := env.Tests(); len(tests) != 1 || tests[0] != `TestSum` {
+		t.Errorf(`wrong tests %v`, tests)
+	}
+	if err = env.Load(`contract TestWrong { action { AssertEqual(1) `); err == nil {
+		t.Error(`compile error expected`)
+	}
+}
+
+func TestErrorText(t *testing.T) {
+	for _, item := range []struct {
+		err  string
+		want string
+	}{
+		{`plain error`, `plain error`},
+		{`{"type":"panic","error":"division by zero","func":"TestDiv","line":3}`, `division by zero [TestDiv:3]`},
+		{`{"type":"error","error":"not found"}`, `not found`},
+	} {
+		if got := errorText(errors.New(item.err)); got != item.want {
+			t.Errorf(`wrong error text %s != %s`, got, item.want)
+		}
+	}
+}
@@ -118,7 +118,7 @@ func Decrypt(msg []byte, key []byte, iv []byte) ([]byte, error) {
 	}
 }
 
-// SharedEncrypt creates a shared key and encrypts text. The first 32 characters are the created public key.
+// SharedEncrypt creates a shared key and encrypts text. The first 64 bytes are the created public key.
 // The cipher text can be only decrypted with the original private key.
 func SharedEncrypt(public, text []byte) ([]byte, error) {
 	priv, pub, err := GenBytesKeys()
@@ -129,8 +129,23 @@ func SharedEncrypt(public, text []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	val, err := Encrypt(shared, text, pub)
-	return val, err
+	val, err := Encrypt(text, shared, nil)
+	if err != nil {
+		return nil, err
+	}
+	return append(pub, val...), nil
+}
+
+// SharedDecrypt decrypts the cipher text which has been created by SharedEncrypt for the public key of private.
+func SharedDecrypt(private, cipherText []byte) ([]byte, error) {
+	if len(cipherText) < consts.PubkeySizeLength {
+		return nil, ErrDecryptingEmpty
+	}
+	shared, err := getSharedKey(private, cipherText[:consts.PubkeySizeLength])
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(cipherText[consts.PubkeySizeLength:], shared, nil)
 }
 
 // GenBytesKeys generates a random pair of ECDSA private and public binary keys.
@@ -146,7 +161,7 @@ func GenBytesKeys() ([]byte, []byte, error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	return private.D.Bytes(), append(converter.FillLeft(private.PublicKey.X.Bytes()), converter.FillLeft(private.PublicKey.Y.Bytes())...), nil
+	return converter.FillLeft(private.D.Bytes()), append(converter.FillLeft(private.PublicKey.X.Bytes()), converter.FillLeft(private.PublicKey.Y.Bytes())...), nil
 }
 
 // GenHexKeys generates a random pair of ECDSA private and public hex keys.
@@ -122,6 +122,12 @@ func hashSHA3256(msg []byte) []byte {
 	return hash[:]
 }
 
+// SHA3256 returns SHA3-256 hash of passed bytes
+func SHA3256(msg []byte) []byte {
+	hash := sha3.Sum256(msg)
+	return hash[:]
+}
+
 func NewHash() hash.Hash {
 	return sha256.New()
 }
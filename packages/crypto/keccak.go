@@ -0,0 +1,102 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package crypto
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The vendored golang.org/x/crypto/sha3 has only the standard SHA3 padding, so the legacy Keccak
+// which is used by Ethereum is implemented here
+const keccakRate = 136
+
+var (
+	keccakRC = [24]uint64{
+		0x0000000000000001, 0x0000000000008082, 0x800000000000808A, 0x8000000080008000,
+		0x000000000000808B, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+		0x000000000000008A, 0x0000000000000088, 0x0000000080008009, 0x000000008000000A,
+		0x000000008000808B, 0x800000000000008B, 0x8000000000008089, 0x8000000000008003,
+		0x8000000000008002, 0x8000000000000080, 0x000000000000800A, 0x800000008000000A,
+		0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+	}
+	keccakRotc = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakPiln = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// keccakF1600 applies the Keccak permutation to the state
+func keccakF1600(a *[25]uint64) {
+	var c [5]uint64
+	for round := 0; round < 24; round++ {
+		// theta
+		for x := 0; x < 5; x++ {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			d := c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				a[y+x] ^= d
+			}
+		}
+		// rho and pi
+		t := a[1]
+		for i := 0; i < 24; i++ {
+			j := keccakPiln[i]
+			t, a[j] = a[j], bits.RotateLeft64(t, keccakRotc[i])
+		}
+		// chi
+		for y := 0; y < 25; y += 5 {
+			copy(c[:], a[y:y+5])
+			for x := 0; x < 5; x++ {
+				a[y+x] = c[x] ^ (^c[(x+1)%5] & c[(x+2)%5])
+			}
+		}
+		// iota
+		a[0] ^= keccakRC[round]
+	}
+}
+
+// Keccak256 returns the legacy Keccak-256 hash of msg
+func Keccak256(msg []byte) []byte {
+	var state [25]uint64
+	block := make([]byte, keccakRate)
+	absorb := func(data []byte) {
+		for i := 0; i < keccakRate/8; i++ {
+			state[i] ^= binary.LittleEndian.Uint64(data[i*8:])
+		}
+		keccakF1600(&state)
+	}
+	for ; len(msg) >= keccakRate; msg = msg[keccakRate:] {
+		absorb(msg)
+	}
+	copy(block, msg)
+	block[len(msg)] = 0x01
+	block[keccakRate-1] |= 0x80
+	absorb(block)
+
+	out := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(out[i*8:], state[i])
+	}
+	return out
+}
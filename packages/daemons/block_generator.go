@@ -34,6 +34,7 @@ import (
 
 	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/mempool"
 	"github.com/GenesisCommunity/go-genesis/packages/model"
 	"github.com/GenesisCommunity/go-genesis/packages/utils"
 
@@ -124,6 +125,16 @@ func BlockGenerator(ctx context.Context, d *daemon) error {
 		NodePosition: nodePosition,
 		Version:      consts.BLOCK_VERSION,
 	}
+	if syspar.HasStateRoot(header.BlockID) {
+		header.Version = consts.BlockVersionStateRoot
+		if header.BlockID > syspar.GetStateRootBlockID() {
+			header.StateRoot, err = model.GetStateRoot(nil, prevBlock.BlockID)
+			if err != nil {
+				d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting state root of previous block")
+				return err
+			}
+		}
+	}
 
 	timeToGenerate, err = blockTimeCalculator.SetClock(&utils.ClockWrapper{}).TimeToGenerate(nodePosition)
 	if err != nil {
@@ -169,9 +180,8 @@ func processTransactions(logger *log.Entry) ([]*model.Transaction, error) {
 		return nil, err
 	}
 
-	trs, err := model.GetAllUnusedTransactions(syspar.GetMaxTxCount())
+	trs, err := mempool.Pending(syspar.GetMaxTxCount())
 	if err != nil {
-		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting all unused transactions")
 		return nil, err
 	}
 
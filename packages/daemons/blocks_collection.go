@@ -28,14 +28,17 @@ import (
 	"io/ioutil"
 	"time"
 
-	"github.com/AplaProject/go-apla/packages/rollback"
+	"github.com/GenesisCommunity/go-genesis/packages/rollback"
+	"github.com/GenesisCommunity/go-genesis/packages/archive"
 	"github.com/GenesisCommunity/go-genesis/packages/block"
 	"github.com/GenesisCommunity/go-genesis/packages/conf"
 	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
 	"github.com/GenesisCommunity/go-genesis/packages/converter"
 	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/mempool"
 	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/peers"
 	"github.com/GenesisCommunity/go-genesis/packages/service"
 	"github.com/GenesisCommunity/go-genesis/packages/tcpserver"
 	"github.com/GenesisCommunity/go-genesis/packages/transaction"
@@ -77,6 +80,11 @@ func InitialLoad(logger *log.Entry) error {
 }
 
 func blocksCollection(ctx context.Context, d *daemon) (err error) {
+	// the halted node doesn't follow the blockchain until it is resumed by the administrator
+	if service.NodePauseType() == service.PauseTypeDeepReorg {
+		return nil
+	}
+
 	hosts, err := filterBannedHosts(syspar.GetRemoteHosts())
 	if err != nil {
 		return err
@@ -101,9 +109,9 @@ func blocksCollection(ctx context.Context, d *daemon) (err error) {
 	}
 
 	if chooseFromConfig {
-		// get a host with the biggest block id from config
-		log.Debug("Getting a host with biggest block from config")
-		hosts = conf.GetNodesAddr()
+		// get a host with the biggest block id from config and the address book
+		log.Debug("Getting a host with biggest block from config and peers")
+		hosts = append(conf.GetNodesAddr(), peers.Hosts(peers.ExchangeCount)...)
 		if len(hosts) > 0 {
 			host, maxBlockID, err = utils.ChooseBestHost(ctx, hosts, d.logger)
 			if err != nil {
@@ -173,6 +181,9 @@ func UpdateChain(ctx context.Context, d *daemon, host string, maxBlockID int64)
 				transaction.CleanCache()
 				//it should be fork, replace our previous blocks to ones from the host
 				err := GetBlocks(b.Header.BlockID-1, host)
+				if err == ErrReorgDepth {
+					return err
+				}
 				if err != nil {
 					d.logger.WithFields(log.Fields{"error": err, "type": consts.ParserError}).Error("processing block")
 					banNode(host, b, err)
@@ -200,11 +211,23 @@ func UpdateChain(ctx context.Context, d *daemon, host string, maxBlockID int64)
 	st := time.Now()
 	d.logger.Infof("starting downloading blocks from %d to %d (%d) \n", curBlock.BlockID, maxBlockID, maxBlockID-curBlock.BlockID)
 
-	count := 0
-	var err error
-	for blockID := curBlock.BlockID + 1; blockID <= maxBlockID; blockID += int64(tcpserver.BlocksPerRequest) {
+	// the headers are verified before downloading the bodies from several hosts concurrently
+	peers := getSyncPeers(host, curBlock.BlockID+1)
+	if isPrunedHost(host, curBlock.BlockID+1) {
+		if len(peers) == 0 {
+			return fmt.Errorf("host %s has pruned block %d", host, curBlock.BlockID+1)
+		}
+		// the pruned host doesn't keep old blocks, so they are downloaded from the peer
+		host, peers = peers[0], peers[1:]
+	}
+
+	var (
+		count int64
+		err   error
+	)
+	for blockID := curBlock.BlockID + 1; blockID <= maxBlockID; blockID = curBlock.BlockID + 1 {
 		var rawBlocksChan chan []byte
-		rawBlocksChan, err = utils.GetBlocksBody(host, blockID, tcpserver.BlocksPerRequest, consts.DATA_TYPE_BLOCK_BODY, false)
+		rawBlocksChan, err = downloadBlocks(ctx, d.logger, host, peers, blockID, maxBlockID)
 		if err != nil {
 			d.logger.WithFields(log.Fields{"error": err, "type": consts.BlockError}).Error("getting block body")
 			break
@@ -215,7 +238,17 @@ func UpdateChain(ctx context.Context, d *daemon, host string, maxBlockID int64)
 			d.logger.WithFields(log.Fields{"error": err, "type": consts.BlockError}).Error("playing raw block")
 			break
 		}
-		count++
+
+		// the blocks can be replaced in the case of fork, so we continue from our last block
+		if _, err = curBlock.Get(); err != nil {
+			d.logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("Getting info block")
+			break
+		}
+		if curBlock.BlockID < blockID {
+			err = fmt.Errorf("no blocks were received from %s", host)
+			break
+		}
+		count += curBlock.BlockID - blockID + 1
 	}
 
 	if err != nil {
@@ -284,6 +317,7 @@ func banNode(host string, block *block.Block, err error) {
 	}
 
 	log.WithFields(log.Fields{"reason": reason, "host": host, "block_id": blockId, "block_time": blockTime}).Debug("ban node")
+	peers.Ban(host)
 
 	n, err := syspar.GetNodeByHost(host)
 	if err != nil {
@@ -344,6 +378,15 @@ func GetBlocks(blockID int64, host string) error {
 		log.WithFields(log.Fields{"error": err, "type": consts.DBError}).Error("getting rollback blocks from blockID")
 		return utils.ErrInfo(err)
 	}
+	var reorg *model.Reorg
+	if len(myRollbackBlocks) > 0 {
+		if reorg, err = newReorg(myRollbackBlocks, blocks); err != nil {
+			return utils.ErrInfo(err)
+		}
+		if err = checkReorgDepth(reorg); err != nil {
+			return err
+		}
+	}
 	for _, block := range myRollbackBlocks {
 		err := rollback.RollbackBlock(block.Data, false)
 		if err != nil {
@@ -351,7 +394,13 @@ func GetBlocks(blockID int64, host string) error {
 		}
 	}
 
-	return processBlocks(blocks)
+	if err = processBlocks(blocks); err != nil {
+		return err
+	}
+	if reorg != nil {
+		logReorg(reorg)
+	}
+	return nil
 }
 
 func getBlocks(blockID int64, host string) ([]*block.Block, error) {
@@ -402,11 +451,7 @@ func getBlocks(blockID int64, host string) ([]*block.Block, error) {
 		}
 
 		// SIGN from 128 bytes to 512 bytes. Signature of TYPE, BLOCK_ID, PREV_BLOCK_HASH, TIME, WALLET_ID, state_id, MRKL_ROOT
-		forSign := fmt.Sprintf("0,%v,%x,%v,%v,%v,%v,%s",
-			block.Header.BlockID, block.PrevHeader.Hash, block.Header.Time,
-			block.Header.EcosystemID, block.Header.KeyID, block.Header.NodePosition,
-			block.MrklRoot,
-		)
+		forSign := block.Header.ForSign(block.PrevHeader.Hash, block.MrklRoot)
 
 		// save the block
 		blocks = append(blocks, block)
@@ -443,10 +488,10 @@ func processBlocks(blocks []*block.Block) error {
 			b.PrevHeader.EcosystemID = prevBlocks[b.Header.BlockID-1].Header.EcosystemID
 			b.PrevHeader.KeyID = prevBlocks[b.Header.BlockID-1].Header.KeyID
 			b.PrevHeader.NodePosition = prevBlocks[b.Header.BlockID-1].Header.NodePosition
+			b.PrevStateRoot = prevBlocks[b.Header.BlockID-1].StateRoot
 		}
 
-		forSha := fmt.Sprintf("%d,%x,%s,%d,%d,%d,%d", b.Header.BlockID, b.PrevHeader.Hash, b.MrklRoot, b.Header.Time, b.Header.EcosystemID, b.Header.KeyID, b.Header.NodePosition)
-		hash, err := crypto.DoubleHash([]byte(forSha))
+		hash, err := crypto.DoubleHash([]byte(b.Header.ForSha(b.PrevHeader.Hash, b.MrklRoot)))
 		if err != nil {
 			log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Fatal("double hashing block")
 		}
@@ -461,6 +506,16 @@ func processBlocks(blocks []*block.Block) error {
 			dbTransaction.Rollback()
 			return utils.ErrInfo(err)
 		}
+		if err := b.CalcStateRoot(dbTransaction); err != nil {
+			dbTransaction.Rollback()
+			return utils.ErrInfo(err)
+		}
+		if archive.IsTime(b.Header.BlockID) {
+			if err := archive.Checkpoint(dbTransaction, b.Header.BlockID); err != nil {
+				dbTransaction.Rollback()
+				return utils.ErrInfo(err)
+			}
+		}
 		prevBlocks[b.Header.BlockID] = b
 
 		// for last block we should update block info
@@ -496,5 +551,11 @@ func processBlocks(blocks []*block.Block) error {
 		}
 	}
 
-	return dbTransaction.Commit()
+	if err := dbTransaction.Commit(); err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		mempool.DropReplaced(b.Transactions)
+	}
+	return nil
 }
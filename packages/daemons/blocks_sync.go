@@ -0,0 +1,398 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package daemons
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/GenesisCommunity/go-genesis/packages/block"
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/protocol"
+	"github.com/GenesisCommunity/go-genesis/packages/tcpserver"
+	"github.com/GenesisCommunity/go-genesis/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// syncPeersCount is the max count of hosts which the blocks are downloaded from
+	syncPeersCount = 5
+	// syncChunkSize is the count of blocks which are downloaded from the host by one request
+	syncChunkSize = 100
+)
+
+var errNoVerifiedHeaders = errors.New("there are no verified headers")
+
+// syncHeader is the verified header of the block
+type syncHeader struct {
+	utils.BlockData
+	binary   []byte
+	mrklRoot []byte
+}
+
+// syncPeer is the host which has the same headers as the main host
+type syncPeer struct {
+	host  string
+	count int // the count of headers which match the headers of the main host
+}
+
+// syncChunk is the range of headers whose bodies are downloaded by one request
+type syncChunk struct {
+	from, to int
+	tried    map[string]bool
+}
+
+// getSyncPeers returns the hosts which are not banned and have the bodies of blocks starting from blockID besides the main host
+func getSyncPeers(host string, blockID int64) []string {
+	hosts, err := filterBannedHosts(syspar.GetRemoteHosts())
+	if err != nil {
+		return nil
+	}
+	utils.ShuffleSlice(hosts)
+
+	peers := make([]string, 0, syncPeersCount)
+	for _, h := range hosts {
+		h = utils.GetHostPort(h)
+		if h == host {
+			continue
+		}
+		if len(peers) == syncPeersCount-1 {
+			break
+		}
+		if isPrunedHost(h, blockID) {
+			continue
+		}
+		peers = append(peers, h)
+	}
+	return peers
+}
+
+// isPrunedHost returns true if the host has removed the body of the block
+func isPrunedHost(host string, blockID int64) bool {
+	conn, err := utils.TCPConn(host)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+
+	if err = tcpserver.SendRequestType(tcpserver.RequestTypePrunedBlock, conn); err != nil {
+		return false
+	}
+	resp := &tcpserver.PrunedBlockResponse{}
+	// the hosts which don't support the request keep all blocks
+	if err = tcpserver.ReadRequest(resp, conn); err != nil {
+		return false
+	}
+	return int64(resp.BlockID) >= blockID
+}
+
+func banSyncPeer(host string, header *utils.BlockData, err error) {
+	if header == nil {
+		banNode(host, nil, err)
+		return
+	}
+	banNode(host, &block.Block{Header: *header}, err)
+}
+
+// downloadBlocks returns the raw blocks starting from blockID which are verified with the headers
+func downloadBlocks(ctx context.Context, logger *log.Entry, host string, peers []string, blockID, maxBlockID int64) (chan []byte, error) {
+	count := tcpserver.BlocksPerRequest
+	if maxBlockID-blockID+1 < int64(count) {
+		count = int32(maxBlockID - blockID + 1)
+	}
+
+	prev, err := block.GetBlockDataFromBlockChain(blockID - 1)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, syncPeers, err := syncHeaders(logger, host, peers, prev, blockID, count)
+	if err == errNoVerifiedHeaders {
+		// our last block can be in the fork, so the blocks are downloaded from the host as is
+		// and the fork is resolved while they are playing
+		logger.WithFields(log.Fields{"block_id": blockID, "host": host}).Debug("headers do not follow our chain")
+		return utils.GetBlocksBody(host, blockID, count, consts.DATA_TYPE_BLOCK_BODY, false)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bodies, err := syncBodies(ctx, logger, headers, syncPeers)
+	if err != nil {
+		return nil, err
+	}
+
+	rawBlocksCh := make(chan []byte, len(bodies))
+	for _, body := range bodies {
+		rawBlocksCh <- body
+	}
+	close(rawBlocksCh)
+	return rawBlocksCh, nil
+}
+
+// getBlockHeaders requests the headers of the blocks from the host
+func getBlockHeaders(host string, blockID int64, count int32) ([]*tcpserver.GetHeaderResponse, error) {
+	conn, err := utils.TCPConn(host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err = tcpserver.SendRequestType(tcpserver.RequestTypeBlockHeaders, conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending request type")
+		return nil, err
+	}
+	req := &tcpserver.GetBlocksRangeRequest{BlockID: uint32(blockID), Count: uint32(count)}
+	if err = tcpserver.SendRequest(req, conn); err != nil {
+		return nil, err
+	}
+
+	resp := &tcpserver.BlocksCountResponse{}
+	if err = tcpserver.ReadRequest(resp, conn); err != nil {
+		return nil, err
+	}
+	if resp.Count > uint32(count) {
+		return nil, fmt.Errorf("host %s sent %d headers instead of %d", host, resp.Count, count)
+	}
+
+	headers := make([]*tcpserver.GetHeaderResponse, resp.Count)
+	for i := range headers {
+		headers[i] = &tcpserver.GetHeaderResponse{}
+		if err = tcpserver.ReadRequest(headers[i], conn); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// verifyHeaders checks the chain of headers, the producer positions and the signatures of the blocks.
+// It returns the headers till the first header which can't be verified with the current list of nodes,
+// and the error if the host sent malformed data.
+func verifyHeaders(prev *utils.BlockData, blockID int64, list []*tcpserver.GetHeaderResponse) ([]*syncHeader, error) {
+	blockTimeCalculator, err := utils.BuildBlockTimeCalculator(nil)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.BlockError, "error": err}).Error("building block time calculator")
+		return nil, err
+	}
+
+	headers := make([]*syncHeader, 0, len(list))
+	prevHash := prev.Hash
+	for i, item := range list {
+		header, err := utils.ParseBlockHeader(bytes.NewBuffer(item.Header), false)
+		if err != nil {
+			return headers, err
+		}
+		if header.BlockID != blockID+int64(i) {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "header_block_id": header.BlockID, "block_id": blockID + int64(i)}).Error("block ids does not match")
+			return headers, fmt.Errorf("wrong block id %d of header", header.BlockID)
+		}
+		if header.Time > time.Now().Unix() {
+			log.WithFields(log.Fields{"type": consts.ParameterExceeded, "block_id": header.BlockID}).Error("block time is larger than now")
+			return headers, fmt.Errorf("incorrect time of block %d", header.BlockID)
+		}
+
+		validBlockTime, err := blockTimeCalculator.ValidateBlock(header.NodePosition, time.Unix(header.Time, 0))
+		if err != nil || !validBlockTime {
+			return headers, nil
+		}
+		nodePublicKey, err := syspar.GetNodePublicKeyByPosition(header.NodePosition)
+		if err != nil || len(nodePublicKey) == 0 {
+			return headers, nil
+		}
+		forSign := header.ForSign(prevHash, item.MrklRoot)
+		if ok, err := utils.CheckSign([][]byte{nodePublicKey}, forSign, header.Sign, true); err != nil || !ok {
+			return headers, nil
+		}
+
+		header.Hash, err = crypto.DoubleHash([]byte(header.ForSha(prevHash, item.MrklRoot)))
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("double hashing block")
+			return headers, err
+		}
+		headers = append(headers, &syncHeader{BlockData: header, binary: item.Header, mrklRoot: item.MrklRoot})
+		prevHash = header.Hash
+	}
+	return headers, nil
+}
+
+// syncHeaders gets the verified headers from the main host and the peers which have the same headers
+func syncHeaders(logger *log.Entry, host string, peers []string, prev *utils.BlockData, blockID int64, count int32) ([]*syncHeader, []*syncPeer, error) {
+	list, err := getBlockHeaders(host, blockID, count)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "host": host}).Error("getting block headers")
+		return nil, nil, err
+	}
+	headers, err := verifyHeaders(prev, blockID, list)
+	if err != nil {
+		banSyncPeer(host, nil, err)
+		return nil, nil, err
+	}
+	if len(headers) == 0 {
+		return nil, nil, errNoVerifiedHeaders
+	}
+
+	syncPeers := []*syncPeer{{host: host, count: len(headers)}}
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+	)
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer string) {
+			defer wg.Done()
+
+			list, err := getBlockHeaders(peer, blockID, int32(len(headers)))
+			if err != nil {
+				logger.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "host": peer}).Debug("getting block headers")
+				return
+			}
+			peerHeaders, err := verifyHeaders(prev, blockID, list)
+			if err != nil {
+				banSyncPeer(peer, nil, err)
+				return
+			}
+			// the peer can be in the fork or behind the main host, so only the same headers are used
+			var matched int
+			for matched < len(peerHeaders) && bytes.Equal(peerHeaders[matched].binary, headers[matched].binary) &&
+				bytes.Equal(peerHeaders[matched].mrklRoot, headers[matched].mrklRoot) {
+				matched++
+			}
+			if matched > 0 {
+				mutex.Lock()
+				syncPeers = append(syncPeers, &syncPeer{host: peer, count: matched})
+				mutex.Unlock()
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	return headers, syncPeers, nil
+}
+
+// syncBodies downloads the bodies of blocks from the peers concurrently and checks them with the headers
+func syncBodies(ctx context.Context, logger *log.Entry, headers []*syncHeader, peers []*syncPeer) ([][]byte, error) {
+	bodies := make([][]byte, len(headers))
+
+	var pending []*syncChunk
+	for from := 0; from < len(headers); from += syncChunkSize {
+		to := from + syncChunkSize
+		if to > len(headers) {
+			to = len(headers)
+		}
+		pending = append(pending, &syncChunk{from: from, to: to, tried: make(map[string]bool)})
+	}
+
+	var next int
+	for len(pending) > 0 {
+		if ctx.Err() != nil {
+			logger.WithFields(log.Fields{"type": consts.ContextError, "error": ctx.Err()}).Error("context error")
+			return nil, ctx.Err()
+		}
+
+		var (
+			failed []*syncChunk
+			wg     sync.WaitGroup
+			mutex  sync.Mutex
+		)
+		for _, chunk := range pending {
+			var peer *syncPeer
+			for i := 0; i < len(peers) && peer == nil; i++ {
+				p := peers[(next+i)%len(peers)]
+				if p.count >= chunk.to && !chunk.tried[p.host] {
+					peer = p
+				}
+			}
+			if peer == nil {
+				wg.Wait()
+				logger.WithFields(log.Fields{"type": consts.BlockError, "block_id": headers[chunk.from].BlockID}).Error("no hosts to download blocks from")
+				return nil, fmt.Errorf("can't download blocks %d-%d", headers[chunk.from].BlockID, headers[chunk.to-1].BlockID)
+			}
+			next++
+			chunk.tried[peer.host] = true
+
+			wg.Add(1)
+			go func(chunk *syncChunk, host string) {
+				defer wg.Done()
+				if err := getBlockBodies(host, headers[chunk.from:chunk.to], bodies[chunk.from:chunk.to]); err != nil {
+					logger.WithFields(log.Fields{"type": consts.BlockError, "error": err, "host": host}).Debug("getting block bodies")
+					mutex.Lock()
+					failed = append(failed, chunk)
+					mutex.Unlock()
+				}
+			}(chunk, peer.host)
+		}
+		wg.Wait()
+		pending = failed
+	}
+	return bodies, nil
+}
+
+// getBlockBodies downloads the bodies of blocks from the host and checks them with the headers
+func getBlockBodies(host string, headers []*syncHeader, bodies [][]byte) error {
+	conn, err := utils.TCPConn(host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err = tcpserver.SendRequestType(tcpserver.RequestTypeBlockBodies, conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending request type")
+		return err
+	}
+	req := &tcpserver.GetBlocksRangeRequest{BlockID: uint32(headers[0].BlockID), Count: uint32(len(headers))}
+	if err = tcpserver.SendRequest(req, conn); err != nil {
+		return err
+	}
+
+	resp := &tcpserver.BlocksCountResponse{}
+	if err = tcpserver.ReadRequest(resp, conn); err != nil {
+		return err
+	}
+	if int(resp.Count) != len(headers) {
+		return fmt.Errorf("host %s sent %d blocks instead of %d", host, resp.Count, len(headers))
+	}
+
+	for i, header := range headers {
+		body := &tcpserver.GetBodyResponse{}
+		if err = tcpserver.ReadRequest(body, conn); err != nil {
+			return err
+		}
+		binary, mrklRoot, err := protocol.SplitBlockHeader(body.Data)
+		if err == nil && (!bytes.Equal(binary, header.binary) || !bytes.Equal(mrklRoot, header.mrklRoot)) {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "block_id": header.BlockID, "host": host}).Error("block does not match the header")
+			err = fmt.Errorf("block %d does not match the header", header.BlockID)
+		}
+		if err != nil {
+			banSyncPeer(host, &header.BlockData, err)
+			return err
+		}
+		bodies[i] = body.Data
+	}
+	return nil
+}
@@ -56,6 +56,7 @@ var daemonsList = map[string]func(context.Context, *daemon) error{
 	"Confirmations":     Confirmations,
 	"Notificator":       Notificate,
 	"Scheduler":         Scheduler,
+	"PeerExchange":      PeerExchange,
 }
 
 var serverList = []string{
@@ -67,6 +68,7 @@ var serverList = []string{
 	"Confirmations",
 	"Notificator",
 	"Scheduler",
+	"PeerExchange",
 }
 
 var rollbackList = []string{
@@ -0,0 +1,97 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package daemons
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/peers"
+	"github.com/GenesisCommunity/go-genesis/packages/tcpserver"
+	"github.com/GenesisCommunity/go-genesis/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PeerExchange sends our record to the peers and fills the address book with the records of their peers
+func PeerExchange(ctx context.Context, d *daemon) error {
+	d.sleepTime = time.Minute
+
+	var request []byte
+	self, err := peers.Self()
+	if err != nil {
+		return err
+	}
+	if self != nil {
+		if request, err = json.Marshal(self); err != nil {
+			d.logger.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling peer record")
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, host := range peers.ExchangeHosts() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			records, err := getPeers(host, request)
+			if err != nil {
+				d.logger.WithFields(log.Fields{"type": consts.ConnectionError, "error": err, "host": host}).Debug("getting peers")
+				return
+			}
+			peers.Add(host, records)
+		}(host)
+	}
+	wg.Wait()
+
+	return peers.Prune()
+}
+
+func getPeers(host string, request []byte) ([]peers.Record, error) {
+	conn, err := utils.TCPConn(host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err = tcpserver.SendRequestType(tcpserver.RequestTypePeers, conn); err != nil {
+		return nil, err
+	}
+	if err = tcpserver.SendRequest(&tcpserver.PeersRequest{Data: request}, conn); err != nil {
+		return nil, err
+	}
+	resp := &tcpserver.PeersResponse{}
+	if err = tcpserver.ReadRequest(resp, conn); err != nil {
+		return nil, err
+	}
+	var records []peers.Record
+	if err = json.Unmarshal(resp.Data, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -25,6 +25,7 @@ import (
 	"context"
 
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/mempool"
 	"github.com/GenesisCommunity/go-genesis/packages/model"
 	"github.com/GenesisCommunity/go-genesis/packages/transaction"
 
@@ -61,5 +62,5 @@ func QueueParserTx(ctx context.Context, d *daemon) error {
 		return err
 	}
 
-	return nil
+	return mempool.Sync()
 }
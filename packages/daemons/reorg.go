@@ -0,0 +1,139 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package daemons
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/GenesisCommunity/go-genesis/packages/block"
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/protocol"
+	"github.com/GenesisCommunity/go-genesis/packages/publisher"
+	"github.com/GenesisCommunity/go-genesis/packages/service"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrReorgDepth is returned if the fork is deeper than the allowed count of blocks
+var ErrReorgDepth = errors.New("fork is deeper than max reorg depth")
+
+// reorgNotification is the message which is published to the clients
+type reorgNotification struct {
+	OldBlockID int64    `json:"old_block_id"`
+	OldHash    string   `json:"old_hash"`
+	NewBlockID int64    `json:"new_block_id"`
+	NewHash    string   `json:"new_hash"`
+	Depth      int64    `json:"depth"`
+	TxHashes   []string `json:"tx_hashes"`
+	Halted     bool     `json:"halted,omitempty"`
+}
+
+// newReorg returns the record about the replacement of our blocks with the blocks of the fork.
+// Both lists of blocks are in the descending order
+func newReorg(oldBlocks []model.Block, newBlocks []*block.Block) (*model.Reorg, error) {
+	reorg := &model.Reorg{
+		Time:       time.Now().Unix(),
+		OldBlockID: oldBlocks[0].ID,
+		OldHash:    oldBlocks[0].Hash,
+		Depth:      int64(len(oldBlocks)),
+	}
+	if len(newBlocks) > 0 {
+		reorg.NewBlockID = newBlocks[0].Header.BlockID
+		reorg.NewHash = newBlocks[0].Header.Hash
+	}
+	var hashes []string
+	for _, item := range oldBlocks {
+		_, txs, err := protocol.SplitBlockData(item.Data)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range txs {
+			hash, err := crypto.Hash(tx)
+			if err != nil {
+				log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("hashing transaction")
+				return nil, err
+			}
+			hashes = append(hashes, hex.EncodeToString(hash))
+		}
+	}
+	reorg.TxHashes = strings.Join(hashes, `,`)
+	return reorg, nil
+}
+
+// checkReorgDepth halts the node instead of rolling back if the fork is deeper than MaxReorgDepth
+// and the administrator hasn't allowed to roll back this fork
+func checkReorgDepth(reorg *model.Reorg) error {
+	if conf.Config.MaxReorgDepth == 0 || reorg.Depth <= conf.Config.MaxReorgDepth {
+		return nil
+	}
+	if service.IsDeepReorgAccepted(reorg.OldBlockID) {
+		log.WithFields(log.Fields{"type": consts.BlockError, "depth": reorg.Depth, "max_depth": conf.Config.MaxReorgDepth,
+			"block_id": reorg.OldBlockID, "new_block_id": reorg.NewBlockID}).Warn("rolling back the deep fork which has been accepted by administrator")
+		return nil
+	}
+	service.HaltOnDeepReorg(reorg.OldBlockID)
+	log.WithFields(log.Fields{"type": consts.BlockError, "depth": reorg.Depth, "max_depth": conf.Config.MaxReorgDepth,
+		"block_id": reorg.OldBlockID, "new_block_id": reorg.NewBlockID}).Error("fork is deeper than max reorg depth, node has been halted")
+	notifyReorg(reorg, true)
+	return ErrReorgDepth
+}
+
+// notifyReorg publishes the reorganization to the clients
+func notifyReorg(reorg *model.Reorg, halted bool) {
+	notification := reorgNotification{
+		OldBlockID: reorg.OldBlockID,
+		OldHash:    hex.EncodeToString(reorg.OldHash),
+		NewBlockID: reorg.NewBlockID,
+		NewHash:    hex.EncodeToString(reorg.NewHash),
+		Depth:      reorg.Depth,
+		TxHashes:   []string{},
+		Halted:     halted,
+	}
+	if len(reorg.TxHashes) > 0 {
+		notification.TxHashes = strings.Split(reorg.TxHashes, `,`)
+	}
+	data, err := json.Marshal(notification)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling reorg notification")
+		return
+	}
+	if _, err = publisher.WriteChannel(publisher.ChannelReorgs, string(data)); err != nil {
+		log.WithFields(log.Fields{"type": consts.CentrifugoError, "error": err}).Warn("publishing reorg notification")
+	}
+}
+
+// logReorg records the reorganization which has been done and notifies the clients
+func logReorg(reorg *model.Reorg) {
+	if err := reorg.Create(nil); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating reorg record")
+	}
+	log.WithFields(log.Fields{"old_block_id": reorg.OldBlockID, "new_block_id": reorg.NewBlockID,
+		"depth": reorg.Depth}).Warn("blockchain has been reorganized")
+	notifyReorg(reorg, false)
+}
@@ -0,0 +1,61 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package daemons
+
+import (
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/service"
+)
+
+func TestCheckReorgDepth(t *testing.T) {
+	defer func(depth int64) { conf.Config.MaxReorgDepth = depth }(conf.Config.MaxReorgDepth)
+
+	reorg := &model.Reorg{OldBlockID: 20, NewBlockID: 21, Depth: 5}
+	conf.Config.MaxReorgDepth = 0
+	if err := checkReorgDepth(reorg); err != nil {
+		t.Errorf("depth isn't limited: %v", err)
+	}
+	conf.Config.MaxReorgDepth = 5
+	if err := checkReorgDepth(reorg); err != nil {
+		t.Errorf("depth is allowed: %v", err)
+	}
+	conf.Config.MaxReorgDepth = 4
+	if err := checkReorgDepth(reorg); err != ErrReorgDepth {
+		t.Errorf("deep fork must halt the node, got %v", err)
+	}
+	if service.NodePauseType() != service.PauseTypeDeepReorg {
+		t.Errorf("node isn't halted, pause type %d", service.NodePauseType())
+	}
+	if blockID, ok := service.ResumeAfterDeepReorg(); !ok || blockID != 20 {
+		t.Fatalf("wrong resumed block %d", blockID)
+	}
+	if err := checkReorgDepth(reorg); err != nil {
+		t.Errorf("accepted fork must be rolled back: %v", err)
+	}
+	if err := checkReorgDepth(reorg); err != ErrReorgDepth {
+		t.Errorf("fork must be accepted only once, got %v", err)
+	}
+	service.ResumeAfterDeepReorg()
+}
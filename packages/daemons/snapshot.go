@@ -0,0 +1,237 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package daemons
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/peers"
+	"github.com/GenesisCommunity/go-genesis/packages/snapshot"
+	"github.com/GenesisCommunity/go-genesis/packages/tcpserver"
+	"github.com/GenesisCommunity/go-genesis/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrSnapshotNotAgreed is returned if there is no snapshot which is agreed by the most of nodes
+var ErrSnapshotNotAgreed = errors.New("there is no agreed snapshot")
+
+// agreedSnapshot is the snapshot which has the same state hash on several hosts
+type agreedSnapshot struct {
+	manifest *snapshot.Manifest
+	hosts    []string
+}
+
+// RestoreSnapshot restores the state from the last snapshot which is agreed by the most of nodes,
+// then the blocks are collected starting from the block of the snapshot
+func RestoreSnapshot(ctx context.Context, logger *log.Entry) error {
+	hosts := getSnapshotHosts()
+	if len(hosts) == 0 {
+		return ErrNodesUnavailable
+	}
+	// the state hash and the block hash must be confirmed by more than half of nodes
+	required := len(hosts)/2 + 1
+	if required < consts.MIN_CONFIRMED_NODES {
+		required = consts.MIN_CONFIRMED_NODES
+	}
+
+	// the latest snapshots of the hosts are candidates
+	candidates := make(map[int64]bool)
+	for _, manifest := range getManifests(hosts, 0, logger) {
+		if manifest != nil {
+			candidates[manifest.BlockID] = true
+		}
+	}
+	blockIDs := make([]int64, 0, len(candidates))
+	for blockID := range candidates {
+		blockIDs = append(blockIDs, blockID)
+	}
+	sort.Slice(blockIDs, func(i, j int) bool { return blockIDs[i] > blockIDs[j] })
+
+	for _, blockID := range blockIDs {
+		if ctx.Err() != nil {
+			logger.WithFields(log.Fields{"type": consts.ContextError, "error": ctx.Err()}).Error("context error")
+			return ctx.Err()
+		}
+		agreed, err := agreeSnapshot(hosts, blockID, required, logger)
+		if err != nil {
+			return err
+		}
+		if agreed == nil {
+			continue
+		}
+		logger.WithFields(log.Fields{"block_id": blockID, "hosts": len(agreed.hosts)}).Info("restoring snapshot")
+		// the chunks are checked with the agreed manifest so they can be downloaded from peers as well
+		agreed.hosts = appendHosts(agreed.hosts, peers.Hosts(0)...)
+		if err = snapshot.Restore(agreed.manifest, agreed.getChunk(logger)); err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": blockID}).Error("restoring snapshot")
+			return err
+		}
+		return syspar.SysUpdate(nil)
+	}
+	return ErrSnapshotNotAgreed
+}
+
+// getSnapshotHosts returns the hosts of the nodes from the config and the system parameters which vote for snapshots.
+// The peers from the address book don't vote because anyone can get there
+func getSnapshotHosts() []string {
+	return appendHosts(nil, append(conf.GetNodesAddr(), syspar.GetRemoteHosts()...)...)
+}
+
+// appendHosts appends the hosts which aren't in the list yet
+func appendHosts(hosts []string, add ...string) []string {
+	exists := make(map[string]bool)
+	for _, host := range hosts {
+		exists[host] = true
+	}
+	for _, host := range add {
+		host = utils.GetHostPort(host)
+		if !exists[host] {
+			exists[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// agreeSnapshot returns the snapshot of the block if its state hash and the hash of the block
+// are the same on the required count of hosts
+func agreeSnapshot(hosts []string, blockID int64, required int, logger *log.Entry) (*agreedSnapshot, error) {
+	manifests := getManifests(hosts, blockID, logger)
+	votes := make(map[string]*agreedSnapshot)
+	for i, manifest := range manifests {
+		if manifest == nil || manifest.BlockID != blockID {
+			continue
+		}
+		hash, err := manifest.Hash()
+		if err != nil {
+			return nil, err
+		}
+		key := string(hash)
+		if votes[key] == nil {
+			votes[key] = &agreedSnapshot{manifest: manifest}
+		}
+		votes[key].hosts = append(votes[key].hosts, hosts[i])
+	}
+
+	for hash, agreed := range votes {
+		if len(agreed.hosts) < required {
+			continue
+		}
+		// the block of the snapshot must be confirmed as well as it is done by confirmations daemon
+		blockHash := string(converter.BinToHex(agreed.manifest.Block.Hash))
+		ch := make(chan string)
+		for _, host := range hosts {
+			go IsReachable(host, blockID, ch, logger)
+		}
+		var confirmed int
+		for range hosts {
+			if <-ch == blockHash {
+				confirmed++
+			}
+		}
+		if confirmed >= required {
+			logger.WithFields(log.Fields{"block_id": blockID, "hash": fmt.Sprintf("%x", hash)}).Debug("snapshot is agreed")
+			return agreed, nil
+		}
+	}
+	return nil, nil
+}
+
+// getManifests requests the manifests of the snapshot from the hosts concurrently
+func getManifests(hosts []string, blockID int64, logger *log.Entry) []*snapshot.Manifest {
+	manifests := make([]*snapshot.Manifest, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			data, err := getSnapshotData(host, tcpserver.RequestTypeSnapshot, &tcpserver.GetSnapshotRequest{BlockID: uint32(blockID)})
+			if err != nil || len(data) == 0 {
+				logger.WithFields(log.Fields{"host": host, "block_id": blockID, "error": err}).Debug("getting snapshot manifest")
+				return
+			}
+			manifest := &snapshot.Manifest{}
+			if err = json.Unmarshal(data, manifest); err != nil {
+				logger.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "host": host, "error": err}).Error("unmarshalling snapshot manifest")
+				return
+			}
+			manifests[i] = manifest
+		}(i, host)
+	}
+	wg.Wait()
+	return manifests
+}
+
+// getChunk returns the function which downloads the chunk from the agreed hosts in turn
+func (agreed *agreedSnapshot) getChunk(logger *log.Entry) func(index int) ([]byte, error) {
+	return func(index int) ([]byte, error) {
+		for i := range agreed.hosts {
+			host := agreed.hosts[(index+i)%len(agreed.hosts)]
+			data, err := getSnapshotData(host, tcpserver.RequestTypeSnapshotChunk, &tcpserver.GetSnapshotChunkRequest{
+				BlockID: uint32(agreed.manifest.BlockID),
+				Chunk:   uint32(index),
+			})
+			if err != nil || len(data) == 0 {
+				logger.WithFields(log.Fields{"host": host, "chunk": index, "error": err}).Debug("getting snapshot chunk")
+				continue
+			}
+			if err = agreed.manifest.CheckChunk(index, data); err != nil {
+				// the host has sent the data which differs from the agreed snapshot
+				banNode(host, nil, err)
+				continue
+			}
+			return data, nil
+		}
+		return nil, fmt.Errorf("can't download chunk %d of snapshot %d", index, agreed.manifest.BlockID)
+	}
+}
+
+func getSnapshotData(host string, requestType int64, request interface{}) ([]byte, error) {
+	conn, err := utils.TCPConn(host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err = tcpserver.SendRequestType(requestType, conn); err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "host": host}).Error("sending request type")
+		return nil, err
+	}
+	if err = tcpserver.SendRequest(request, conn); err != nil {
+		return nil, err
+	}
+	resp := &tcpserver.SnapshotResponse{}
+	if err = tcpserver.ReadRequest(resp, conn); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/GenesisCommunity/go-genesis/packages/converter"
 	"github.com/GenesisCommunity/go-genesis/packages/daemons"
 	"github.com/GenesisCommunity/go-genesis/packages/daylight/daemonsctl"
+	"github.com/GenesisCommunity/go-genesis/packages/debugger"
 	logtools "github.com/GenesisCommunity/go-genesis/packages/log"
 	"github.com/GenesisCommunity/go-genesis/packages/model"
 	"github.com/GenesisCommunity/go-genesis/packages/publisher"
@@ -281,6 +282,9 @@ func Start() {
 				log.WithFields(log.Fields{"type": consts.VMError, "error": err}).Fatal("on loading vde virtual mashine")
 				Exit(1)
 			}
+			if conf.Config.Debugger.Port > 0 {
+				debugger.Start(smart.GetVM(), conf.Config.Debugger.Str())
+			}
 		}
 
 		if conf.Config.IsVDEMaster() {
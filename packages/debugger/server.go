@@ -0,0 +1,312 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package debugger
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/script"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	threadID = 1
+	// extendReference is the reference to the variables of extend, the references of locals equal the frame ids
+	extendReference = 1 << 20
+)
+
+// Server attaches editors to the debugger of contracts over the Debug Adapter Protocol.
+// Breakpoints are set in the files with the source code of contracts, the lines are counted
+// from the beginning of the file like when the file is loaded by the contracts test
+type Server struct {
+	dbg *script.Debugger
+
+	mutex   sync.Mutex
+	conn    io.Writer
+	seq     int
+	files   map[string][]string // the names of contracts and functions in the files with breakpoints
+	sources map[string]string   // the paths of files of contracts and functions
+	ready   chan struct{}
+	isReady bool
+}
+
+// NewServer returns the server for the specified debugger
+func NewServer(dbg *script.Debugger) *Server {
+	srv := &Server{
+		dbg:     dbg,
+		files:   make(map[string][]string),
+		sources: make(map[string]string),
+		ready:   make(chan struct{}),
+	}
+	dbg.OnStop = srv.onStop
+	return srv
+}
+
+// Start attaches the new debugger to the virtual machine and serves clients at the specified address
+func Start(vm *script.VM, addr string) *Server {
+	dbg := script.NewDebugger()
+	vm.Debugger = dbg
+	srv := NewServer(dbg)
+	go srv.ListenAndServe(addr)
+	return srv
+}
+
+// Ready returns the channel which is closed when the first client has finished the configuration
+func (srv *Server) Ready() <-chan struct{} {
+	return srv.ready
+}
+
+// ListenAndServe accepts clients at the specified tcp address, clients are served one by one
+func (srv *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "addr": addr}).Error("listening debugger address")
+		return err
+	}
+	log.WithFields(log.Fields{"addr": addr}).Info("contracts debugger is listening")
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.NetworkError, "error": err}).Error("accepting debugger client")
+			return err
+		}
+		srv.Serve(conn)
+		conn.Close()
+	}
+}
+
+// Serve handles the requests of the client until it is disconnected
+func (srv *Server) Serve(conn io.ReadWriter) {
+	srv.mutex.Lock()
+	srv.conn = conn
+	srv.mutex.Unlock()
+	defer srv.detach()
+
+	reader := bufio.NewReader(conn)
+	for {
+		req, err := readMessage(reader)
+		if err != nil {
+			if err != io.EOF {
+				log.WithFields(log.Fields{"type": consts.ProtocolError, "error": err}).Error("reading debugger request")
+			}
+			return
+		}
+		if req.Type != typeRequest {
+			continue
+		}
+		body, err := srv.handle(req)
+		resp := &response{Type: typeResponse, RequestSeq: req.Seq, Success: err == nil, Command: req.Command, Body: body}
+		if err != nil {
+			resp.Message = err.Error()
+		}
+		srv.send(resp)
+		switch req.Command {
+		case `initialize`:
+			srv.send(&event{Type: typeEvent, Event: `initialized`})
+		case `disconnect`:
+			return
+		}
+	}
+}
+
+// detach removes breakpoints of the client and resumes the stopped execution
+func (srv *Server) detach() {
+	srv.mutex.Lock()
+	srv.conn = nil
+	for path, names := range srv.files {
+		srv.dbg.SetBreakpoints(names, nil)
+		delete(srv.files, path)
+	}
+	srv.mutex.Unlock()
+	srv.dbg.Continue()
+}
+
+func (srv *Server) send(msg interface{}) {
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	if srv.conn == nil {
+		return
+	}
+	srv.seq++
+	switch v := msg.(type) {
+	case *response:
+		v.Seq = srv.seq
+	case *event:
+		v.Seq = srv.seq
+	}
+	if err := writeMessage(srv.conn, msg); err != nil {
+		log.WithFields(log.Fields{"type": consts.NetworkError, "error": err}).Error("writing debugger message")
+	}
+}
+
+func (srv *Server) onStop(state *script.DebugState) {
+	srv.mutex.Lock()
+	attached := srv.conn != nil
+	srv.mutex.Unlock()
+	if !attached {
+		srv.dbg.Continue()
+		return
+	}
+	srv.send(&event{Type: typeEvent, Event: `stopped`, Body: map[string]interface{}{
+		"reason":            state.Reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	}})
+}
+
+func (srv *Server) handle(req *request) (interface{}, error) {
+	switch req.Command {
+	case `initialize`:
+		return map[string]interface{}{"supportsConfigurationDoneRequest": true}, nil
+	case `launch`, `attach`:
+		return nil, nil
+	case `configurationDone`:
+		srv.mutex.Lock()
+		if !srv.isReady {
+			srv.isReady = true
+			close(srv.ready)
+		}
+		srv.mutex.Unlock()
+		return nil, nil
+	case `setBreakpoints`:
+		var args setBreakpointsArguments
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return srv.setBreakpoints(&args)
+	case `threads`:
+		return map[string]interface{}{"threads": []thread{{ID: threadID, Name: `contracts`}}}, nil
+	case `stackTrace`:
+		state := srv.dbg.State()
+		frames := make([]stackFrame, 0)
+		if state != nil {
+			srv.mutex.Lock()
+			for i, item := range state.Frames {
+				frame := stackFrame{ID: i + 1, Name: item.Name, Line: int(item.Line), Column: int(item.Column)}
+				if path, ok := srv.sources[item.Source]; ok {
+					frame.Source = &source{Name: item.Source, Path: path}
+				} else {
+					frame.Source = &source{Name: item.Source}
+				}
+				frames = append(frames, frame)
+			}
+			srv.mutex.Unlock()
+		}
+		return map[string]interface{}{"stackFrames": frames, "totalFrames": len(frames)}, nil
+	case `scopes`:
+		var args struct {
+			FrameID int `json:"frameId"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"scopes": []scope{
+			{Name: `Locals`, VariablesReference: args.FrameID},
+			{Name: `Extend`, VariablesReference: extendReference},
+		}}, nil
+	case `variables`:
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := json.Unmarshal(req.Arguments, &args); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"variables": srv.variables(args.VariablesReference)}, nil
+	case `continue`:
+		srv.dbg.Continue()
+		return map[string]interface{}{"allThreadsContinued": true}, nil
+	case `next`:
+		srv.dbg.Next()
+	case `stepIn`:
+		srv.dbg.StepIn()
+	case `stepOut`:
+		srv.dbg.StepOut()
+	case `pause`:
+		srv.dbg.Pause()
+	case `terminate`:
+		srv.dbg.Terminate()
+	case `disconnect`:
+	default:
+		return nil, fmt.Errorf(`unsupported command %s`, req.Command)
+	}
+	return nil, nil
+}
+
+// setBreakpoints sets breakpoints for all contracts and functions which are defined in the file
+func (srv *Server) setBreakpoints(args *setBreakpointsArguments) (interface{}, error) {
+	path := args.Source.Path
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "path": path}).Error("reading source file")
+		return nil, err
+	}
+	names, err := script.ContractsList(string(data))
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]uint32, 0, len(args.Breakpoints))
+	result := make([]breakpoint, 0, len(args.Breakpoints))
+	for _, item := range args.Breakpoints {
+		lines = append(lines, uint32(item.Line))
+		result = append(result, breakpoint{Verified: len(names) > 0, Line: item.Line})
+	}
+
+	srv.mutex.Lock()
+	defer srv.mutex.Unlock()
+	if prev, ok := srv.files[path]; ok {
+		srv.dbg.SetBreakpoints(prev, nil)
+	}
+	srv.dbg.SetBreakpoints(names, lines)
+	srv.files[path] = names
+	for _, name := range names {
+		srv.sources[name] = path
+	}
+	return map[string]interface{}{"breakpoints": result}, nil
+}
+
+func (srv *Server) variables(reference int) []variable {
+	ret := make([]variable, 0)
+	state := srv.dbg.State()
+	if state == nil {
+		return ret
+	}
+	var vars map[string]interface{}
+	if reference == extendReference {
+		vars = state.Extend
+	} else if reference > 0 && reference <= len(state.Frames) {
+		vars = state.Frames[reference-1].Vars
+	}
+	for name, value := range vars {
+		ret = append(ret, variable{Name: name, Value: fmt.Sprint(value)})
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
@@ -0,0 +1,138 @@
+package debugger
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/script"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSource = `func double(a int) int {
+	return a * 2
+}
+func debugged() int {
+	var x int
+	x = 5
+	x = double(x)
+	return x + 1
+}`
+
+type testClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+	seq    int
+}
+
+func (c *testClient) request(command string, args interface{}) {
+	c.seq++
+	data, err := json.Marshal(args)
+	require.NoError(c.t, err)
+	require.NoError(c.t, writeMessage(c.conn, &request{Seq: c.seq, Type: typeRequest, Command: command,
+		Arguments: data}))
+}
+
+// readMessage reads the response or the event
+func (c *testClient) readMessage() map[string]interface{} {
+	header, err := textproto.NewReader(c.reader).ReadMIMEHeader()
+	require.NoError(c.t, err)
+	length, err := strconv.Atoi(header.Get(headerLength))
+	require.NoError(c.t, err)
+	data := make([]byte, length)
+	_, err = io.ReadFull(c.reader, data)
+	require.NoError(c.t, err)
+	var ret map[string]interface{}
+	require.NoError(c.t, json.Unmarshal(data, &ret))
+	return ret
+}
+
+func (c *testClient) readResponse() map[string]interface{} {
+	msg := c.readMessage()
+	require.Equal(c.t, typeResponse, msg["type"])
+	return msg
+}
+
+func (c *testClient) readEvent() map[string]interface{} {
+	msg := c.readMessage()
+	require.Equal(c.t, typeEvent, msg["type"])
+	return msg
+}
+
+func TestServer(t *testing.T) {
+	file, err := ioutil.TempFile(``, `contract`)
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(testSource)
+	require.NoError(t, err)
+	file.Close()
+
+	vm := script.NewVM()
+	require.NoError(t, vm.Compile([]rune(testSource), &script.OwnerInfo{StateID: 1}))
+	vm.Debugger = script.NewDebugger()
+	srv := NewServer(vm.Debugger)
+
+	serverConn, clientConn := net.Pipe()
+	go srv.Serve(serverConn)
+	client := &testClient{t: t, conn: clientConn, reader: bufio.NewReader(clientConn)}
+
+	client.request(`initialize`, map[string]interface{}{})
+	assert.Equal(t, true, client.readResponse()["success"])
+	assert.Equal(t, `initialized`, client.readEvent()["event"])
+
+	client.request(`setBreakpoints`, setBreakpointsArguments{Source: source{Path: file.Name()},
+		Breakpoints: []sourceBreakpoint{{Line: 7}}})
+	resp := client.readResponse()
+	assert.Equal(t, true, resp["success"])
+
+	client.request(`configurationDone`, nil)
+	client.readResponse()
+	select {
+	case <-srv.Ready():
+	default:
+		t.Error(`server is not ready`)
+	}
+
+	done := make(chan []interface{})
+	go func() {
+		out, err := vm.Call(`debugged`, nil, &map[string]interface{}{`rt_state`: uint32(1)})
+		assert.NoError(t, err)
+		done <- out
+	}()
+	stopped := client.readEvent()
+	assert.Equal(t, `stopped`, stopped["event"])
+	assert.Equal(t, script.StopBreakpoint, stopped["body"].(map[string]interface{})["reason"])
+
+	client.request(`stackTrace`, map[string]interface{}{"threadId": threadID})
+	frames := client.readResponse()["body"].(map[string]interface{})["stackFrames"].([]interface{})
+	if assert.Len(t, frames, 1) {
+		frame := frames[0].(map[string]interface{})
+		assert.Equal(t, `debugged`, frame["name"])
+		assert.Equal(t, float64(7), frame["line"])
+		assert.Equal(t, file.Name(), frame["source"].(map[string]interface{})["path"])
+	}
+
+	client.request(`variables`, map[string]interface{}{"variablesReference": 1})
+	vars := client.readResponse()["body"].(map[string]interface{})["variables"].([]interface{})
+	if assert.Len(t, vars, 1) {
+		assert.Equal(t, `x`, vars[0].(map[string]interface{})["name"])
+		assert.Equal(t, `5`, vars[0].(map[string]interface{})["value"])
+	}
+
+	client.request(`continue`, map[string]interface{}{"threadId": threadID})
+	client.readResponse()
+	assert.Equal(t, []interface{}{int64(11)}, <-done)
+
+	client.request(`disconnect`, nil)
+	client.readResponse()
+	clientConn.Close()
+}
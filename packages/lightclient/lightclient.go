@@ -0,0 +1,315 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package lightclient
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/protocol"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HeadersPerRequest is the count of headers which are requested from the full node at once
+const HeadersPerRequest = 100
+
+// apiTimeout is the timeout of requests to the api of full nodes
+const apiTimeout = 10 * time.Second
+
+var (
+	// ErrUnknownBlock is returned when the header of the block hasn't been received yet
+	ErrUnknownBlock = errors.New("Header of block has not been received")
+	// ErrWrongProof is returned when the merkle path doesn't lead to the root of the block
+	ErrWrongProof = errors.New("Merkle proof does not match the header of block")
+	// ErrNoProof is returned when none of the full nodes have returned the valid proof
+	ErrNoProof = errors.New("Proof of transaction has not been received")
+	// ErrNoStateRoot is returned when the header of the next block doesn't contain the state root
+	ErrNoStateRoot = errors.New("State root of block has not been committed")
+)
+
+// Node is the full node which produces blocks, its position in the list is the node position in headers
+type Node struct {
+	PublicKey  []byte
+	TCPAddress string
+	APIAddress string
+}
+
+// Header is the verified header of the block
+type Header struct {
+	protocol.BlockData
+	MrklRoot []byte
+}
+
+// TxProof is the proof of the transaction which is returned by txproof api of full nodes
+type TxProof struct {
+	BlockID int64                      `json:"block_id"`
+	Data    string                     `json:"data"`
+	Proof   []protocol.MerkleProofItem `json:"proof"`
+}
+
+// Client follows the headers of blocks from full nodes and verifies that transactions are included in blocks.
+// It trusts only the hash of the checkpoint block and the list of full nodes which produce blocks
+type Client struct {
+	mutex   sync.RWMutex
+	nodes   []Node
+	headers map[int64]*Header
+	last    *Header
+}
+
+// New returns the client which follows the blocks after the checkpoint block with the hash.
+// The checkpoint can't be less than the first block because the first block isn't signed
+func New(nodes []Node, blockID int64, hash []byte) *Client {
+	checkpoint := &Header{BlockData: protocol.BlockData{BlockID: blockID, Hash: hash}}
+	return &Client{
+		nodes:   nodes,
+		headers: map[int64]*Header{blockID: checkpoint},
+		last:    checkpoint,
+	}
+}
+
+// LastBlockID returns the id of the last verified block
+func (c *Client) LastBlockID() int64 {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.last.BlockID
+}
+
+// GetHeader returns the verified header of the block
+func (c *Client) GetHeader(blockID int64) (*Header, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	header, ok := c.headers[blockID]
+	if !ok {
+		return nil, ErrUnknownBlock
+	}
+	return header, nil
+}
+
+// GetStateRoot returns the verified state root after playing the block. The state root of block N
+// is committed by the header of block N+1, so it's unknown until the next block has been received
+func (c *Client) GetStateRoot(blockID int64) ([]byte, error) {
+	header, err := c.GetHeader(blockID + 1)
+	if err != nil {
+		return nil, err
+	}
+	if header.Version < consts.BlockVersionStateRoot || len(header.StateRoot) == 0 {
+		return nil, ErrNoStateRoot
+	}
+	return header.StateRoot, nil
+}
+
+// Sync requests the new headers from the full nodes and returns the id of the last verified block
+func (c *Client) Sync() (int64, error) {
+	var (
+		synced  bool
+		lastErr error
+	)
+	for _, node := range c.nodes {
+		if len(node.TCPAddress) == 0 {
+			continue
+		}
+		if err := c.follow(node.TCPAddress); err != nil {
+			log.WithFields(log.Fields{"type": consts.BlockError, "error": err, "host": node.TCPAddress}).Warn("following headers")
+			lastErr = err
+			continue
+		}
+		synced = true
+	}
+	if !synced && lastErr != nil {
+		return 0, lastErr
+	}
+	return c.LastBlockID(), nil
+}
+
+// follow adds the headers from the host while they can be verified
+func (c *Client) follow(host string) error {
+	for {
+		list, err := getHeaders(host, c.LastBlockID()+1)
+		if err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		for _, item := range list {
+			if err = c.addHeader(item); err != nil {
+				return err
+			}
+		}
+		if len(list) < HeadersPerRequest {
+			return nil
+		}
+	}
+}
+
+// addHeader checks the signature of the producer and the link to the last header
+func (c *Client) addHeader(item *protocol.GetHeaderResponse) error {
+	header, err := protocol.ParseBlockHeader(bytes.NewBuffer(item.Header), 0)
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if header.BlockID != c.last.BlockID+1 {
+		return fmt.Errorf("wrong block id %d of header", header.BlockID)
+	}
+	if header.NodePosition < 0 || header.NodePosition >= int64(len(c.nodes)) {
+		return fmt.Errorf("unknown node position %d of block %d", header.NodePosition, header.BlockID)
+	}
+	if len(header.Sign) == 0 {
+		return fmt.Errorf("block %d isn't signed", header.BlockID)
+	}
+	ok, err := crypto.CheckSign(c.nodes[header.NodePosition].PublicKey,
+		header.ForSign(c.last.Hash, item.MrklRoot), header.Sign)
+	if err != nil || !ok {
+		return fmt.Errorf("incorrect signature of block %d", header.BlockID)
+	}
+	header.Hash, err = crypto.DoubleHash([]byte(header.ForSha(c.last.Hash, item.MrklRoot)))
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("double hashing block")
+		return err
+	}
+
+	c.last = &Header{BlockData: header, MrklRoot: item.MrklRoot}
+	c.headers[header.BlockID] = c.last
+	return nil
+}
+
+// VerifyTx checks that the transaction with the hash is included in the block with the verified header
+func (c *Client) VerifyTx(hash []byte, proof *TxProof) error {
+	data, err := hex.DecodeString(proof.Data)
+	if err != nil {
+		return err
+	}
+	txHash, err := crypto.Hash(data)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(txHash, hash) {
+		return fmt.Errorf("data of transaction doesn't match hash %x", hash)
+	}
+	header, err := c.GetHeader(proof.BlockID)
+	if err != nil {
+		return err
+	}
+	items, err := protocol.MerkleTreeItems([][]byte{data})
+	if err != nil {
+		return err
+	}
+	root, err := protocol.MerkleProofRoot(items[0], proof.Proof)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(root, header.MrklRoot) {
+		return ErrWrongProof
+	}
+	return nil
+}
+
+// GetTxProof requests the proof of the transaction from the api of full nodes and returns
+// the first proof which is verified against the headers. The headers are synchronized if it's necessary
+func (c *Client) GetTxProof(hash []byte) (*TxProof, error) {
+	for _, node := range c.nodes {
+		if len(node.APIAddress) == 0 {
+			continue
+		}
+		proof, err := getTxProof(node.APIAddress, hash)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.NetworkError, "error": err, "host": node.APIAddress}).Warn("getting proof of transaction")
+			continue
+		}
+		if proof.BlockID > c.LastBlockID() {
+			if _, err = c.Sync(); err != nil {
+				return nil, err
+			}
+		}
+		if err = c.VerifyTx(hash, proof); err != nil {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "error": err, "host": node.APIAddress}).Warn("verifying proof of transaction")
+			continue
+		}
+		return proof, nil
+	}
+	return nil, ErrNoProof
+}
+
+// getHeaders requests the headers of the blocks starting from blockID
+func getHeaders(host string, blockID int64) ([]*protocol.GetHeaderResponse, error) {
+	conn, err := net.DialTimeout("tcp", host, consts.TCPConnTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(consts.READ_TIMEOUT * time.Second))
+
+	if err = protocol.SendRequestType(protocol.RequestTypeBlockHeaders, conn); err != nil {
+		return nil, err
+	}
+	req := &protocol.GetBlocksRangeRequest{BlockID: uint32(blockID), Count: HeadersPerRequest}
+	if err = protocol.SendRequest(req, conn); err != nil {
+		return nil, err
+	}
+	resp := &protocol.BlocksCountResponse{}
+	if err = protocol.ReadRequest(resp, conn); err != nil {
+		return nil, err
+	}
+	if resp.Count > HeadersPerRequest {
+		return nil, fmt.Errorf("host %s sent %d headers instead of %d", host, resp.Count, HeadersPerRequest)
+	}
+	headers := make([]*protocol.GetHeaderResponse, resp.Count)
+	for i := range headers {
+		headers[i] = &protocol.GetHeaderResponse{}
+		if err = protocol.ReadRequest(headers[i], conn); err != nil {
+			return nil, err
+		}
+	}
+	return headers, nil
+}
+
+// getTxProof requests the proof of the transaction from the api
+func getTxProof(apiAddress string, hash []byte) (*TxProof, error) {
+	client := &http.Client{Timeout: apiTimeout}
+	resp, err := client.Get(strings.TrimRight(apiAddress, `/`) + consts.ApiPath + `txproof/` + hex.EncodeToString(hash))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api returned status %d", resp.StatusCode)
+	}
+	proof := &TxProof{}
+	if err = json.NewDecoder(resp.Body).Decode(proof); err != nil {
+		return nil, err
+	}
+	return proof, nil
+}
@@ -0,0 +1,154 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package lightclient
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/protocol"
+)
+
+func genKey(t *testing.T) (*ecdsa.PrivateKey, []byte) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private, append(converter.FillLeft(private.X.Bytes()), converter.FillLeft(private.Y.Bytes())...)
+}
+
+func sign(t *testing.T, private *ecdsa.PrivateKey, data string) []byte {
+	hash, err := crypto.Hash([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, private, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(converter.FillLeft(r.Bytes()), converter.FillLeft(s.Bytes())...)
+}
+
+func signedBlock(t *testing.T, privateKey *ecdsa.PrivateKey, blockID int64, prevHash []byte, txs [][]byte) []byte {
+	header := protocol.BlockData{BlockID: blockID, Time: 1500000000 + blockID, KeyID: 1, Version: 2,
+		StateRoot: []byte(fmt.Sprintf("root after %d", blockID-1))}
+	items, err := protocol.MerkleTreeItems(txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := sign(t, privateKey, header.ForSign(prevHash, protocol.MerkleTreeRoot(items)))
+	var data bytes.Buffer
+	data.Write(converter.DecToBin(header.Version, 2))
+	data.Write(converter.DecToBin(header.BlockID, 4))
+	data.Write(converter.DecToBin(header.Time, 4))
+	data.Write(converter.DecToBin(header.EcosystemID, 4))
+	data.Write(converter.EncodeLenInt64InPlace(header.KeyID))
+	data.Write(converter.DecToBin(header.NodePosition, 1))
+	data.Write(converter.EncodeLengthPlusData(header.StateRoot))
+	data.Write(converter.EncodeLengthPlusData(signature))
+	for _, tx := range txs {
+		data.Write(converter.EncodeLengthPlusData(tx))
+	}
+	return data.Bytes()
+}
+
+func headerResponse(t *testing.T, data []byte) *protocol.GetHeaderResponse {
+	header, mrklRoot, err := protocol.SplitBlockHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &protocol.GetHeaderResponse{Header: header, MrklRoot: mrklRoot}
+}
+
+func TestVerifyTx(t *testing.T) {
+	privateKey, publicKey := genKey(t)
+	otherKey, _ := genKey(t)
+	checkpoint := []byte("hash of checkpoint")
+	client := New([]Node{{PublicKey: publicKey}}, 10, checkpoint)
+
+	if err := client.addHeader(headerResponse(t, signedBlock(t, otherKey, 11, checkpoint, nil))); err == nil {
+		t.Error("header signed by unknown key must be rejected")
+	}
+
+	txs := [][]byte{[]byte("first tx"), []byte("second tx"), []byte("third tx")}
+	if err := client.addHeader(headerResponse(t, signedBlock(t, privateKey, 11, checkpoint, txs))); err != nil {
+		t.Fatal(err)
+	}
+	if client.LastBlockID() != 11 {
+		t.Errorf("wrong last block %d", client.LastBlockID())
+	}
+	last, _ := client.GetHeader(11)
+	if err := client.addHeader(headerResponse(t, signedBlock(t, privateKey, 12, checkpoint, nil))); err == nil {
+		t.Error("header with wrong previous hash must be rejected")
+	}
+	if err := client.addHeader(headerResponse(t, signedBlock(t, privateKey, 12, last.Hash, nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := protocol.MerkleTreeItems(txs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := protocol.MerkleTreeProof(items, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := crypto.Hash(txs[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof := &TxProof{BlockID: 11, Data: hex.EncodeToString(txs[1]), Proof: path}
+	if err = client.VerifyTx(hash, proof); err != nil {
+		t.Error(err)
+	}
+	proof.BlockID = 12
+	if err = client.VerifyTx(hash, proof); err != ErrWrongProof {
+		t.Errorf("proof for other block must be rejected, got %v", err)
+	}
+	proof.BlockID = 13
+	if err = client.VerifyTx(hash, proof); err != ErrUnknownBlock {
+		t.Errorf("proof for unknown block must be rejected, got %v", err)
+	}
+	proof.BlockID = 11
+	proof.Data = hex.EncodeToString(txs[0])
+	if err = client.VerifyTx(hash, proof); err == nil {
+		t.Error("data of other transaction must be rejected")
+	}
+
+	// the state after block N is committed by the header of block N+1
+	if root, err := client.GetStateRoot(11); err != nil || string(root) != "root after 11" {
+		t.Errorf("wrong state root of block 11 %q, %v", root, err)
+	}
+	if _, err = client.GetStateRoot(12); err != ErrUnknownBlock {
+		t.Errorf("state root of the last block must be unknown, got %v", err)
+	}
+	if _, err = client.GetStateRoot(9); err != ErrNoStateRoot {
+		t.Errorf("checkpoint has no state root, got %v", err)
+	}
+}
@@ -0,0 +1,381 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package mempool
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/transaction"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultMaxSize is the max size of pending transactions if it isn't specified in the config
+	DefaultMaxSize = 32 << 20
+	// DefaultMaxAge is the max time in seconds of waiting for the block if it isn't specified in the config
+	DefaultMaxAge = 3600
+	// ReplaceBumpPercent is the min increase of the fuel rate which is required to replace the pending transaction
+	ReplaceBumpPercent = 10
+)
+
+// Item is the pending transaction
+type Item struct {
+	Hash     []byte
+	KeyID    int64
+	Contract string
+	Time     int64 // the time of the transaction
+	Added    int64 // the time when the transaction has been added to the pool
+	Size     int
+	HighRate int64
+	Rate     decimal.Decimal // the effective fuel rate which is the fuel rate of the ecosystem and PayOver
+	Replaces []byte          // the hash of the pending transaction which is replaced by this one
+}
+
+// before returns true if the item must be included in the block before the other one
+func (item *Item) before(other *Item) bool {
+	if item.HighRate != other.HighRate {
+		return item.HighRate > other.HighRate
+	}
+	if cmp := item.Rate.Cmp(other.Rate); cmp != 0 {
+		return cmp > 0
+	}
+	if item.Added != other.Added {
+		return item.Added < other.Added
+	}
+	return bytes.Compare(item.Hash, other.Hash) < 0
+}
+
+// dropped is the transaction which has been removed from the pool by the policy of the pool
+type dropped struct {
+	hash   []byte
+	reason string
+}
+
+// Pool is the ordered set of pending transactions. The transaction which names the pending transaction
+// of the same key in Replaces takes its place if it has the higher fuel rate
+type Pool struct {
+	mutex    sync.RWMutex
+	items    map[string]*Item
+	replaced map[string]*Item // the pending replacements by the hashes of the replaced transactions
+	size     int
+}
+
+// NewPool returns the empty pool
+func NewPool() *Pool {
+	return &Pool{
+		items:    make(map[string]*Item),
+		replaced: make(map[string]*Item),
+	}
+}
+
+var pool = NewPool()
+
+func (p *Pool) remove(item *Item) {
+	delete(p.items, string(item.Hash))
+	if p.replaced[string(item.Replaces)] == item {
+		delete(p.replaced, string(item.Replaces))
+	}
+	p.size -= item.Size
+}
+
+// add puts the item into the pool and returns the transaction which has lost the replacement
+func (p *Pool) add(item *Item) *dropped {
+	if _, ok := p.items[string(item.Hash)]; ok {
+		return nil
+	}
+	if by, ok := p.replaced[string(item.Hash)]; ok {
+		return &dropped{item.Hash, fmt.Sprintf("replaced by %x", by.Hash)}
+	}
+	if len(item.Replaces) > 0 {
+		prev, ok := p.items[string(item.Replaces)]
+		if !ok {
+			return &dropped{item.Hash, fmt.Sprintf("transaction %x to replace is not pending", item.Replaces)}
+		}
+		if prev.KeyID != item.KeyID {
+			return &dropped{item.Hash, fmt.Sprintf("transaction %x of other key can't be replaced", item.Replaces)}
+		}
+		bump := prev.Rate.Mul(decimal.New(100+ReplaceBumpPercent, -2))
+		if item.Rate.Cmp(bump) < 0 {
+			return &dropped{item.Hash, fmt.Sprintf("fuel rate is too low to replace %x", prev.Hash)}
+		}
+		p.remove(prev)
+		p.insert(item)
+		return &dropped{prev.Hash, fmt.Sprintf("replaced by %x", item.Hash)}
+	}
+	p.insert(item)
+	return nil
+}
+
+func (p *Pool) insert(item *Item) {
+	p.items[string(item.Hash)] = item
+	if len(item.Replaces) > 0 {
+		p.replaced[string(item.Replaces)] = item
+	}
+	p.size += item.Size
+}
+
+// versions returns the pending transactions which are replaced by the transaction or replace it
+func (p *Pool) versions(t *transaction.Transaction) []*Item {
+	var list []*Item
+	if t.TxSmart != nil && len(t.TxSmart.Replaces) > 0 {
+		if item, ok := p.items[string(t.TxSmart.Replaces)]; ok {
+			list = append(list, item)
+		}
+	}
+	if item, ok := p.replaced[string(t.TxHash)]; ok {
+		list = append(list, item)
+	}
+	return list
+}
+
+// sorted returns the items in the order of including in the block
+func (p *Pool) sorted() []*Item {
+	list := make([]*Item, 0, len(p.items))
+	for _, item := range p.items {
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].before(list[j]) })
+	return list
+}
+
+// evict removes the transactions which are waiting too long and then the transactions
+// with the lowest priority until the size of the pool is less than maxSize
+func (p *Pool) evict(now, maxAge int64, maxSize int) []dropped {
+	var result []dropped
+	for _, item := range p.items {
+		if now-item.Added > maxAge {
+			p.remove(item)
+			result = append(result, dropped{item.Hash, "transaction has been waiting for the block too long"})
+		}
+	}
+	if p.size <= maxSize {
+		return result
+	}
+	list := p.sorted()
+	for i := len(list) - 1; i >= 0 && p.size > maxSize; i-- {
+		p.remove(list[i])
+		result = append(result, dropped{list[i].Hash, "mempool is full"})
+	}
+	return result
+}
+
+// txRate returns the effective fuel rate of the transaction
+func txRate(t *transaction.Transaction) decimal.Decimal {
+	rate := decimal.New(0, 0)
+	if t.TxSmart == nil {
+		return rate
+	}
+	tokenEcosystem := t.TxSmart.TokenEcosystem
+	if tokenEcosystem == 0 {
+		tokenEcosystem = 1
+	}
+	if fuelRate, err := decimal.NewFromString(syspar.GetFuelRate(tokenEcosystem)); err == nil {
+		rate = fuelRate
+	}
+	if len(t.TxSmart.PayOver) > 0 {
+		if payOver, err := decimal.NewFromString(t.TxSmart.PayOver); err == nil {
+			rate = rate.Add(payOver)
+		}
+	}
+	return rate
+}
+
+func newItem(tx *model.Transaction, now int64) (*Item, error) {
+	t, err := transaction.UnmarshallTransaction(bytes.NewBuffer(tx.Data))
+	if err != nil {
+		return nil, err
+	}
+	item := txItem(t, now)
+	item.Hash = tx.Hash
+	item.Size = len(tx.Data)
+	item.HighRate = int64(tx.HighRate)
+	return item, nil
+}
+
+// txItem returns the item of the parsed transaction
+func txItem(t *transaction.Transaction, now int64) *Item {
+	item := &Item{
+		Hash:  t.TxHash,
+		KeyID: t.TxKeyID,
+		Time:  t.TxTime,
+		Added: now,
+		Rate:  txRate(t),
+	}
+	if t.TxSmart != nil {
+		item.Replaces = t.TxSmart.Replaces
+	}
+	if t.TxContract != nil {
+		item.Contract = t.TxContract.Name
+	} else {
+		item.Contract = consts.TxTypes[int(t.TxType)]
+	}
+	return item
+}
+
+func maxSize() int {
+	if conf.Config.Mempool.MaxSize > 0 {
+		return int(conf.Config.Mempool.MaxSize)
+	}
+	return DefaultMaxSize
+}
+
+func maxAge() int64 {
+	if conf.Config.Mempool.MaxAge > 0 {
+		return conf.Config.Mempool.MaxAge
+	}
+	return DefaultMaxAge
+}
+
+// Sync brings the pool in accordance with unused transactions of the database.
+// The transactions which are replaced or evicted by the pool are marked as bad
+func Sync() error {
+	hashes, err := model.GetUnusedTransactionHashes()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting unused transaction hashes")
+		return err
+	}
+	unused := make(map[string]bool, len(hashes))
+	var added [][]byte
+	pool.mutex.Lock()
+	for _, hash := range hashes {
+		unused[string(hash)] = true
+		if _, ok := pool.items[string(hash)]; !ok {
+			added = append(added, hash)
+		}
+	}
+	for hash, item := range pool.items {
+		if !unused[hash] {
+			pool.remove(item)
+		}
+	}
+	pool.mutex.Unlock()
+
+	txs, err := model.GetTransactionsByHashes(added)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting transactions by hashes")
+		return err
+	}
+	now := time.Now().Unix()
+	var drop []dropped
+	items := make([]*Item, 0, len(txs))
+	for _, tx := range txs {
+		item, err := newItem(tx, now)
+		if err != nil {
+			drop = append(drop, dropped{tx.Hash, err.Error()})
+			continue
+		}
+		items = append(items, item)
+	}
+	// the replaced transactions must be in the pool before their replacements
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Time != items[j].Time {
+			return items[i].Time < items[j].Time
+		}
+		return len(items[i].Replaces) == 0 && len(items[j].Replaces) > 0
+	})
+	pool.mutex.Lock()
+	for _, item := range items {
+		if d := pool.add(item); d != nil {
+			drop = append(drop, *d)
+		}
+	}
+	drop = append(drop, pool.evict(now, maxAge(), maxSize())...)
+	pool.mutex.Unlock()
+
+	for _, item := range drop {
+		transaction.MarkTransactionBad(nil, item.hash, item.reason)
+	}
+	return nil
+}
+
+// Pending returns the pending transactions in the order of including in the block
+func Pending(limit int) ([]*model.Transaction, error) {
+	if err := Sync(); err != nil {
+		return nil, err
+	}
+	pool.mutex.RLock()
+	list := pool.sorted()
+	pool.mutex.RUnlock()
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	hashes := make([][]byte, len(list))
+	for i, item := range list {
+		hashes[i] = item.Hash
+	}
+	txs, err := model.GetTransactionsByHashes(hashes)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting transactions by hashes")
+		return nil, err
+	}
+	byHash := make(map[string]*model.Transaction, len(txs))
+	for _, tx := range txs {
+		byHash[string(tx.Hash)] = tx
+	}
+	result := make([]*model.Transaction, 0, len(txs))
+	for _, item := range list {
+		if tx, ok := byHash[string(item.Hash)]; ok {
+			result = append(result, tx)
+		}
+	}
+	return result, nil
+}
+
+// List returns the copies of pending transactions in the order of including in the block and the total size
+func List() ([]Item, int) {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+	list := pool.sorted()
+	result := make([]Item, len(list))
+	for i, item := range list {
+		result[i] = *item
+	}
+	return result, pool.size
+}
+
+// DropReplaced removes the pending transactions which are other versions of the played transactions.
+// The replaced transaction can be included in the block by the node which hasn't received the replacement yet
+func DropReplaced(txs []*transaction.Transaction) {
+	var drop []dropped
+	pool.mutex.Lock()
+	for _, t := range txs {
+		for _, item := range pool.versions(t) {
+			pool.remove(item)
+			drop = append(drop, dropped{item.Hash, "other version of transaction " + hex.EncodeToString(t.TxHash) + " has been played"})
+		}
+	}
+	pool.mutex.Unlock()
+	for _, item := range drop {
+		transaction.MarkTransactionBad(nil, item.hash, item.reason)
+	}
+}
@@ -0,0 +1,139 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package mempool
+
+import (
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/transaction"
+	"github.com/GenesisCommunity/go-genesis/packages/utils/tx"
+
+	"github.com/shopspring/decimal"
+)
+
+func newTestItem(hash, replaces string, rate int64, added int64, size int) *Item {
+	item := &Item{
+		Hash:  []byte(hash),
+		Rate:  decimal.New(rate, 0),
+		Added: added,
+		Size:  size,
+	}
+	if len(replaces) > 0 {
+		item.Replaces = []byte(replaces)
+	}
+	return item
+}
+
+func TestOrder(t *testing.T) {
+	p := NewPool()
+	p.add(newTestItem("a", "", 100, 10, 1))
+	p.add(newTestItem("b", "", 200, 20, 1))
+	p.add(newTestItem("c", "", 100, 5, 1))
+	high := newTestItem("d", "", 1, 30, 1)
+	high.HighRate = 1
+	p.add(high)
+
+	list := p.sorted()
+	for i, hash := range []string{"d", "b", "c", "a"} {
+		if string(list[i].Hash) != hash {
+			t.Errorf("wrong order at %d: %s != %s", i, list[i].Hash, hash)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	p := NewPool()
+	p.add(newTestItem("a", "", 100, 10, 1))
+
+	if d := p.add(newTestItem("b", "a", 105, 11, 1)); d == nil || string(d.hash) != "b" {
+		t.Errorf("replacement with low fuel rate must be rejected")
+	}
+	if d := p.add(newTestItem("c", "a", 110, 12, 1)); d == nil || string(d.hash) != "a" {
+		t.Errorf("pending transaction must be replaced")
+	}
+	if len(p.items) != 1 || p.items["c"] == nil || p.replaced["a"] == nil || p.size != 1 {
+		t.Errorf("wrong pool state after replacement")
+	}
+	if d := p.add(newTestItem("a", "", 100, 10, 1)); d == nil || string(d.hash) != "a" {
+		t.Errorf("replaced transaction must not be added again")
+	}
+	if d := p.add(newTestItem("d", "x", 200, 13, 1)); d == nil || string(d.hash) != "d" {
+		t.Errorf("replacement of unknown transaction must be rejected")
+	}
+	other := newTestItem("e", "c", 200, 13, 1)
+	other.KeyID = 1
+	if d := p.add(other); d == nil || string(d.hash) != "e" {
+		t.Errorf("replacement of transaction of other key must be rejected")
+	}
+	p.remove(p.items["c"])
+	if len(p.replaced) != 0 || p.size != 0 {
+		t.Errorf("wrong pool state after removing")
+	}
+}
+
+func TestIdenticalCalls(t *testing.T) {
+	prepare := func(hash, requestID string, time int64, payOver string, replaces []byte) *Item {
+		return txItem(&transaction.Transaction{TxHash: []byte(hash), TxKeyID: 100, TxTime: time,
+			TxSmart: &tx.SmartContract{
+				Header:    tx.Header{Type: 5, Time: time, EcosystemID: 1, KeyID: 100},
+				RequestID: requestID,
+				PayOver:   payOver,
+				Data:      []byte("amount=10"),
+				Replaces:  replaces,
+			}}, 10)
+	}
+	p := NewPool()
+	// the same call is sent twice and both transactions must be played
+	if d := p.add(prepare("a", "req1", 1000, "", nil)); d != nil {
+		t.Errorf("unexpected drop %v", d)
+	}
+	if d := p.add(prepare("b", "req2", 1005, "", nil)); d != nil {
+		t.Errorf("identical call must not replace the pending one: %v", d)
+	}
+	// the call which has been prepared again with the higher PayOver and names the replaced transaction
+	if d := p.add(prepare("c", "req3", 1010, "50", []byte("a"))); d == nil || string(d.hash) != "a" {
+		t.Errorf("pending transaction must be replaced explicitly")
+	}
+	if len(p.items) != 2 || p.items["b"] == nil || p.items["c"] == nil {
+		t.Errorf("wrong pool state after replacement")
+	}
+	played := &transaction.Transaction{TxHash: []byte("a"), TxSmart: &tx.SmartContract{}}
+	if list := p.versions(played); len(list) != 1 || string(list[0].Hash) != "c" {
+		t.Errorf("replacement must be dropped when the replaced transaction has been played")
+	}
+}
+
+func TestEvict(t *testing.T) {
+	p := NewPool()
+	p.add(newTestItem("a", "", 100, 10, 10))
+	p.add(newTestItem("b", "", 300, 50, 10))
+	p.add(newTestItem("c", "", 200, 50, 10))
+	p.add(newTestItem("d", "", 100, 50, 10))
+
+	drop := p.evict(100, 60, 15)
+	if len(drop) != 3 || string(drop[0].hash) != "a" || string(drop[1].hash) != "d" || string(drop[2].hash) != "c" {
+		t.Errorf("wrong evicted transactions %v", drop)
+	}
+	if len(p.items) != 1 || p.size != 10 || p.items["b"] == nil {
+		t.Errorf("wrong pool state after eviction")
+	}
+}
@@ -170,4 +170,101 @@ var (
 		DROP TABLE IF EXISTS "stop_daemons"; CREATE TABLE "stop_daemons" (
 		"stop_time" int NOT NULL DEFAULT '0'
 		);`
+
+	migrationStateRoot = `ALTER TABLE "block_chain" ADD COLUMN IF NOT EXISTS "state_root" bytea NOT NULL DEFAULT '';`
+
+	migrationPruning = `ALTER TABLE "info_block" ADD COLUMN IF NOT EXISTS "pruned_block_id" int NOT NULL DEFAULT '0',
+		ADD COLUMN IF NOT EXISTS "pruned_body_id" int NOT NULL DEFAULT '0';`
+
+	migrationArchive = `CREATE TABLE IF NOT EXISTS "archive_checkpoints" (
+		"block_id" bigint NOT NULL DEFAULT '0',
+		"table_name" varchar(255) NOT NULL DEFAULT '',
+		"table_id" varchar(255) NOT NULL DEFAULT '',
+		"data" text NOT NULL DEFAULT '',
+		PRIMARY KEY (table_name, table_id, block_id)
+		);
+		CREATE INDEX IF NOT EXISTS "archive_checkpoints_block" ON "archive_checkpoints" (block_id);
+		CREATE INDEX IF NOT EXISTS "rollback_tx_table_block" ON "rollback_tx" (table_name, block_id);`
+
+	migrationReorgs = `ALTER TABLE "transactions_status" ADD COLUMN IF NOT EXISTS "reverted_block_id" bigint NOT NULL DEFAULT '0';
+		CREATE SEQUENCE IF NOT EXISTS reorgs_id_seq START WITH 1;
+		CREATE TABLE IF NOT EXISTS "reorgs" (
+		"id" bigint NOT NULL default nextval('reorgs_id_seq'),
+		"time" bigint NOT NULL DEFAULT '0',
+		"old_block_id" bigint NOT NULL DEFAULT '0',
+		"old_hash" bytea NOT NULL DEFAULT '',
+		"new_block_id" bigint NOT NULL DEFAULT '0',
+		"new_hash" bytea NOT NULL DEFAULT '',
+		"depth" bigint NOT NULL DEFAULT '0',
+		"tx_hashes" text NOT NULL DEFAULT '',
+		PRIMARY KEY (id)
+		);
+		ALTER SEQUENCE reorgs_id_seq owned by reorgs.id;`
+
+	migrationPeers = `CREATE TABLE IF NOT EXISTS "peers" (
+		"address" varchar(255) NOT NULL DEFAULT '',
+		"public_key" bytea NOT NULL DEFAULT '',
+		"last_seen" bigint NOT NULL DEFAULT '0',
+		"signature" bytea NOT NULL DEFAULT '',
+		"latency" bigint NOT NULL DEFAULT '0',
+		"failures" bigint NOT NULL DEFAULT '0',
+		"bans" bigint NOT NULL DEFAULT '0',
+		"ban_time" bigint NOT NULL DEFAULT '0',
+		PRIMARY KEY (address)
+		);`
+
+	migrationTxErrors = `ALTER TABLE "transactions_status" ALTER COLUMN "error" TYPE text;`
+
+	migrationTxFuel = `ALTER TABLE "transactions_status" ADD COLUMN IF NOT EXISTS "fuel" text NOT NULL DEFAULT '';`
+
+	migrationPeersSource = `ALTER TABLE "peers" ADD COLUMN IF NOT EXISTS "source" varchar(255) NOT NULL DEFAULT '';`
+
+	migrationAnalyzeContracts = `DO $$
+		BEGIN
+			IF to_regclass('"1_contracts"') IS NULL THEN
+				RETURN;
+			END IF;
+			UPDATE "1_contracts" SET "value" = regexp_replace("value",
+				'(\$contract_name = ContractName\(\$Value\))', E'\\1\n        AnalyzeContract($Value, $ecosystem_id)')
+			WHERE "name" = 'NewContract' AND strpos("value", 'AnalyzeContract(') = 0;
+			UPDATE "1_contracts" SET "value" = regexp_replace("value",
+				'(ValidateEditContractNewValue\(\$Value, \$cur\["value"\]\))', E'\\1\n            AnalyzeContract($Value, $ecosystem_id)')
+			WHERE "name" = 'EditContract' AND strpos("value", 'AnalyzeContract(') = 0;
+		END $$;`
+
+	migrationStateRootParam = `DO $$
+		BEGIN
+			IF to_regclass('"1_system_parameters"') IS NULL THEN
+				RETURN;
+			END IF;
+			INSERT INTO "1_system_parameters" ("id", "name", "value", "conditions")
+			SELECT (SELECT max(id) + 1 FROM "1_system_parameters"), 'state_root_block_id', '0', 'true'
+			WHERE NOT EXISTS (SELECT 1 FROM "1_system_parameters" WHERE name = 'state_root_block_id');
+		END $$;`
+
+	migrationContractVersions = `DO $$
+		DECLARE
+			eco record;
+		BEGIN
+			IF to_regclass('"1_ecosystems"') IS NULL THEN
+				RETURN;
+			END IF;
+			FOR eco IN SELECT id FROM "1_ecosystems" LOOP
+				EXECUTE format('CREATE TABLE IF NOT EXISTS "%s_contract_versions" (
+				"id" bigint NOT NULL DEFAULT ''0'',
+				"contract_id" bigint NOT NULL DEFAULT ''0'',
+				"name" text NOT NULL DEFAULT '''',
+				"major" bigint NOT NULL DEFAULT ''1'',
+				"minor" bigint NOT NULL DEFAULT ''0'',
+				"value" text NOT NULL DEFAULT '''',
+				"hash" varchar(64) NOT NULL DEFAULT '''',
+				"key_id" bigint NOT NULL DEFAULT ''0'',
+				"block_id" bigint NOT NULL DEFAULT ''0'',
+				"migrated" bigint NOT NULL DEFAULT ''0'',
+				PRIMARY KEY (id)
+				);
+				CREATE INDEX IF NOT EXISTS "%s_contract_versions_index_contract" ON "%s_contract_versions" (contract_id);',
+				eco.id, eco.id, eco.id);
+			END LOOP;
+		END $$;`
 )
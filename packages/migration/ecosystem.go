@@ -139,6 +139,21 @@ var schemaEcosystem = `DROP TABLE IF EXISTS "%[1]d_keys"; CREATE TABLE "%[1]d_ke
 		"app_id" bigint NOT NULL DEFAULT '1'
 		);
 		ALTER TABLE ONLY "%[1]d_contracts" ADD CONSTRAINT "%[1]d_contracts_pkey" PRIMARY KEY (id);
+
+		DROP TABLE IF EXISTS "%[1]d_contract_versions"; CREATE TABLE "%[1]d_contract_versions" (
+		"id" bigint NOT NULL DEFAULT '0',
+		"contract_id" bigint NOT NULL DEFAULT '0',
+		"name" text NOT NULL DEFAULT '',
+		"major" bigint NOT NULL DEFAULT '1',
+		"minor" bigint NOT NULL DEFAULT '0',
+		"value" text NOT NULL DEFAULT '',
+		"hash" varchar(64) NOT NULL DEFAULT '',
+		"key_id" bigint NOT NULL DEFAULT '0',
+		"block_id" bigint NOT NULL DEFAULT '0',
+		"migrated" bigint NOT NULL DEFAULT '0'
+		);
+		ALTER TABLE ONLY "%[1]d_contract_versions" ADD CONSTRAINT "%[1]d_contract_versions_pkey" PRIMARY KEY (id);
+		CREATE INDEX "%[1]d_contract_versions_index_contract" ON "%[1]d_contract_versions" (contract_id);
 		
 		
 		DROP TABLE IF EXISTS "%[1]d_parameters";
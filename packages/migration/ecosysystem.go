@@ -184,7 +184,14 @@ var (
 	  ('2','contract VDEFunctions {}
 	  
 		func DBFind(table string).Columns(columns string).Where(where string, params ...)
-			.WhereId(id int).Order(order string).Limit(limit int).Offset(offset int).Ecosystem(ecosystem int) array {
+			.WhereId(id int).Order(order string).Limit(limit int).Offset(offset int).Ecosystem(ecosystem int)
+			.GroupBy(groupBy string).Having(having string) array {
+			if groupBy || having || Contains(columns, "(") {
+				if id {
+					error "WhereId cannot be used with GroupBy, Having or aggregate functions"
+				}
+				return DBAggregate(table, columns, groupBy, having, order, offset, limit, ecosystem, where, params)
+			}
 			return DBSelect(table, columns, id, order, offset, limit, ecosystem, where, params)
 		}
 
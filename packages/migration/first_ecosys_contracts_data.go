@@ -338,6 +338,7 @@ VALUES ('2', 'DelApplication', 'contract DelApplication {
         UpdatePerm string
         NewColumnPerm string
         ReadPerm string "optional"
+        DeletePerm string "optional"
     }
 
     conditions {
@@ -358,6 +359,9 @@ VALUES ('2', 'DelApplication', 'contract DelApplication {
         if $ReadPerm {
             permissions["read"] = $ReadPerm
         }
+        if $DeletePerm {
+            permissions["delete"] = $DeletePerm
+        }
         $Permissions = permissions
         TableConditions($Name, "", JSONEncode($Permissions))
     }
@@ -623,6 +627,7 @@ VALUES ('2', 'DelApplication', 'contract DelApplication {
         if !$contract_name {
             error "must be the name"
         }
+        AnalyzeContract($Value, $ecosystem_id)
 
         if !$TokenEcosystem {
             $TokenEcosystem = 1
@@ -1066,6 +1071,7 @@ VALUES ('2', 'DelApplication', 'contract DelApplication {
         Value string "optional"
         Conditions string "optional"
         WalletId string "optional"
+        Version string "optional"
     }
     func onlyConditions() bool {
         return $Conditions && !$Value && !$WalletId
@@ -1082,6 +1088,7 @@ VALUES ('2', 'DelApplication', 'contract DelApplication {
         }
         if $Value {
             ValidateEditContractNewValue($Value, $cur["value"])
+            AnalyzeContract($Value, $ecosystem_id)
         }
         if $WalletId != "" {
             $recipient = AddressToId($WalletId)
@@ -1097,7 +1104,7 @@ VALUES ('2', 'DelApplication', 'contract DelApplication {
     }
 
     action {
-        UpdateContract($Id, $Value, $Conditions, $WalletId, $recipient, $cur["active"], $cur["token_id"])
+        UpdateContract($Id, $Value, $Conditions, $WalletId, $recipient, $cur["active"], $cur["token_id"], $Version)
     }
     func rollback() {
         RollbackEditContract()
@@ -2612,5 +2619,53 @@ VALUES ('2', 'DelApplication', 'contract DelApplication {
         warning "Value must be greater than zero"
       }
     }
-}', %[1]d, 'ContractConditions("MainCondition")', 2);
+}', %[1]d, 'ContractConditions("MainCondition")', 2),
+('114', 'DelColumn', 'contract DelColumn {
+    data {
+        TableName string
+        Name string
+    }
+    action {
+        DropColumn($TableName, $Name)
+    }
+}', %[1]d, 'ContractConditions("MainCondition")', 1),
+('115', 'EditColumnName', 'contract EditColumnName {
+    data {
+        TableName string
+        Name string
+        NewName string
+    }
+    action {
+        RenameColumn($TableName, $Name, $NewName)
+    }
+}', %[1]d, 'ContractConditions("MainCondition")', 1),
+('116', 'EditColumnType', 'contract EditColumnType {
+    data {
+        TableName string
+        Name string
+        Type string
+    }
+    action {
+        AlterColumnType($TableName, $Name, $Type)
+    }
+}', %[1]d, 'ContractConditions("MainCondition")', 1),
+('117', 'NewIndex', 'contract NewIndex {
+    data {
+        TableName string
+        Name string
+        Columns string
+    }
+    action {
+        CreateIndex($TableName, $Name, $Columns)
+    }
+}', %[1]d, 'ContractConditions("MainCondition")', 1),
+('118', 'DelIndex', 'contract DelIndex {
+    data {
+        TableName string
+        Name string
+    }
+    action {
+        DropIndex($TableName, $Name)
+    }
+}', %[1]d, 'ContractConditions("MainCondition")', 1);
 `
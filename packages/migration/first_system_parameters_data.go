@@ -92,5 +92,13 @@ INSERT INTO "1_system_parameters" ("id","name", "value", "conditions") VALUES
 	('64','incorrect_blocks_per_day','10','true'),
 	('65','node_ban_time','86400000','true'),
 	('66','local_node_ban_time','1800000','true'),
-	('67','max_forsign_size', '1000000', 'true');
+	('67','max_forsign_size', '1000000', 'true'),
+	('68','extend_cost_sha3', '50', 'true'),
+	('69','extend_cost_keccak256', '50', 'true'),
+	('70','extend_cost_verify_signature', '200', 'true'),
+	('71','extend_cost_recover_address', '1000', 'true'),
+	('72','extend_cost_date', '10', 'true'),
+	('73','extend_cost_regexp', '10', 'true'),
+	('74','extend_cost_format_money', '10', 'true'),
+	('75','state_root_block_id', '0', 'true');
 `
@@ -34,6 +34,39 @@ var migrations = []*migration{
 
 	// Initial schema
 	&migration{"0.1.6b9", migrationInitialSchema},
+
+	// State root of blocks
+	&migration{"0.9.5", migrationStateRoot},
+
+	// Pruning of old blocks
+	&migration{"0.9.6", migrationPruning},
+
+	// Checkpoints of the archive mode
+	&migration{"0.9.7", migrationArchive},
+
+	// Log of reorganizations
+	&migration{"0.9.8", migrationReorgs},
+
+	// Address book of peers
+	&migration{"0.9.9", migrationPeers},
+
+	// Source positions and contract stack in transaction errors
+	&migration{"0.9.10", migrationTxErrors},
+
+	// Version history of contracts in the existing ecosystems
+	&migration{"0.9.11", migrationContractVersions},
+
+	// Breakdown of the fuel in transaction statuses
+	&migration{"0.9.12", migrationTxFuel},
+
+	// Activation height of the state root in the existing networks
+	&migration{"0.9.13", migrationStateRootParam},
+
+	// Hosts which have added the peers into the address book
+	&migration{"0.9.14", migrationPeersSource},
+
+	// Static analysis of the source in NewContract and EditContract of the existing networks
+	&migration{"0.9.15", migrationAnalyzeContracts},
 }
 
 type migration struct {
@@ -771,6 +771,9 @@ If(#buffer_value_app_id# > 0){
 					double,"Double"
 					character,"Character"
 					json,"JSON"
+					bool,"Boolean"
+					date,"Date"
+					uuid,"UUID"
 				}
 				Label("Type")
 				Select(Name: Coltype, Source: src_type, NameColumn: name, ValueColumn: type, Value:"text")
@@ -840,6 +843,12 @@ If(#buffer_value_app_id# > 0){
 						If(#col_type# == uuid){
 							SetVar(input_type, "UUID")
 						}
+						If(#col_type# == bool){
+							SetVar(input_type, "Boolean")
+						}
+						If(#col_type# == date){
+							SetVar(input_type, "Date")
+						}
 						Input(Name: Coltype, Disabled: "true", Value: #input_type#)
 					}
 					Div(form-group){
@@ -1579,6 +1588,9 @@ Div(content-wrapper){
 		double,"Double"
 		character,"Character"
 		json,"JSON"
+		bool,"Boolean"
+		date,"Date"
+		uuid,"UUID"
 	}
 	Form(){
 		Div(panel panel-default){
@@ -1726,6 +1738,12 @@ Div(content-wrapper){
 								If(#col_type# == uuid){
 									Span("UUID")
 								}
+								If(#col_type# == bool){
+									Span("Boolean")
+								}
+								If(#col_type# == date){
+									Span("Date")
+								}
 							}
 							Div(col-md-5 h5){
 								Span(#value#)
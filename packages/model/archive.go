@@ -0,0 +1,51 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package model
+
+// ArchiveCheckpoint is the value of the row after the checkpoint block. The empty data means that the row doesn't exist
+type ArchiveCheckpoint struct {
+	BlockID   int64  `gorm:"primary_key;not null"`
+	NameTable string `gorm:"primary_key;not null;size:255;column:table_name"`
+	TableID   string `gorm:"primary_key;not null;size:255"`
+	Data      string `gorm:"not null"`
+}
+
+// TableName returns name of table
+func (ArchiveCheckpoint) TableName() string {
+	return "archive_checkpoints"
+}
+
+// Create is creating record of model
+func (ac *ArchiveCheckpoint) Create(transaction *DbTransaction) error {
+	return GetDB(transaction).Create(ac).Error
+}
+
+// GetNext returns the first checkpoint of the row at the block or after it
+func (ac *ArchiveCheckpoint) GetNext(transaction *DbTransaction, tableName, tableID string, blockID int64) (bool, error) {
+	return isFound(GetDB(transaction).Where("table_name = ? AND table_id = ? AND block_id >= ?",
+		tableName, tableID, blockID).Order("block_id asc").First(ac))
+}
+
+// DeleteArchiveCheckpointsFrom deletes the checkpoints of blockID and later blocks
+func DeleteArchiveCheckpointsFrom(transaction *DbTransaction, blockID int64) error {
+	return GetDB(transaction).Exec("DELETE FROM archive_checkpoints WHERE block_id >= ?", blockID).Error
+}
@@ -34,6 +34,7 @@ type Block struct {
 	NodePosition  int64  `gorm:"not null"`
 	Time          int64  `gorm:"not null"`
 	Tx            int32  `gorm:"not null"`
+	StateRoot     []byte `gorm:"not null"`
 }
 
 // TableName returns name of table
@@ -120,3 +121,9 @@ func (b *Block) GetNodeBlocksAtTime(from, to time.Time, node int64) ([]Block, er
 func (b *Block) DeleteById(transaction *DbTransaction, id int64) error {
 	return GetDB(transaction).Where("id = ?", id).Delete(Block{}).Error
 }
+
+// DeleteBlocksDataTill removes the bodies of the blocks till blockID, the first block is always kept
+func DeleteBlocksDataTill(transaction *DbTransaction, blockID int64) (int64, error) {
+	query := GetDB(transaction).Exec("UPDATE block_chain SET data = '' WHERE id > 1 AND id <= ? AND data <> ''", blockID)
+	return query.RowsAffected, query.Error
+}
@@ -39,6 +39,19 @@ func (c *Confirmation) GetConfirmation(blockID int64) (bool, error) {
 	return isFound(DBConn.Where("block_id= ?", blockID).First(&c))
 }
 
+// GetBlockConfirmations returns the count of nodes which have confirmed the block.
+// The confirmation of the later block confirms the previous blocks too
+func GetBlockConfirmations(blockID int64) (int64, error) {
+	var count int64
+	err := DBConn.Raw(`SELECT coalesce(max(good), 0) FROM confirmations WHERE block_id >= ?`, blockID).Row().Scan(&count)
+	return count, err
+}
+
+// DeleteConfirmationsFrom deletes the confirmations of blockID and later blocks
+func DeleteConfirmationsFrom(transaction *DbTransaction, blockID int64) error {
+	return GetDB(transaction).Exec("DELETE FROM confirmations WHERE block_id >= ?", blockID).Error
+}
+
 // Save is saving model
 func (c *Confirmation) Save() error {
 	return DBConn.Save(c).Error
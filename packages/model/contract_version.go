@@ -0,0 +1,65 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package model
+
+// ContractVersion is the record about the version of the contract source
+type ContractVersion struct {
+	tableName  string
+	ID         int64  `gorm:"primary_key;not null"`
+	ContractID int64  `gorm:"not null"`
+	Name       string `gorm:"not null"`
+	Major      int64  `gorm:"not null"`
+	Minor      int64  `gorm:"not null"`
+	Value      string `gorm:"not null"`
+	Hash       string `gorm:"not null;size:64"`
+	KeyID      int64  `gorm:"not null"`
+	BlockID    int64  `gorm:"not null"`
+	Migrated   int64  `gorm:"not null"` // 1 if the migrate function has been called after the upgrade
+}
+
+// SetTablePrefix is setting table prefix
+func (cv *ContractVersion) SetTablePrefix(prefix string) {
+	cv.tableName = prefix + "_contract_versions"
+}
+
+// TableName returns name of table
+func (cv *ContractVersion) TableName() string {
+	return cv.tableName
+}
+
+// GetLast returns the last version of the contract
+func (cv *ContractVersion) GetLast(transaction *DbTransaction, contractID int64) (bool, error) {
+	return isFound(GetDB(transaction).Where("contract_id = ?", contractID).Order("id desc").First(cv))
+}
+
+// GetPrevious returns the version of the contract which precedes the specified version
+func (cv *ContractVersion) GetPrevious(transaction *DbTransaction, contractID, id int64) (bool, error) {
+	return isFound(GetDB(transaction).Where("contract_id = ? and id < ?", contractID, id).Order("id desc").First(cv))
+}
+
+// GetLastMajors returns the last versions of each major version of all contracts
+func (cv *ContractVersion) GetLastMajors(transaction *DbTransaction) ([]ContractVersion, error) {
+	var versions []ContractVersion
+	err := GetDB(transaction).Raw(`SELECT DISTINCT ON (contract_id, major) * FROM "` + cv.tableName +
+		`" ORDER BY contract_id, major, id DESC`).Scan(&versions).Error
+	return versions, err
+}
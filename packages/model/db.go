@@ -29,6 +29,7 @@ import (
 
 	"github.com/GenesisCommunity/go-genesis/packages/conf"
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
 	"github.com/GenesisCommunity/go-genesis/packages/crypto"
 	"github.com/GenesisCommunity/go-genesis/packages/migration"
 	"github.com/GenesisCommunity/go-genesis/packages/migration/vde"
@@ -71,6 +72,20 @@ func GormInit(host string, port int, user string, pass string, dbName string) er
 	return nil
 }
 
+// GormInitSchema is initializing Gorm connection where the specified schema is used for searching tables
+func GormInitSchema(host string, port int, user string, pass string, dbName string, schema string) error {
+	var err error
+	DBConn, err = gorm.Open("postgres",
+		fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=disable password=%s search_path=%s",
+			host, port, user, dbName, pass, schema))
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("cant open connection to DB")
+		DBConn = nil
+		return err
+	}
+	return nil
+}
+
 // GormClose is closing Gorm connection
 func GormClose() error {
 	if DBConn != nil {
@@ -241,8 +256,76 @@ func AlterTableAddColumn(transaction *DbTransaction, tableName, columnName, colu
 }
 
 // AlterTableDropColumn is dropping column from table
-func AlterTableDropColumn(tableName, columnName string) error {
-	return DBConn.Exec(`ALTER TABLE "` + tableName + `" DROP COLUMN "` + columnName + `"`).Error
+func AlterTableDropColumn(transaction *DbTransaction, tableName, columnName string) error {
+	return GetDB(transaction).Exec(`ALTER TABLE "` + tableName + `" DROP COLUMN "` + columnName + `"`).Error
+}
+
+// AlterTableRenameColumn is renaming column of table
+func AlterTableRenameColumn(transaction *DbTransaction, tableName, columnName, newName string) error {
+	return GetDB(transaction).Exec(`ALTER TABLE "` + tableName + `" RENAME COLUMN "` + columnName +
+		`" TO "` + newName + `"`).Error
+}
+
+// AlterTableColumnType is changing type of table column, columnType can contain NOT NULL and DEFAULT clauses
+func AlterTableColumnType(transaction *DbTransaction, tableName, columnName, columnType string) error {
+	baseType, notNull, defValue := SplitColumnType(columnType)
+	query := fmt.Sprintf(`ALTER TABLE "%[1]s" ALTER COLUMN "%[2]s" DROP DEFAULT, `+
+		`ALTER COLUMN "%[2]s" TYPE %[3]s USING "%[2]s"::%[3]s`, tableName, columnName, baseType)
+	if notNull {
+		query += fmt.Sprintf(`, ALTER COLUMN "%s" SET NOT NULL`, columnName)
+	} else {
+		query += fmt.Sprintf(`, ALTER COLUMN "%s" DROP NOT NULL`, columnName)
+	}
+	if len(defValue) > 0 {
+		query += fmt.Sprintf(`, ALTER COLUMN "%s" SET DEFAULT %s`, columnName, defValue)
+	}
+	return GetDB(transaction).Exec(query).Error
+}
+
+// SplitColumnType splits the column type definition into the type, NOT NULL flag and default value
+func SplitColumnType(columnType string) (baseType string, notNull bool, defValue string) {
+	baseType = columnType
+	if off := strings.Index(baseType, ` DEFAULT `); off >= 0 {
+		defValue = strings.TrimSpace(baseType[off+len(` DEFAULT `):])
+		baseType = baseType[:off]
+	}
+	if off := strings.Index(baseType, ` NOT NULL`); off >= 0 {
+		notNull = true
+		baseType = baseType[:off]
+	}
+	baseType = strings.TrimSpace(baseType)
+	return
+}
+
+// GetColumnValues returns not null values of table column as strings by id of rows
+func GetColumnValues(transaction *DbTransaction, tableName, columnName string) (map[string]string, error) {
+	rows, err := GetAllTx(transaction, fmt.Sprintf(`SELECT id, "%[2]s"::text as value FROM "%[1]s"
+		WHERE "%[2]s" IS NOT NULL`, tableName, columnName), -1)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(rows))
+	for _, row := range rows {
+		values[row["id"]] = row["value"]
+	}
+	return values, nil
+}
+
+// SetColumnValues writes the values of table column by id of rows
+func SetColumnValues(transaction *DbTransaction, tableName, columnName, columnType string,
+	values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	baseType, _, _ := SplitColumnType(columnType)
+	list := make([]string, 0, len(values))
+	for id, value := range values {
+		list = append(list, fmt.Sprintf(`(%d,'%s')`, converter.StrToInt64(id),
+			strings.Replace(value, `'`, `''`, -1)))
+	}
+	return GetDB(transaction).Exec(fmt.Sprintf(`UPDATE "%[1]s" SET "%[2]s" = v.value::%[3]s
+		FROM (VALUES %[4]s) AS v(id, value) WHERE "%[1]s".id = v.id`, tableName, columnName, baseType,
+		strings.Join(list, `,`))).Error
 }
 
 // CreateIndex is creating index on table column
@@ -250,9 +333,30 @@ func CreateIndex(transaction *DbTransaction, indexName, tableName, onColumn stri
 	return GetDB(transaction).Exec(`CREATE INDEX "` + indexName + `_index" ON "` + tableName + `" (` + onColumn + `)`).Error
 }
 
+// DropIndex is dropping index which has been created with CreateIndex
+func DropIndex(transaction *DbTransaction, indexName string) error {
+	return GetDB(transaction).Exec(`DROP INDEX "` + indexName + `_index"`).Error
+}
+
+// GetIndexColumns returns the columns of index which has been created with CreateIndex
+func GetIndexColumns(transaction *DbTransaction, indexName, tableName string) ([]string, error) {
+	rows, err := GetAllTx(transaction, `select a.attname from pg_class t, pg_class i, pg_index ix, pg_attribute a
+	 where t.oid = ix.indrelid and i.oid = ix.indexrelid and a.attrelid = t.oid and a.attnum = ANY(ix.indkey)
+	 and t.relname = ? and i.relname = ? order by array_position(ix.indkey::int2[], a.attnum)`, -1,
+		tableName, indexName+`_index`)
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, 0, len(rows))
+	for _, row := range rows {
+		columns = append(columns, row["attname"])
+	}
+	return columns, nil
+}
+
 // GetColumnDataTypeCharMaxLength is returns max length of table column
 func GetColumnDataTypeCharMaxLength(tableName, columnName string) (map[string]string, error) {
-	return GetOneRow(`select data_type,character_maximum_length from
+	return GetOneRow(`select data_type,character_maximum_length,udt_name,numeric_precision,numeric_scale from
 			 information_schema.columns where table_name = ? AND column_name = ?`,
 		tableName, columnName).String()
 }
@@ -282,9 +386,24 @@ func GetColumnType(tblname, column string) (itype string, err error) {
 		case strings.HasPrefix(dataType, `timestamp`):
 			itype = "datetime"
 		case strings.HasPrefix(dataType, `numeric`):
-			itype = "money"
+			if scale := converter.StrToInt64(coltype["numeric_scale"]); scale > 0 {
+				itype = fmt.Sprintf("decimal(%s,%d)", coltype["numeric_precision"], scale)
+			} else {
+				itype = "money"
+			}
 		case strings.HasPrefix(dataType, `double`):
 			itype = "double"
+		case dataType == `boolean`:
+			itype = "bool"
+		case dataType == `ARRAY`:
+			switch coltype["udt_name"] {
+			case `_int8`:
+				itype = "number[]"
+			case `_varchar`:
+				itype = "varchar[]"
+			default:
+				itype = coltype["udt_name"]
+			}
 		default:
 			itype = dataType
 		}
@@ -321,6 +440,21 @@ func IsIndex(tblname, column string) (bool, error) {
 	return len(row) > 0 && row[`column_name`] == column, err
 }
 
+// GetColumnIndexes returns the names of the indexes which contain the column of the table
+func GetColumnIndexes(transaction *DbTransaction, tblname, column string) ([]string, error) {
+	rows, err := GetAllTx(transaction, `select i.relname from pg_class t, pg_class i, pg_index ix, pg_attribute a
+	 where t.oid = ix.indrelid and i.oid = ix.indexrelid and a.attrelid = t.oid and a.attnum = ANY(ix.indkey)
+	 and t.relkind = 'r' and t.relname = ? and a.attname = ? order by i.relname`, -1, tblname, column)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]string, 0, len(rows))
+	for _, row := range rows {
+		indexes = append(indexes, row["relname"])
+	}
+	return indexes, nil
+}
+
 // ListResult is a structure for the list result
 type ListResult struct {
 	result []string
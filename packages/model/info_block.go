@@ -31,6 +31,8 @@ type InfoBlock struct {
 	Time           int64  `gorm:"not null"`
 	CurrentVersion string `gorm:"not null"`
 	Sent           int8   `gorm:"not null"`
+	PrunedBlockID  int64  `gorm:"not null"` // rollback data of blocks till this block have been removed
+	PrunedBodyID   int64  `gorm:"not null"` // bodies of blocks till this block have been removed
 }
 
 // TableName returns name of table
@@ -43,6 +45,11 @@ func (ib *InfoBlock) Get() (bool, error) {
 	return isFound(DBConn.Last(ib))
 }
 
+// GetTx is retrieving model from database using the transaction
+func (ib *InfoBlock) GetTx(transaction *DbTransaction) (bool, error) {
+	return isFound(GetDB(transaction).Last(ib))
+}
+
 // Update is update model
 func (ib *InfoBlock) Update(transaction *DbTransaction) error {
 	return GetDB(transaction).Model(&InfoBlock{}).Updates(ib).Error
@@ -72,3 +79,18 @@ func BlockGetUnsent() (*InfoBlock, error) {
 	}
 	return ib, err
 }
+
+// GetPrunedBlocks returns the ids of the last blocks whose rollback data and bodies have been removed
+func GetPrunedBlocks(transaction *DbTransaction) (int64, int64, error) {
+	ib := &InfoBlock{}
+	if _, err := isFound(GetDB(transaction).Last(ib)); err != nil {
+		return 0, 0, err
+	}
+	return ib.PrunedBlockID, ib.PrunedBodyID, nil
+}
+
+// SetPrunedBlocks updates the ids of the last blocks whose rollback data and bodies have been removed
+func SetPrunedBlocks(transaction *DbTransaction, blockID, bodyID int64) error {
+	return GetDB(transaction).Model(&InfoBlock{}).Updates(map[string]interface{}{
+		"pruned_block_id": blockID, "pruned_body_id": bodyID}).Error
+}
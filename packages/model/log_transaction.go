@@ -21,6 +21,8 @@
 // SOFTWARE.
 package model
 
+import "github.com/GenesisCommunity/go-genesis/packages/consts"
+
 // LogTransaction is model
 type LogTransaction struct {
 	Hash []byte `gorm:"primary_key;not null"`
@@ -51,3 +53,15 @@ func GetLogTransactionsCount(hash []byte) (int64, error) {
 	}
 	return rowsCount, nil
 }
+
+// LogTxExpiredTime returns the time before which transactions can't get into the blocks
+// starting from the block with the specified time, so their log isn't needed
+func LogTxExpiredTime(blockTime int64) int64 {
+	return blockTime - consts.MAX_TX_BACK
+}
+
+// DeleteLogTransactionsBefore deletes the log of transactions which are older than the time
+func DeleteLogTransactionsBefore(transaction *DbTransaction, time int64) (int64, error) {
+	query := GetDB(transaction).Exec("DELETE FROM log_transactions WHERE time < ?", time)
+	return query.RowsAffected, query.Error
+}
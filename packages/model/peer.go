@@ -0,0 +1,80 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package model
+
+// Peer is the record of the address book of the node
+type Peer struct {
+	Address   string `gorm:"primary_key;not null"`
+	PublicKey []byte `gorm:"not null"`
+	LastSeen  int64  `gorm:"not null"`
+	Signature []byte `gorm:"not null"` // the signature of the address and the last seen time made by the peer
+	Latency   int64  `gorm:"not null"` // the time of the last response in milliseconds
+	Failures  int64  `gorm:"not null"` // the count of failed requests in a row
+	Bans      int64  `gorm:"not null"`
+	BanTime   int64  `gorm:"not null"` // the time of the last ban
+	Source    string `gorm:"not null"` // the host which has sent the first record of the peer
+}
+
+// TableName returns name of table
+func (Peer) TableName() string {
+	return "peers"
+}
+
+// Get is retrieving model from database
+func (p *Peer) Get(address string) (bool, error) {
+	return isFound(DBConn.Where("address = ?", address).First(p))
+}
+
+// Save is creating or updating record of model
+func (p *Peer) Save() error {
+	return DBConn.Save(p).Error
+}
+
+// GetPeers returns all records of the address book
+func GetPeers() ([]Peer, error) {
+	var peers []Peer
+	err := DBConn.Find(&peers).Error
+	return peers, err
+}
+
+// UpdatePeerLatency writes the result of the request to the peer
+func UpdatePeerLatency(address string, latency int64, ok bool) error {
+	if ok {
+		return DBConn.Exec(`UPDATE "peers" SET latency = ?, failures = 0 WHERE address = ?`, latency, address).Error
+	}
+	return DBConn.Exec(`UPDATE "peers" SET failures = failures + 1 WHERE address = ?`, address).Error
+}
+
+// BanPeer increments the count of bans of the peer
+func BanPeer(address string, banTime int64) error {
+	return DBConn.Exec(`UPDATE "peers" SET bans = bans + 1, ban_time = ? WHERE address = ?`, banTime, address).Error
+}
+
+// DeletePeer removes the peer from the address book
+func DeletePeer(address string) error {
+	return DBConn.Exec(`DELETE FROM "peers" WHERE address = ?`, address).Error
+}
+
+// DeletePeersBefore removes the peers which haven't been seen since lastSeen
+func DeletePeersBefore(lastSeen int64) error {
+	return DBConn.Exec(`DELETE FROM "peers" WHERE last_seen < ?`, lastSeen).Error
+}
@@ -0,0 +1,51 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package model
+
+// Reorg is the record about the replacement of our last blocks with the blocks of the fork
+type Reorg struct {
+	ID         int64  `gorm:"primary_key;not null"`
+	Time       int64  `gorm:"not null"`
+	OldBlockID int64  `gorm:"not null"`
+	OldHash    []byte `gorm:"not null"`
+	NewBlockID int64  `gorm:"not null"`
+	NewHash    []byte `gorm:"not null"`
+	Depth      int64  `gorm:"not null"`
+	TxHashes   string `gorm:"not null"` // the hex hashes of rolled back transactions separated by comma
+}
+
+// TableName returns name of table
+func (Reorg) TableName() string {
+	return "reorgs"
+}
+
+// Create is creating record of model
+func (r *Reorg) Create(transaction *DbTransaction) error {
+	return GetDB(transaction).Create(r).Error
+}
+
+// GetReorgs returns the records of reorganizations, the last one is first
+func GetReorgs(offset, limit int64) ([]Reorg, error) {
+	var reorgs []Reorg
+	err := DBConn.Order("id desc").Offset(offset).Limit(limit).Find(&reorgs).Error
+	return reorgs, err
+}
@@ -215,7 +215,7 @@ func GetAll(query string, countRows int, args ...interface{}) ([]map[string]stri
 
 // GetAllTx returns all tx's
 func GetAllTx(transaction *DbTransaction, query string, countRows int, args ...interface{}) ([]map[string]string, error) {
-	return GetAllTransaction(transaction, query, countRows, args)
+	return GetAllTransaction(transaction, query, countRows, args...)
 }
 
 // GetOneRowTransaction returns one row from transactions
@@ -21,6 +21,62 @@
 // SOFTWARE.
 package model
 
+import (
+	"encoding/json"
+	"regexp"
+)
+
+// historyTablePattern matches the tables which history is available in contracts with Get*History functions.
+// It is used both in Go and in PostgreSQL queries
+const historyTablePattern = `^[0-9]+_(blocks|contracts|menu|pages)$`
+
+var historyTableRegexp = regexp.MustCompile(historyTablePattern)
+
+// RollbackSchemaID is table_id of rollback records which keep changes of the table structure
+const RollbackSchemaID = `schema`
+
+// The actions of changing the table structure
+const (
+	SchemaDropColumn   = `drop_column`
+	SchemaRenameColumn = `rename_column`
+	SchemaAlterColumn  = `alter_column`
+	SchemaCreateIndex  = `create_index`
+	SchemaDropIndex    = `drop_index`
+)
+
+// RollbackDelete is the operation of rollback records which keep all values of the deleted row
+const RollbackDelete = `delete`
+
+// DeletedRowRollback is the data of rollback record for the deleted row
+type DeletedRowRollback struct {
+	Op  string            `json:"op"`
+	Row map[string]string `json:"row"`
+}
+
+// ParseRollbackData returns the values of the rollback record of the row. If deleted is true
+// the values are the whole deleted row, otherwise they are the previous values of the updated columns
+func ParseRollbackData(data string) (values map[string]string, deleted bool, err error) {
+	// the values of updated columns are strings so they can't be parsed as the deleted row
+	var row DeletedRowRollback
+	if json.Unmarshal([]byte(data), &row) == nil && row.Op == RollbackDelete && row.Row != nil {
+		return row.Row, true, nil
+	}
+	if err = json.Unmarshal([]byte(data), &values); err != nil {
+		return nil, false, err
+	}
+	return values, false, nil
+}
+
+// SchemaRollback is the data of rollback record for changing the table structure
+type SchemaRollback struct {
+	Action  string            `json:"action"`
+	Column  string            `json:"column,omitempty"`
+	Name    string            `json:"name,omitempty"`
+	Type    string            `json:"type,omitempty"`
+	Columns []string          `json:"columns,omitempty"`
+	Values  map[string]string `json:"values,omitempty"`
+}
+
 // RollbackTx is model
 type RollbackTx struct {
 	ID        int64  `gorm:"primary_key;not null" json:"-"`
@@ -77,3 +133,44 @@ func (rt *RollbackTx) Create(transaction *DbTransaction) error {
 func (rt *RollbackTx) Get(dbTransaction *DbTransaction, transactionHash []byte, tableName string) (bool, error) {
 	return isFound(GetDB(dbTransaction).Where("tx_hash = ? AND table_name = ?", transactionHash, tableName).First(rt))
 }
+
+// IsHistoryTable returns true if the rollback records of the table are used as its history
+func IsHistoryTable(tableName string) bool {
+	return historyTableRegexp.MatchString(tableName)
+}
+
+// DeleteRollbackTxTill deletes rollback records of the blocks till blockID.
+// The records of history tables are kept so the history is the same on all nodes
+func DeleteRollbackTxTill(transaction *DbTransaction, blockID int64) (int64, error) {
+	query := GetDB(transaction).Exec("DELETE FROM rollback_tx WHERE block_id <= ? AND table_name !~ ?",
+		blockID, historyTablePattern)
+	return query.RowsAffected, query.Error
+}
+
+// GetRowRollbackTxs returns rollback records of the row which have been made in the blocks
+// after fromID till toID, the last record is first. If toID is 0 then all later blocks are included
+func GetRowRollbackTxs(transaction *DbTransaction, tableName, tableID string, fromID, toID int64) ([]RollbackTx, error) {
+	var rollbackTxs []RollbackTx
+	query := GetDB(transaction).Where("table_name = ? AND table_id = ? AND block_id > ?", tableName, tableID, fromID)
+	if toID > 0 {
+		query = query.Where("block_id <= ?", toID)
+	}
+	err := query.Order("id desc").Find(&rollbackTxs).Error
+	return rollbackTxs, err
+}
+
+// GetChangedRows returns the names of tables and ids of rows which have been changed in the blocks
+// after fromID till toID. If tableName isn't empty then only rows of this table are returned
+func GetChangedRows(transaction *DbTransaction, tableName string, fromID, toID int64) ([]RollbackTx, error) {
+	var rollbackTxs []RollbackTx
+	query := GetDB(transaction).Select("DISTINCT table_name, table_id").
+		Where("block_id > ? AND table_id <> ?", fromID, RollbackSchemaID)
+	if toID > 0 {
+		query = query.Where("block_id <= ?", toID)
+	}
+	if len(tableName) > 0 {
+		query = query.Where("table_name = ?", tableName)
+	}
+	err := query.Find(&rollbackTxs).Error
+	return rollbackTxs, err
+}
@@ -0,0 +1,137 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var regexpEcosystemTable = regexp.MustCompile(`^\d+_`)
+
+// systemStateTables are the tables besides ecosystem ones which keep the state of the blockchain
+var systemStateTables = map[string]bool{
+	"system_contracts": true,
+	"system_tables":    true,
+	"log_transactions": true,
+}
+
+// IsStateTable returns true if the table keeps the state which is the same on all nodes
+func IsStateTable(tableName string) bool {
+	if systemStateTables[tableName] {
+		return true
+	}
+	return regexpEcosystemTable.MatchString(tableName) && !strings.Contains(tableName, `_vde_`)
+}
+
+// StartSnapshotTransaction starts read only transaction which sees the database as it was at the start
+func StartSnapshotTransaction() (*DbTransaction, error) {
+	return startReadOnlyTransaction(`SET LOCAL TIME ZONE 'UTC'`)
+}
+
+// StartReadOnlyTransaction starts read only transaction which sees the same data in all queries
+func StartReadOnlyTransaction() (*DbTransaction, error) {
+	return startReadOnlyTransaction()
+}
+
+func startReadOnlyTransaction(settings ...string) (*DbTransaction, error) {
+	transaction, err := StartTransaction()
+	if err != nil {
+		return nil, err
+	}
+	queries := append([]string{`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY`}, settings...)
+	// the snapshot of the database is taken by the first query
+	for _, query := range append(queries, `SELECT 1`) {
+		if err = GetDB(transaction).Exec(query).Error; err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": query}).Error("starting read only transaction")
+			transaction.Rollback()
+			return nil, err
+		}
+	}
+	return transaction, nil
+}
+
+// GetStateTables returns the sorted names of the tables which keep the state
+func GetStateTables(transaction *DbTransaction) ([]string, error) {
+	rows, err := GetAllTx(transaction, `SELECT table_name FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_type = 'BASE TABLE' ORDER BY table_name COLLATE "C"`, -1)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if IsStateTable(row["table_name"]) {
+			tables = append(tables, row["table_name"])
+		}
+	}
+	return tables, nil
+}
+
+// GetTableSchema returns the statements which create the table with its constraints and indexes
+func GetTableSchema(transaction *DbTransaction, tableName string) ([]string, error) {
+	relation := `"` + tableName + `"`
+	columns, err := GetAllTx(transaction, `SELECT a.attname, format_type(a.atttypid, a.atttypmod) as type,
+		a.attnotnull::int as notnull, coalesce(pg_get_expr(d.adbin, d.adrelid), '') as def
+		FROM pg_attribute a LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = ?::regclass AND a.attnum > 0 AND NOT a.attisdropped ORDER BY a.attnum`, -1, relation)
+	if err != nil {
+		return nil, err
+	}
+	constraints, err := GetAllTx(transaction, `SELECT conname, pg_get_constraintdef(oid) as def
+		FROM pg_constraint WHERE conrelid = ?::regclass ORDER BY conname COLLATE "C"`, -1, relation)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := GetAllTx(transaction, `SELECT indexdef FROM pg_indexes
+		WHERE schemaname = 'public' AND tablename = ? AND indexname NOT IN
+		(SELECT conname FROM pg_constraint WHERE conrelid = ?::regclass) ORDER BY indexname COLLATE "C"`,
+		-1, tableName, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make([]string, 0, len(columns)+len(constraints))
+	for _, column := range columns {
+		def := fmt.Sprintf(`"%s" %s`, column["attname"], column["type"])
+		if column["notnull"] == `1` {
+			def += ` NOT NULL`
+		}
+		if len(column["def"]) > 0 {
+			def += ` DEFAULT ` + column["def"]
+		}
+		defs = append(defs, def)
+	}
+	for _, constraint := range constraints {
+		defs = append(defs, fmt.Sprintf(`CONSTRAINT "%s" %s`, constraint["conname"], constraint["def"]))
+	}
+
+	schema := []string{fmt.Sprintf(`CREATE TABLE %s (%s)`, relation, strings.Join(defs, `, `))}
+	for _, index := range indexes {
+		schema = append(schema, index["indexdef"])
+	}
+	return schema, nil
+}
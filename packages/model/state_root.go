@@ -0,0 +1,70 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package model
+
+import (
+	"fmt"
+)
+
+// GetStateRoot returns the state root which has been stored for the block
+func GetStateRoot(transaction *DbTransaction, blockID int64) ([]byte, error) {
+	b := &Block{}
+	found, err := isFound(GetDB(transaction).Select("state_root").Where("id = ?", blockID).First(b))
+	if err != nil || !found {
+		return nil, err
+	}
+	return b.StateRoot, nil
+}
+
+// GetRowsJSON returns the rows of the table in json format by their identifiers
+func GetRowsJSON(transaction *DbTransaction, tableName string, ids []int64) (map[int64]string, error) {
+	// the text of timestamps must not depend on the time zone of the node
+	if err := GetDB(transaction).Exec(`SET LOCAL TIME ZONE 'UTC'`).Error; err != nil {
+		return nil, err
+	}
+	result, err := getRowsJSON(transaction, tableName, ids)
+	if errZone := GetDB(transaction).Exec(`SET LOCAL TIME ZONE DEFAULT`).Error; err == nil {
+		err = errZone
+	}
+	return result, err
+}
+
+func getRowsJSON(transaction *DbTransaction, tableName string, ids []int64) (map[int64]string, error) {
+	rows, err := GetDB(transaction).Raw(fmt.Sprintf(`SELECT id, row_to_json(t)::text FROM "%s" t WHERE id IN (?)`,
+		tableName), ids).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := make(map[int64]string, len(ids))
+	for rows.Next() {
+		var (
+			id   int64
+			data string
+		)
+		if err = rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		result[id] = data
+	}
+	return result, rows.Err()
+}
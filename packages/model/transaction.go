@@ -71,6 +71,27 @@ func GetAllUnusedTransactions(limit int) ([]*Transaction, error) {
 	return transactions, nil
 }
 
+// GetUnusedTransactionHashes returns the hashes of all unused transactions
+func GetUnusedTransactionHashes() ([][]byte, error) {
+	var hashes [][]byte
+	if err := DBConn.Model(&Transaction{}).Where("used = ?", "0").Pluck("hash", &hashes).Error; err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// GetTransactionsByHashes returns the transactions with the specified hashes
+func GetTransactionsByHashes(hashes [][]byte) ([]*Transaction, error) {
+	var transactions []*Transaction
+	if len(hashes) == 0 {
+		return transactions, nil
+	}
+	if err := DBConn.Where("hash in (?)", hashes).Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
 // GetAllUnsentTransactions is retrieving all unset transactions
 func GetAllUnsentTransactions() (*[]Transaction, error) {
 	transactions := new([]Transaction)
@@ -28,7 +28,11 @@ type TransactionStatus struct {
 	Type     int64  `gorm:"not null"`
 	WalletID int64  `gorm:"not null"`
 	BlockID  int64  `gorm:"not null"`
-	Error    string `gorm:"not null;size 255"`
+	Error    string `gorm:"not null"`
+	// Fuel is the breakdown of the fuel spent by the transaction in JSON format
+	Fuel string `gorm:"not null"`
+	// RevertedBlockID is the id of the block which has contained the transaction and has been rolled back
+	RevertedBlockID int64 `gorm:"not null"`
 }
 
 // TableName returns name of table
@@ -51,13 +55,25 @@ func (ts *TransactionStatus) UpdateBlockID(transaction *DbTransaction, newBlockI
 	return GetDB(transaction).Model(&TransactionStatus{}).Where("hash = ?", transactionHash).Update("block_id", newBlockID).Error
 }
 
-// UpdateBlockMsg is updating block msg
-func (ts *TransactionStatus) UpdateBlockMsg(transaction *DbTransaction, newBlockID int64, msg string, transactionHash []byte) error {
+// SetReverted marks the transaction as reverted because the block has been rolled back
+func (ts *TransactionStatus) SetReverted(transaction *DbTransaction, blockID int64, transactionHash []byte) error {
 	return GetDB(transaction).Model(&TransactionStatus{}).Where("hash = ?", transactionHash).Updates(
-		map[string]interface{}{"block_id": newBlockID, "error": msg}).Error
+		map[string]interface{}{"block_id": 0, "reverted_block_id": blockID}).Error
+}
+
+// UpdateBlockMsg is updating block msg and the fuel of the transaction
+func (ts *TransactionStatus) UpdateBlockMsg(transaction *DbTransaction, newBlockID int64, msg, fuel string, transactionHash []byte) error {
+	return GetDB(transaction).Model(&TransactionStatus{}).Where("hash = ?", transactionHash).Updates(
+		map[string]interface{}{"block_id": newBlockID, "error": msg, "fuel": fuel}).Error
 }
 
 // SetError is updating transaction status error
 func (ts *TransactionStatus) SetError(transaction *DbTransaction, errorText string, transactionHash []byte) error {
 	return GetDB(transaction).Model(&TransactionStatus{}).Where("hash = ?", transactionHash).Update("error", errorText).Error
 }
+
+// DeleteTransactionsStatusTill deletes the statuses of transactions which are in the blocks till blockID
+func DeleteTransactionsStatusTill(transaction *DbTransaction, blockID int64) (int64, error) {
+	query := GetDB(transaction).Exec("DELETE FROM transactions_status WHERE block_id > 0 AND block_id <= ?", blockID)
+	return query.RowsAffected, query.Error
+}
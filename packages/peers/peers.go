@@ -0,0 +1,367 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package peers
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxRecordAge is the time in seconds after which the peer which hasn't been seen is removed
+	MaxRecordAge = 24 * 3600
+	// MaxClockDrift is the max difference in seconds between the time of the record and our time
+	MaxClockDrift = 300
+	// MaxRecords is the max count of records which are sent in the response
+	MaxRecords = 100
+	// MaxPeers is the max count of peers in the address book
+	MaxPeers = 1000
+	// MaxPeersBySource is the max count of peers in the address book which have been added by the same host
+	MaxPeersBySource = 20
+	// ExchangeCount is the count of peers which are asked for the records at a time
+	ExchangeCount = 8
+
+	unknownLatency = 1000  // the latency of the peer which hasn't been asked yet
+	failurePenalty = 1000  // the decrease of the score for each failed request in a row
+	banPenalty     = 10000 // the decrease of the score for each ban
+)
+
+var (
+	// ErrBadSignature is returned if the signature of the record is wrong
+	ErrBadSignature = errors.New("Bad signature of the peer record")
+	// ErrBadAddress is returned if the address of the record isn't host:port
+	ErrBadAddress = errors.New("Bad address of the peer record")
+	// ErrStaleRecord is returned if the time of the record is too old or too far in the future
+	ErrStaleRecord = errors.New("Stale peer record")
+)
+
+// Record is the address of the peer signed by its node key
+type Record struct {
+	Address   string `json:"address"`
+	PublicKey []byte `json:"public_key"`
+	LastSeen  int64  `json:"last_seen"`
+	Signature []byte `json:"signature"`
+}
+
+func (r *Record) forSign() string {
+	return fmt.Sprintf("%s,%d", r.Address, r.LastSeen)
+}
+
+// Sign signs the record with the private key
+func (r *Record) Sign(privateKey string) (err error) {
+	r.Signature, err = crypto.Sign(privateKey, r.forSign())
+	return
+}
+
+// Verify checks the address, the time and the signature of the record
+func (r *Record) Verify(now int64) error {
+	if len(r.Address) == 0 || len(r.Address) > 255 {
+		return ErrBadAddress
+	}
+	if _, _, err := net.SplitHostPort(r.Address); err != nil {
+		return ErrBadAddress
+	}
+	if r.LastSeen > now+MaxClockDrift || now-r.LastSeen > MaxRecordAge {
+		return ErrStaleRecord
+	}
+	ok, err := crypto.CheckSign(r.PublicKey, r.forSign(), r.Signature)
+	if err != nil || !ok {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Self returns the signed record of our node, it returns nil if the public address isn't specified
+func Self() (*Record, error) {
+	if len(conf.Config.Peers.PublicAddress) == 0 {
+		return nil, nil
+	}
+	privateKey, publicKey, err := utils.GetNodeKeys()
+	if err != nil {
+		return nil, err
+	}
+	r := &Record{
+		Address:  utils.GetHostPort(conf.Config.Peers.PublicAddress),
+		LastSeen: time.Now().Unix(),
+	}
+	if r.PublicKey, err = hex.DecodeString(publicKey); err != nil {
+		log.WithFields(log.Fields{"type": consts.ConversionError, "error": err}).Error("decoding node public key from hex")
+		return nil, err
+	}
+	if err = r.Sign(privateKey); err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("signing peer record")
+		return nil, err
+	}
+	return r, nil
+}
+
+func isSelf(address string) bool {
+	return len(conf.Config.Peers.PublicAddress) > 0 && address == utils.GetHostPort(conf.Config.Peers.PublicAddress)
+}
+
+// mutex doesn't allow concurrent responses to exceed the limits of the address book
+var mutex sync.Mutex
+
+// sourceHost returns the host of the address without the port which is different for each connection
+func sourceHost(address string) string {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// addressBook is the list of known peers which limits the count of peers added by each source
+type addressBook struct {
+	peers    map[string]*model.Peer
+	bySource map[string]int
+}
+
+func newAddressBook(list []model.Peer) *addressBook {
+	book := &addressBook{
+		peers:    make(map[string]*model.Peer, len(list)),
+		bySource: make(map[string]int),
+	}
+	for i := range list {
+		book.add(&list[i])
+	}
+	return book
+}
+
+func (b *addressBook) add(peer *model.Peer) {
+	if _, ok := b.peers[peer.Address]; !ok {
+		b.bySource[peer.Source]++
+	}
+	b.peers[peer.Address] = peer
+}
+
+func (b *addressBook) remove(peer *model.Peer) {
+	if _, ok := b.peers[peer.Address]; ok {
+		b.bySource[peer.Source]--
+		delete(b.peers, peer.Address)
+	}
+}
+
+// worst returns the peer which is removed to make room for the new one. The stale peers go first
+// and then the peers with the lowest score. It returns nil if all peers are better than the unknown one
+func (b *addressBook) worst(now int64) *model.Peer {
+	var result *model.Peer
+	worse := func(peer *model.Peer) bool {
+		staleA, staleB := now-peer.LastSeen > MaxRecordAge, now-result.LastSeen > MaxRecordAge
+		if staleA != staleB {
+			return staleA
+		}
+		if score(peer) != score(result) {
+			return score(peer) < score(result)
+		}
+		return peer.LastSeen < result.LastSeen
+	}
+	for _, peer := range b.peers {
+		if result == nil || worse(peer) {
+			result = peer
+		}
+	}
+	if result == nil || (now-result.LastSeen <= MaxRecordAge && score(result) > -unknownLatency) {
+		return nil
+	}
+	return result
+}
+
+// Add writes the valid records which are received from the source into the address book if they are newer
+// than the known ones. Only MaxRecords records are taken from the source and it can't add more than
+// MaxPeersBySource new peers. The worst peer is removed when the address book is full
+func Add(source string, records []Record) {
+	if len(records) > MaxRecords {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "count": len(records), "source": source}).Warn("too many peer records")
+		records = records[:MaxRecords]
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	list, err := model.GetPeers()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting peers")
+		return
+	}
+	book := newAddressBook(list)
+	source = sourceHost(source)
+	now := time.Now().Unix()
+	for _, r := range records {
+		if err := r.Verify(now); err != nil {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "error": err, "address": r.Address}).Debug("verifying peer record")
+			continue
+		}
+		if isSelf(r.Address) {
+			continue
+		}
+		peer, found := book.peers[r.Address]
+		if found && peer.LastSeen >= r.LastSeen {
+			continue
+		}
+		if !found {
+			if book.bySource[source] >= MaxPeersBySource {
+				log.WithFields(log.Fields{"type": consts.ParameterExceeded, "source": source, "address": r.Address}).Debug("too many peers from source")
+				continue
+			}
+			if len(book.peers) >= MaxPeers {
+				worst := book.worst(now)
+				if worst == nil {
+					continue
+				}
+				if err = model.DeletePeer(worst.Address); err != nil {
+					log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting peer")
+					return
+				}
+				book.remove(worst)
+			}
+			peer = &model.Peer{Address: r.Address, Latency: unknownLatency, Source: source}
+		}
+		peer.PublicKey, peer.LastSeen, peer.Signature = r.PublicKey, r.LastSeen, r.Signature
+		if err = peer.Save(); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("saving peer")
+			return
+		}
+		book.add(peer)
+	}
+}
+
+func score(peer *model.Peer) int64 {
+	return -peer.Latency - peer.Failures*failurePenalty - peer.Bans*banPenalty
+}
+
+// sortPeers returns the available peers from the best to the worst
+func sortPeers(list []model.Peer, now int64, banTime int64) []model.Peer {
+	result := make([]model.Peer, 0, len(list))
+	for _, peer := range list {
+		if now-peer.LastSeen > MaxRecordAge || (peer.BanTime > 0 && now-peer.BanTime < banTime) {
+			continue
+		}
+		result = append(result, peer)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return score(&result[i]) > score(&result[j])
+	})
+	return result
+}
+
+func bestPeers(limit int) ([]model.Peer, error) {
+	list, err := model.GetPeers()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting peers")
+		return nil, err
+	}
+	list = sortPeers(list, time.Now().Unix(), int64(syspar.GetLocalNodeBanTime()/time.Second))
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// Records returns our record and the records of the best peers which are sent to other nodes,
+// there are no more than MaxRecords records
+func Records() ([]Record, error) {
+	list, err := bestPeers(MaxRecords - 1)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]Record, 0, len(list)+1)
+	self, err := Self()
+	if err != nil {
+		return nil, err
+	}
+	if self != nil {
+		records = append(records, *self)
+	}
+	for _, peer := range list {
+		records = append(records, Record{
+			Address:   peer.Address,
+			PublicKey: peer.PublicKey,
+			LastSeen:  peer.LastSeen,
+			Signature: peer.Signature,
+		})
+	}
+	return records, nil
+}
+
+// Hosts returns the addresses of the best peers from the address book
+func Hosts(limit int) []string {
+	list, err := bestPeers(limit)
+	if err != nil {
+		return nil
+	}
+	hosts := make([]string, len(list))
+	for i, peer := range list {
+		hosts[i] = peer.Address
+	}
+	return hosts
+}
+
+// ExchangeHosts returns the hosts which are asked for the peers. The seeds and the known nodes
+// are used when the address book doesn't have enough peers
+func ExchangeHosts() []string {
+	hosts := Hosts(ExchangeCount)
+	if len(hosts) < ExchangeCount {
+		hosts = append(hosts, conf.Config.Peers.Seeds...)
+		hosts = append(hosts, conf.GetNodesAddr()...)
+		hosts = append(hosts, syspar.GetRemoteHosts()...)
+	}
+	result := make([]string, 0, len(hosts))
+	exists := make(map[string]bool)
+	for _, host := range hosts {
+		host = utils.GetHostPort(host)
+		if !exists[host] && !isSelf(host) {
+			exists[host] = true
+			result = append(result, host)
+		}
+	}
+	utils.ShuffleSlice(result)
+	return result
+}
+
+// Ban lowers the score of the peer which has sent the bad block
+func Ban(host string) {
+	if err := model.BanPeer(utils.GetHostPort(host), time.Now().Unix()); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("banning peer")
+	}
+}
+
+// Prune removes the peers which haven't been seen for a long time
+func Prune() error {
+	if err := model.DeletePeersBefore(time.Now().Unix() - MaxRecordAge); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting old peers")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package peers
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+)
+
+func signRecord(t *testing.T, r *Record) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := crypto.Hash([]byte(r.forSign()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sr, ss, err := ecdsa.Sign(rand.Reader, private, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.PublicKey = append(converter.FillLeft(private.X.Bytes()), converter.FillLeft(private.Y.Bytes())...)
+	r.Signature = append(converter.FillLeft(sr.Bytes()), converter.FillLeft(ss.Bytes())...)
+}
+
+func TestRecord(t *testing.T) {
+	r := &Record{Address: "127.0.0.1:7078", LastSeen: 1000}
+	signRecord(t, r)
+	var err error
+	if err = r.Verify(1000); err != nil {
+		t.Errorf("valid record: %s", err)
+	}
+	if err = r.Verify(1000 + MaxRecordAge + 1); err != ErrStaleRecord {
+		t.Errorf("stale record: %v", err)
+	}
+	if err = r.Verify(1000 - MaxClockDrift - 1); err != ErrStaleRecord {
+		t.Errorf("future record: %v", err)
+	}
+
+	r.LastSeen++
+	if err = r.Verify(1000); err != ErrBadSignature {
+		t.Errorf("changed record: %v", err)
+	}
+	r.Address = "127.0.0.1"
+	if err = r.Verify(1000); err != ErrBadAddress {
+		t.Errorf("address without port: %v", err)
+	}
+}
+
+func TestSortPeers(t *testing.T) {
+	list := []model.Peer{
+		{Address: "a", LastSeen: 1000, Latency: 100},
+		{Address: "b", LastSeen: 1000, Latency: 10, Failures: 1},
+		{Address: "c", LastSeen: 1000, Latency: 50},
+		{Address: "d", LastSeen: 1000, Latency: 1, Bans: 1, BanTime: 900},
+		{Address: "e", LastSeen: 1000 - MaxRecordAge - 1, Latency: 1},
+		{Address: "f", LastSeen: 1000, Latency: 1, Bans: 1, BanTime: 990},
+	}
+	result := sortPeers(list, 1000, 60)
+	expected := []string{"c", "a", "b", "d"}
+	if len(result) != len(expected) {
+		t.Fatalf("wrong count of peers %d", len(result))
+	}
+	for i, address := range expected {
+		if result[i].Address != address {
+			t.Errorf("wrong peer at %d: %s != %s", i, result[i].Address, address)
+		}
+	}
+}
+
+func TestAddressBook(t *testing.T) {
+	book := newAddressBook([]model.Peer{
+		{Address: "a:1", Source: "1.1.1.1", LastSeen: 1000, Latency: 10},
+		{Address: "b:1", Source: "1.1.1.1", LastSeen: 1000, Latency: 20},
+		{Address: "c:1", Source: "2.2.2.2", LastSeen: 1000, Latency: 30},
+	})
+	if book.bySource["1.1.1.1"] != 2 || book.bySource["2.2.2.2"] != 1 {
+		t.Errorf("wrong count of peers by source %v", book.bySource)
+	}
+	if worst := book.worst(1000); worst != nil {
+		t.Errorf("peers which are better than unknown one must be kept, got %s", worst.Address)
+	}
+
+	book.add(&model.Peer{Address: "d:1", Source: "2.2.2.2", LastSeen: 900, Latency: unknownLatency})
+	book.add(&model.Peer{Address: "e:1", Source: "2.2.2.2", LastSeen: 950, Latency: unknownLatency})
+	if worst := book.worst(1000); worst == nil || worst.Address != "d:1" {
+		t.Errorf("the oldest unknown peer must be removed, got %v", worst)
+	}
+	book.add(&model.Peer{Address: "a:1", Source: "1.1.1.1", LastSeen: 1000, Latency: 10, Failures: 2})
+	if book.bySource["1.1.1.1"] != 2 {
+		t.Errorf("updated peer must not be counted twice %v", book.bySource)
+	}
+	if worst := book.worst(1000); worst == nil || worst.Address != "a:1" {
+		t.Errorf("the peer with the lowest score must be removed, got %v", worst)
+	}
+	book.add(&model.Peer{Address: "f:1", Source: "3.3.3.3", LastSeen: 1000 - MaxRecordAge - 1, Latency: 1})
+	if worst := book.worst(1000); worst == nil || worst.Address != "f:1" {
+		t.Errorf("stale peer must be removed first, got %v", worst)
+	}
+	book.remove(book.peers["f:1"])
+	if len(book.peers) != 5 || book.bySource["3.3.3.3"] != 0 {
+		t.Errorf("wrong address book after removing %d %v", len(book.peers), book.bySource)
+	}
+
+	if sourceHost("1.2.3.4:5678") != "1.2.3.4" || sourceHost("1.2.3.4") != "1.2.3.4" {
+		t.Error("wrong host of source")
+	}
+}
@@ -0,0 +1,176 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// BlockData is a structure of the block's header
+type BlockData struct {
+	BlockID      int64
+	Time         int64
+	EcosystemID  int64
+	KeyID        int64
+	NodePosition int64
+	Sign         []byte
+	Hash         []byte
+	StateRoot    []byte // the state root after the previous block, see StateRootBlockID
+	Version      int
+}
+
+// StateRootBlockID returns the id of the block which the state root in the header has been calculated after.
+// The header is signed before the transactions of the block are played, so the state after block N
+// is committed by the header of block N+1
+func (b BlockData) StateRootBlockID() int64 {
+	return b.BlockID - 1
+}
+
+func (b BlockData) String() string {
+	return fmt.Sprintf("BlockID:%d, Time:%d, NodePosition %d", b.BlockID, b.Time, b.NodePosition)
+}
+
+// ForSign returns the data of the block which is signed by the node
+func (b BlockData) ForSign(prevHash, mrklRoot []byte) string {
+	forSign := fmt.Sprintf("0,%d,%x,%d,%d,%d,%d,%s",
+		b.BlockID, prevHash, b.Time, b.EcosystemID, b.KeyID, b.NodePosition, mrklRoot)
+	if b.Version >= consts.BlockVersionStateRoot {
+		forSign += fmt.Sprintf(",%x", b.StateRoot)
+	}
+	return forSign
+}
+
+// ForSha returns the data of the block which the hash of the block is calculated from
+func (b BlockData) ForSha(prevHash, mrklRoot []byte) string {
+	forSha := fmt.Sprintf("%d,%x,%s,%d,%d,%d,%d",
+		b.BlockID, prevHash, mrklRoot, b.Time, b.EcosystemID, b.KeyID, b.NodePosition)
+	if b.Version >= consts.BlockVersionStateRoot {
+		forSha += fmt.Sprintf(",%x", b.StateRoot)
+	}
+	return forSha
+}
+
+// ParseBlockHeader is parses block header, the size of the block isn't checked if maxSize is 0
+func ParseBlockHeader(binaryBlock *bytes.Buffer, maxSize int64) (BlockData, error) {
+	var block BlockData
+	var err error
+
+	if binaryBlock.Len() < 9 {
+		log.WithFields(log.Fields{"size": binaryBlock.Len(), "type": consts.SizeDoesNotMatch}).Error("binary block size is too small")
+		return BlockData{}, fmt.Errorf("bad binary block length")
+	}
+
+	blockVersion := int(converter.BinToDec(binaryBlock.Next(2)))
+
+	if maxSize > 0 && int64(binaryBlock.Len()) > maxSize {
+		log.WithFields(log.Fields{"size": binaryBlock.Len(), "max_size": maxSize, "type": consts.ParameterExceeded}).Error("binary block size exceeds max block size")
+		err = fmt.Errorf(`len(binaryBlock) > variables.Int64["max_block_size"]  %v > %v`,
+			binaryBlock.Len(), maxSize)
+
+		return BlockData{}, err
+	}
+
+	block.BlockID = converter.BinToDec(binaryBlock.Next(4))
+	block.Time = converter.BinToDec(binaryBlock.Next(4))
+	block.Version = blockVersion
+	block.EcosystemID = converter.BinToDec(binaryBlock.Next(4))
+	block.KeyID, err = converter.DecodeLenInt64Buf(binaryBlock)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": block.BlockID, "block_time": block.Time, "block_version": block.Version, "error": err}).Error("decoding binary block walletID")
+		return BlockData{}, err
+	}
+	block.NodePosition = converter.BinToDec(binaryBlock.Next(1))
+
+	if block.Version >= consts.BlockVersionStateRoot {
+		rootSize, err := converter.DecodeLengthBuf(binaryBlock)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": block.BlockID, "time": block.Time, "version": block.Version, "error": err}).Error("decoding binary state root size")
+			return BlockData{}, err
+		}
+		if binaryBlock.Len() < rootSize {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": block.BlockID, "time": block.Time, "version": block.Version}).Error("decoding binary state root")
+			return BlockData{}, fmt.Errorf("bad block format (no state root)")
+		}
+		block.StateRoot = binaryBlock.Next(rootSize)
+	}
+
+	if block.BlockID > 1 {
+		signSize, err := converter.DecodeLengthBuf(binaryBlock)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": block.BlockID, "time": block.Time, "version": block.Version, "error": err}).Error("decoding binary sign size")
+			return BlockData{}, err
+		}
+		if binaryBlock.Len() < signSize {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": block.BlockID, "time": block.Time, "version": block.Version, "error": err}).Error("decoding binary sign")
+			return BlockData{}, fmt.Errorf("bad block format (no sign)")
+		}
+		block.Sign = binaryBlock.Next(int(signSize))
+	} else {
+		binaryBlock.Next(1)
+	}
+
+	return block, nil
+}
+
+// SplitBlockHeader returns the binary header of the block and the merkle root of its transactions
+func SplitBlockHeader(data []byte) ([]byte, []byte, error) {
+	binHeader, txs, err := SplitBlockData(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	mrklSlice, err := MerkleTreeItems(txs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return binHeader, MerkleTreeRoot(mrklSlice), nil
+}
+
+// SplitBlockData returns the binary header of the block and the list of its transactions
+func SplitBlockData(data []byte) ([]byte, [][]byte, error) {
+	buf := bytes.NewBuffer(data)
+	header, err := ParseBlockHeader(buf, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	binHeader := data[:len(data)-buf.Len()]
+
+	var txs [][]byte
+	for buf.Len() > 0 {
+		size, err := converter.DecodeLengthBuf(buf)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.UnmarshallingError, "block_id": header.BlockID, "error": err}).Error("decoding transaction size")
+			return nil, nil, err
+		}
+		if size == 0 || buf.Len() < size {
+			log.WithFields(log.Fields{"type": consts.SizeDoesNotMatch, "block_id": header.BlockID, "size": size}).Error("transaction size does not matches block data")
+			return nil, nil, fmt.Errorf("bad block format (transaction len %d)", size)
+		}
+		txs = append(txs, buf.Next(size))
+	}
+	return binHeader, txs, nil
+}
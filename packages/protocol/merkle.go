@@ -0,0 +1,170 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package protocol
+
+import (
+	"fmt"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MerkleTreeRoot rertun Merkle value
+func MerkleTreeRoot(dataArray [][]byte) []byte {
+	log.Debug("dataArray: %s", dataArray)
+	result := make(map[int32][][]byte)
+	for _, v := range dataArray {
+		hash, err := crypto.DoubleHash(v)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "type": consts.CryptoError}).Fatal("double hasing value, while calculating merkle tree root")
+		}
+		hash = converter.BinToHex(hash)
+		result[0] = append(result[0], hash)
+	}
+	var j int32
+	for len(result[j]) > 1 {
+		for i := 0; i < len(result[j]); i = i + 2 {
+			if len(result[j]) <= (i + 1) {
+				if _, ok := result[j+1]; !ok {
+					result[j+1] = [][]byte{result[j][i]}
+				} else {
+					result[j+1] = append(result[j+1], result[j][i])
+				}
+			} else {
+				if _, ok := result[j+1]; !ok {
+					hash, err := crypto.DoubleHash(append(result[j][i], result[j][i+1]...))
+					if err != nil {
+						log.WithFields(log.Fields{"error": err, "type": consts.CryptoError}).Fatal("double hasing value, while calculating merkle tree root")
+					}
+					hash = converter.BinToHex(hash)
+					result[j+1] = [][]byte{hash}
+				} else {
+					hash, err := crypto.DoubleHash([]byte(append(result[j][i], result[j][i+1]...)))
+					if err != nil {
+						log.WithFields(log.Fields{"error": err, "type": consts.CryptoError}).Fatal("double hasing value, while calculating merkle tree root")
+					}
+					hash = converter.BinToHex(hash)
+					result[j+1] = append(result[j+1], hash)
+				}
+			}
+		}
+		j++
+	}
+
+	ret := result[int32(len(result)-1)]
+	return []byte(ret[0])
+}
+
+// MerkleProofItem is the hash of the sibling node on the path from the item to the merkle root
+type MerkleProofItem struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"` // the sibling is on the left side
+}
+
+// MerkleTreeProof returns the path of the item with the index in the merkle tree
+// which is built like MerkleTreeRoot does
+func MerkleTreeProof(dataArray [][]byte, index int) ([]MerkleProofItem, error) {
+	if index < 0 || index >= len(dataArray) {
+		return nil, fmt.Errorf("wrong index %d of merkle tree item", index)
+	}
+	level := make([][]byte, 0, len(dataArray))
+	for _, v := range dataArray {
+		hash, err := crypto.DoubleHash(v)
+		if err != nil {
+			log.WithFields(log.Fields{"error": err, "type": consts.CryptoError}).Error("double hasing value, while calculating merkle tree proof")
+			return nil, err
+		}
+		level = append(level, converter.BinToHex(hash))
+	}
+	proof := make([]MerkleProofItem, 0)
+	for len(level) > 1 {
+		// the last odd node doesn't have the sibling and goes to the next level as is
+		if sibling := index ^ 1; sibling < len(level) {
+			proof = append(proof, MerkleProofItem{Hash: string(level[sibling]), Left: sibling < index})
+		}
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			hash, err := merkleNode(level[i], level[i+1])
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, hash)
+		}
+		level = next
+		index /= 2
+	}
+	return proof, nil
+}
+
+// MerkleProofRoot returns the merkle root which is calculated from the item and its path
+func MerkleProofRoot(data []byte, proof []MerkleProofItem) ([]byte, error) {
+	hash, err := crypto.DoubleHash(data)
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "type": consts.CryptoError}).Error("double hasing value, while calculating merkle proof root")
+		return nil, err
+	}
+	hash = converter.BinToHex(hash)
+	for _, item := range proof {
+		if item.Left {
+			hash, err = merkleNode([]byte(item.Hash), hash)
+		} else {
+			hash, err = merkleNode(hash, []byte(item.Hash))
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hash, nil
+}
+
+func merkleNode(left, right []byte) ([]byte, error) {
+	hash, err := crypto.DoubleHash(append(append([]byte{}, left...), right...))
+	if err != nil {
+		log.WithFields(log.Fields{"error": err, "type": consts.CryptoError}).Error("double hasing value, while calculating merkle tree node")
+		return nil, err
+	}
+	return converter.BinToHex(hash), nil
+}
+
+// MerkleTreeItems returns the items of the merkle tree of the block transactions
+func MerkleTreeItems(txs [][]byte) ([][]byte, error) {
+	mrklSlice := make([][]byte, 0, len(txs))
+	for _, tx := range txs {
+		hash, err := crypto.DoubleHash(tx)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("double hashing transaction")
+			return nil, err
+		}
+		mrklSlice = append(mrklSlice, converter.BinToHex(hash))
+	}
+	if len(mrklSlice) == 0 {
+		mrklSlice = append(mrklSlice, []byte("0"))
+	}
+	return mrklSlice, nil
+}
@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+)
+
+func TestSplitBlockHeader(t *testing.T) {
+	var header bytes.Buffer
+	header.Write(converter.DecToBin(1, 2))
+	header.Write(converter.DecToBin(10, 4))
+	header.Write(converter.DecToBin(1500000000, 4))
+	header.Write(converter.DecToBin(1, 4))
+	header.Write(converter.EncodeLenInt64InPlace(-12345))
+	header.Write(converter.DecToBin(0, 1))
+	header.Write(converter.EncodeLengthPlusData([]byte("signature")))
+
+	txs := [][]byte{[]byte("first transaction"), []byte("second transaction")}
+	var mrklSlice [][]byte
+	data := append([]byte{}, header.Bytes()...)
+	for _, tx := range txs {
+		data = append(data, converter.EncodeLengthPlusData(tx)...)
+		hash, err := crypto.DoubleHash(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mrklSlice = append(mrklSlice, converter.BinToHex(hash))
+	}
+
+	binHeader, mrklRoot, err := SplitBlockHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(binHeader, header.Bytes()) {
+		t.Errorf("wrong header %x", binHeader)
+	}
+	if !bytes.Equal(mrklRoot, MerkleTreeRoot(mrklSlice)) {
+		t.Errorf("wrong merkle root %s", mrklRoot)
+	}
+
+	if _, _, err = SplitBlockHeader(data[:len(data)-1]); err == nil {
+		t.Error("truncated block must be rejected")
+	}
+}
+
+func TestParseBlockHeaderStateRoot(t *testing.T) {
+	stateRoot := []byte("state root of the previous block")
+	var data bytes.Buffer
+	data.Write(converter.DecToBin(2, 2))
+	data.Write(converter.DecToBin(10, 4))
+	data.Write(converter.DecToBin(1500000000, 4))
+	data.Write(converter.DecToBin(1, 4))
+	data.Write(converter.EncodeLenInt64InPlace(-12345))
+	data.Write(converter.DecToBin(3, 1))
+	data.Write(converter.EncodeLengthPlusData(stateRoot))
+	data.Write(converter.EncodeLengthPlusData([]byte("signature")))
+
+	header, err := ParseBlockHeader(bytes.NewBuffer(data.Bytes()), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(header.StateRoot, stateRoot) || string(header.Sign) != "signature" || header.NodePosition != 3 {
+		t.Errorf("wrong header %+v", header)
+	}
+	if header.StateRootBlockID() != 9 {
+		t.Errorf("state root of block 10 must be calculated after block 9, got %d", header.StateRootBlockID())
+	}
+
+	prevHash, mrklRoot := []byte{1, 2}, []byte("root")
+	if header.ForSign(prevHash, mrklRoot) != "0,10,0102,1500000000,1,-12345,3,root,"+fmt.Sprintf("%x", stateRoot) {
+		t.Errorf("wrong data for sign %s", header.ForSign(prevHash, mrklRoot))
+	}
+	header.Version = 1
+	if header.ForSha(prevHash, mrklRoot) != "10,0102,root,1500000000,1,-12345,3" {
+		t.Errorf("wrong data for hash %s", header.ForSha(prevHash, mrklRoot))
+	}
+}
+
+func TestMerkleTreeProof(t *testing.T) {
+	for count := 1; count <= 9; count++ {
+		var items [][]byte
+		for i := 0; i < count; i++ {
+			items = append(items, []byte(fmt.Sprintf("transaction %d", i)))
+		}
+		root := MerkleTreeRoot(items)
+		for i := range items {
+			proof, err := MerkleTreeProof(items, i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proofRoot, err := MerkleProofRoot(items[i], proof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(proofRoot, root) {
+				t.Errorf("wrong root of item %d from %d items", i, count)
+			}
+			if proofRoot, _ = MerkleProofRoot([]byte("other"), proof); bytes.Equal(proofRoot, root) {
+				t.Errorf("root of other item must not match")
+			}
+		}
+	}
+	if _, err := MerkleTreeProof([][]byte{[]byte("0")}, 1); err == nil {
+		t.Error("wrong index must be rejected")
+	}
+}
@@ -0,0 +1,143 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package pruning
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Interval is the interval of blocks between removing old data
+const Interval = 100
+
+var pruning int32
+
+// IsTime returns true if old data must be removed after the block. The archive mode keeps all data
+func IsTime(blockID int64) bool {
+	return conf.Config.Pruning.Blocks > 0 && conf.Config.Archive.Interval == 0 && blockID%Interval == 0
+}
+
+// KeepBlocks returns the count of the last blocks which keep rollback data.
+// It can't be less than the depth of rollback in the case of fork
+func KeepBlocks() int64 {
+	keep := conf.Config.Pruning.Blocks
+	if rb := syspar.GetRbBlocks1(); keep < rb {
+		keep = rb
+	}
+	return keep
+}
+
+// CheckRollback returns the error if the blocks after blockID can't be rolled back because their data have been removed
+func CheckRollback(transaction *model.DbTransaction, blockID int64) error {
+	prunedID, _, err := model.GetPrunedBlocks(transaction)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting pruned blocks")
+		return err
+	}
+	if blockID < prunedID {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "block_id": blockID, "pruned_block_id": prunedID}).Error("rollback beyond pruning horizon")
+		return fmt.Errorf("can't rollback to block %d, rollback data of blocks till %d have been pruned", blockID, prunedID)
+	}
+	return nil
+}
+
+// Start removes the data of blocks which are older than the pruning horizon in the background.
+// It must be called when the block has been committed
+func Start(blockID int64) {
+	if !atomic.CompareAndSwapInt32(&pruning, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&pruning, 0)
+		if err := Prune(blockID - KeepBlocks()); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": blockID}).Error("pruning old blocks")
+		}
+	}()
+}
+
+// Prune removes rollback data, statuses and log of transactions and optionally bodies of blocks till the horizon
+func Prune(horizon int64) error {
+	prunedID, bodyID, err := model.GetPrunedBlocks(nil)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting pruned blocks")
+		return err
+	}
+	if horizon <= 1 || horizon <= prunedID {
+		return nil
+	}
+	block := &model.Block{}
+	found, err := block.Get(horizon)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": horizon}).Error("getting block")
+		return err
+	}
+	if !found {
+		return nil
+	}
+
+	transaction, err := model.StartTransaction()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("starting transaction")
+		return err
+	}
+	if _, err = model.DeleteRollbackTxTill(transaction, horizon); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("pruning rollback data")
+		transaction.Rollback()
+		return err
+	}
+	if _, err = model.DeleteTransactionsStatusTill(transaction, horizon); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("pruning transactions status")
+		transaction.Rollback()
+		return err
+	}
+	if _, err = model.DeleteLogTransactionsBefore(transaction, model.LogTxExpiredTime(block.Time)); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("pruning log transactions")
+		transaction.Rollback()
+		return err
+	}
+	// the body of the horizon block is kept because its header is needed after rollback
+	if conf.Config.Pruning.Bodies && bodyID < horizon-1 {
+		bodyID = horizon - 1
+		if _, err = model.DeleteBlocksDataTill(transaction, bodyID); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("pruning bodies of blocks")
+			transaction.Rollback()
+			return err
+		}
+	}
+	if err = model.SetPrunedBlocks(transaction, horizon, bodyID); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("updating pruned blocks")
+		transaction.Rollback()
+		return err
+	}
+	if err = transaction.Commit(); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"block_id": horizon, "body_id": bodyID}).Info("old blocks have been pruned")
+	return nil
+}
@@ -84,6 +84,17 @@ func Write(userID int64, data string) (bool, error) {
 	return publisher.Publish("client"+strconv.FormatInt(userID, 10), []byte(data))
 }
 
+// ChannelReorgs is the channel of notifications about reorganizations of blockchain
+const ChannelReorgs = "reorgs"
+
+// WriteChannel is publishing data to the common channel
+func WriteChannel(channel string, data string) (bool, error) {
+	if publisher == nil {
+		return false, fmt.Errorf("publisher not initialized")
+	}
+	return publisher.Publish(channel, []byte(data))
+}
+
 // GetStats returns Stats
 func GetStats() (gocent.Stats, error) {
 	if publisher == nil {
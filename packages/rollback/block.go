@@ -94,9 +94,9 @@ func rollbackBlock(dbTransaction *model.DbTransaction, block *block.Block) error
 		}
 
 		ts := &model.TransactionStatus{}
-		err = ts.UpdateBlockID(dbTransaction, 0, t.TxHash)
+		err = ts.SetReverted(dbTransaction, block.Header.BlockID, t.TxHash)
 		if err != nil {
-			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("updating block id in transaction status")
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("marking transaction status as reverted")
 			return err
 		}
 
@@ -130,5 +130,15 @@ func rollbackBlock(dbTransaction *model.DbTransaction, block *block.Block) error
 		}
 	}
 
+	// the checkpoints of the archive mode are saved after the block
+	if err := model.DeleteArchiveCheckpointsFrom(dbTransaction, block.Header.BlockID); err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting archive checkpoints")
+		return err
+	}
+	// the confirmations have been received for the hash of the rolled back block
+	if err := model.DeleteConfirmationsFrom(dbTransaction, block.Header.BlockID); err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting confirmations")
+		return err
+	}
 	return nil
 }
@@ -28,6 +28,7 @@ import (
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
 	"github.com/GenesisCommunity/go-genesis/packages/converter"
 	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/pruning"
 	"github.com/GenesisCommunity/go-genesis/packages/utils"
 
 	log "github.com/sirupsen/logrus"
@@ -35,6 +36,10 @@ import (
 
 // ToBlockID rollbacks blocks till blockID
 func ToBlockID(blockID int64, dbTransaction *model.DbTransaction, logger *log.Entry) error {
+	if err := pruning.CheckRollback(dbTransaction, blockID); err != nil {
+		return err
+	}
+
 	_, err := model.MarkVerifiedAndNotUsedTransactionsUnverified()
 	if err != nil {
 		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("marking verified and not used transactions unverified")
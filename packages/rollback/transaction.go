@@ -23,6 +23,8 @@ package rollback
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
@@ -33,11 +35,14 @@ import (
 )
 
 func rollbackUpdatedRow(tx map[string]string, where string, dbTransaction *model.DbTransaction, logger *log.Entry) error {
-	var rollbackInfo map[string]string
-	if err := json.Unmarshal([]byte(tx["data"]), &rollbackInfo); err != nil {
+	rollbackInfo, deleted, err := model.ParseRollbackData(tx["data"])
+	if err != nil {
 		logger.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling rollback.Data from json")
 		return err
 	}
+	if deleted {
+		return rollbackDeletedRow(tx, rollbackInfo, dbTransaction, logger)
+	}
 	addSQLUpdate := ""
 	for k, v := range rollbackInfo {
 		if v == "NULL" {
@@ -56,6 +61,33 @@ func rollbackUpdatedRow(tx map[string]string, where string, dbTransaction *model
 	return nil
 }
 
+func rollbackDeletedRow(tx map[string]string, rollbackInfo map[string]string, dbTransaction *model.DbTransaction, logger *log.Entry) error {
+	fields := make([]string, 0, len(rollbackInfo))
+	for k := range rollbackInfo {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+	values := make([]string, len(fields))
+	for i, k := range fields {
+		v := rollbackInfo[k]
+		if v == "NULL" {
+			values[i] = `NULL`
+		} else if converter.IsByteColumn(tx["table_name"], k) && len(v) != 0 {
+			values[i] = `decode('` + string(converter.BinToHex([]byte(v))) + `','HEX')`
+		} else {
+			values[i] = `'` + strings.Replace(v, `'`, `''`, -1) + `'`
+		}
+		fields[i] = `"` + k + `"`
+	}
+	query := `INSERT INTO "` + tx["table_name"] + `" (` + strings.Join(fields, `,`) + `) VALUES (` +
+		strings.Join(values, `,`) + `)`
+	if err := model.GetDB(dbTransaction).Exec(query).Error; err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": query}).Error("inserting deleted row")
+		return err
+	}
+	return nil
+}
+
 func rollbackInsertedRow(tx map[string]string, where string, dbTransaction *model.DbTransaction, logger *log.Entry) error {
 	if err := model.Delete(dbTransaction, tx["table_name"], where); err != nil {
 		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("deleting from table")
@@ -64,6 +96,37 @@ func rollbackInsertedRow(tx map[string]string, where string, dbTransaction *mode
 	return nil
 }
 
+func rollbackSchema(tx map[string]string, dbTransaction *model.DbTransaction, logger *log.Entry) error {
+	var schema model.SchemaRollback
+	if err := json.Unmarshal([]byte(tx["data"]), &schema); err != nil {
+		logger.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling rollback.Data from json")
+		return err
+	}
+	var err error
+	table := tx["table_name"]
+	switch schema.Action {
+	case model.SchemaDropColumn:
+		if err = model.AlterTableAddColumn(dbTransaction, table, schema.Column, schema.Type); err == nil {
+			err = model.SetColumnValues(dbTransaction, table, schema.Column, schema.Type, schema.Values)
+		}
+	case model.SchemaRenameColumn:
+		err = model.AlterTableRenameColumn(dbTransaction, table, schema.Column, schema.Name)
+	case model.SchemaAlterColumn:
+		err = model.AlterTableColumnType(dbTransaction, table, schema.Column, schema.Type)
+	case model.SchemaCreateIndex:
+		err = model.DropIndex(dbTransaction, schema.Name)
+	case model.SchemaDropIndex:
+		err = model.CreateIndex(dbTransaction, schema.Name, table, `"`+strings.Join(schema.Columns, `","`)+`"`)
+	default:
+		logger.WithFields(log.Fields{"type": consts.InvalidObject, "action": schema.Action}).Error("unknown schema action")
+		return fmt.Errorf(`unknown schema action %s`, schema.Action)
+	}
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "action": schema.Action}).Error("rolling back table structure")
+	}
+	return err
+}
+
 func rollbackTransaction(txHash []byte, dbTransaction *model.DbTransaction, logger *log.Entry) error {
 	rollbackTx := &model.RollbackTx{}
 	txs, err := rollbackTx.GetRollbackTransactions(dbTransaction, txHash)
@@ -73,7 +136,11 @@ func rollbackTransaction(txHash []byte, dbTransaction *model.DbTransaction, logg
 	}
 	for _, tx := range txs {
 		where := " WHERE id='" + tx["table_id"] + `'`
-		if len(tx["data"]) > 0 {
+		if tx["table_id"] == model.RollbackSchemaID {
+			if err := rollbackSchema(tx, dbTransaction, logger); err != nil {
+				return err
+			}
+		} else if len(tx["data"]) > 0 {
 			if err := rollbackUpdatedRow(tx, where, dbTransaction, logger); err != nil {
 				return err
 			}
@@ -0,0 +1,509 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package script
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// IssueError is the level of the problems which lead to the error at runtime
+	IssueError = `error`
+	// IssueWarning is the level of the suspicious code
+	IssueWarning = `warning`
+)
+
+// Issue is the problem found by the static analysis
+type Issue struct {
+	Level string `json:"level"`
+	Name  string `json:"name"` // the name of the contract or the function
+	Text  string `json:"text"`
+}
+
+func (issue *Issue) String() string {
+	if len(issue.Name) == 0 {
+		return fmt.Sprintf(`%s: %s`, issue.Level, issue.Text)
+	}
+	return fmt.Sprintf(`%s %s: %s`, issue.Level, issue.Name, issue.Text)
+}
+
+// AnalyzeOptions describes the environment where the contracts are executed
+type AnalyzeOptions struct {
+	Extend     []string // extend variables which are defined by the environment
+	StateFuncs []string // extended functions which change the state
+}
+
+var (
+	// contractFuncs contains the extended functions which get the names of contracts and
+	// the indexes of such parameters, -1 means all parameters
+	contractFuncs = map[string]int{`ExecContract`: 0, `CallContract`: 1, `ContractConditions`: -1,
+		`ContractAccess`: -1}
+)
+
+// staticValue is the value on the stack which is known at the compile time
+type staticValue struct {
+	Type  reflect.Type // nil if the type is unknown
+	Value interface{}
+	Const bool
+}
+
+// contractUsage collects the usage of the environment inside the contract
+type contractUsage struct {
+	extend   map[string]bool // used extend variables
+	assigned map[string]bool // assigned extend variables
+	calls    map[string]bool // called extended functions
+}
+
+type analyzer struct {
+	vm       *VM
+	owner    *OwnerInfo
+	opts     *AnalyzeOptions
+	names    map[string]bool // contracts of the source
+	reads    map[*Block]map[int]bool
+	issues   []*Issue
+	reported map[string]bool
+}
+
+// Analyze compiles the source code without loading it into the virtual machine and checks it for
+// the problems which otherwise are found only when the wrong code is executed
+func Analyze(vm *VM, input string, owner *OwnerInfo, opts *AnalyzeOptions) []*Issue {
+	root, err := vm.CompileBlock([]rune(input), owner)
+	if err != nil {
+		return []*Issue{{Level: IssueError, Text: err.Error()}}
+	}
+	if opts == nil {
+		opts = &AnalyzeOptions{}
+	}
+	a := &analyzer{vm: vm, owner: owner, opts: opts, names: make(map[string]bool),
+		reads: make(map[*Block]map[int]bool), reported: make(map[string]bool)}
+	for key, obj := range root.Objects {
+		if obj.Type == ObjContract {
+			a.names[key] = true
+		}
+	}
+	a.walk(root, func(block *Block) {
+		for _, cmd := range block.Code {
+			if cmd.Cmd == cmdVar {
+				ivar := cmd.Value.(*VarInfo)
+				if a.reads[ivar.Owner] == nil {
+					a.reads[ivar.Owner] = make(map[int]bool)
+				}
+				a.reads[ivar.Owner][ivar.Obj.Value.(int)] = true
+			}
+		}
+	})
+	for _, child := range root.Children {
+		var usage *contractUsage
+		if child.Type == ObjContract {
+			usage = &contractUsage{extend: make(map[string]bool), assigned: make(map[string]bool),
+				calls: make(map[string]bool)}
+		}
+		a.walk(child, func(block *Block) {
+			a.checkBlock(block, usage)
+		})
+		if usage != nil {
+			a.checkContract(child, usage)
+		}
+	}
+	return a.issues
+}
+
+// walk calls f for the block and all its children
+func (a *analyzer) walk(block *Block, f func(*Block)) {
+	f(block)
+	for _, child := range block.Children {
+		a.walk(child, f)
+	}
+}
+
+func (a *analyzer) add(level string, block *Block, format string, args ...interface{}) {
+	issue := &Issue{Level: level, Name: blockName(block), Text: fmt.Sprintf(format, args...)}
+	if key := issue.String(); !a.reported[key] {
+		a.reported[key] = true
+		a.issues = append(a.issues, issue)
+	}
+}
+
+// blockName returns the name of the function or the contract which contains the block
+func blockName(block *Block) string {
+	for ; block != nil; block = block.Parent {
+		switch block.Type {
+		case ObjContract:
+			return block.Info.(*ContractInfo).Name
+		case ObjFunc:
+			for key, obj := range block.Parent.Objects {
+				if obj.Value == block {
+					if block.Parent.Type == ObjContract {
+						return block.Parent.Info.(*ContractInfo).Name + `.` + key
+					}
+					return key
+				}
+			}
+		}
+	}
+	return ``
+}
+
+func (a *analyzer) checkBlock(block *Block, usage *contractUsage) {
+	a.checkVars(block)
+	for ci, cmd := range block.Code {
+		switch cmd.Cmd {
+		case cmdReturn, cmdBreak, cmdContinue, cmdError:
+			// the last continue of the loop is added by the compiler
+			if ci < len(block.Code)-1 && !(ci == len(block.Code)-2 && block.Code[ci+1].Cmd == cmdContinue) {
+				a.add(IssueWarning, block, `unreachable code`)
+			}
+		case cmdWhile:
+			if ci > 1 && block.Code[ci-2].Cmd == cmdLabel && block.Code[ci-1].Cmd == cmdPush &&
+				valueToBool(block.Code[ci-1].Value) && !loopExits(cmd.Value.(*Block), true) {
+				a.add(IssueWarning, block, `infinite loop without break`)
+			}
+		case cmdAssignVar:
+			if usage != nil {
+				for _, ivar := range cmd.Value.([]*VarInfo) {
+					if ivar.Owner == nil && ivar.Obj.Type == ObjExtend {
+						usage.assigned[ivar.Obj.Value.(string)] = true
+					}
+				}
+			}
+		case cmdExtend:
+			if usage != nil {
+				usage.extend[cmd.Value.(string)] = true
+			}
+		}
+	}
+	a.checkCalls(block, usage)
+}
+
+// checkVars checks that the variables of the block are used
+func (a *analyzer) checkVars(block *Block) {
+	params := make(map[int]bool)
+	if block.Type == ObjFunc {
+		finfo := block.Info.(*FuncInfo)
+		for i := range finfo.Params {
+			params[i] = true
+		}
+		if finfo.Names != nil {
+			for _, name := range *finfo.Names {
+				for _, off := range name.Offset {
+					params[off] = true
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(block.Objects))
+	for key, obj := range block.Objects {
+		if obj.Type == ObjVar && !params[obj.Value.(int)] && !a.reads[block][obj.Value.(int)] {
+			names = append(names, key)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a.add(IssueWarning, block, `variable %s is not used`, name)
+	}
+}
+
+// loopExits returns true if the loop can be finished by break, return or error
+func loopExits(block *Block, isBreak bool) bool {
+	for _, cmd := range block.Code {
+		switch cmd.Cmd {
+		case cmdReturn, cmdError:
+			return true
+		case cmdBreak:
+			if isBreak {
+				return true
+			}
+		case cmdIf, cmdElse:
+			if loopExits(cmd.Value.(*Block), isBreak) {
+				return true
+			}
+		case cmdWhile:
+			if loopExits(cmd.Value.(*Block), false) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkContract checks the environment of the contract
+func (a *analyzer) checkContract(block *Block, usage *contractUsage) {
+	cinfo := block.Info.(*ContractInfo)
+	known := make(map[string]bool)
+	for _, name := range a.opts.Extend {
+		known[name] = true
+	}
+	if cinfo.Tx != nil {
+		for _, field := range *cinfo.Tx {
+			known[field.Name] = true
+		}
+	}
+	names := make([]string, 0, len(usage.extend))
+	for name := range usage.extend {
+		if !known[name] && !usage.assigned[name] && !isSysVar(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a.add(IssueWarning, block, `$%s is not defined`, name)
+	}
+	names = names[:0]
+	for name := range cinfo.Used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a.checkContractName(block, name, IssueError)
+	}
+	if cond, ok := block.Objects[`conditions`]; !ok || cond.Type != ObjFunc {
+		for _, name := range a.opts.StateFuncs {
+			if usage.calls[name] {
+				a.add(IssueWarning, block, `contract changes the state without conditions`)
+				break
+			}
+		}
+	}
+}
+
+// checkContractName reports the contract which is neither in the source nor in the virtual machine
+func (a *analyzer) checkContractName(block *Block, name, level string) {
+	name = StateName(a.owner.StateID, name)
+	if a.names[name] {
+		return
+	}
+	if obj, ok := a.vm.Objects[name]; ok && obj.Type == ObjContract {
+		return
+	}
+	a.add(level, block, eUnknownContract, name)
+}
+
+// checkCalls emulates the stack of the block with the types known at the compile time
+// and checks the parameters of the called functions
+func (a *analyzer) checkCalls(block *Block, usage *contractUsage) {
+	stack := make([]staticValue, 0, 16)
+	pop := func(count int) ([]staticValue, bool) {
+		if count > len(stack) || count < 0 {
+			return nil, false
+		}
+		ret := stack[len(stack)-count:]
+		stack = stack[:len(stack)-count]
+		return ret, true
+	}
+	jumps := make(map[int]bool)
+	for ci := 0; ci < len(block.Code); ci++ {
+		if jumps[ci] && len(stack) > 0 {
+			// the value can be calculated by any of the branches of the ternary operator
+			stack[len(stack)-1] = staticValue{}
+		}
+		cmd := block.Code[ci]
+		count := 0
+		switch cmd.Cmd {
+		case cmdPush, cmdPushStr:
+			stack = append(stack, staticValue{reflect.TypeOf(cmd.Value), cmd.Value, true})
+		case cmdVar:
+			ivar := cmd.Value.(*VarInfo)
+			var value staticValue
+			if vtype := ivar.Owner.Vars[ivar.Obj.Value.(int)]; vtype.String() == Decimal || isStructType(vtype) {
+				// the values of these types are converted on the assignment
+				value.Type = vtype
+			}
+			stack = append(stack, value)
+		case cmdExtend:
+			stack = append(stack, staticValue{})
+		case cmdIndex:
+			count = 2
+		case cmdSetIndex, cmdSetField:
+			if _, ok := pop(2); !ok {
+				return
+			}
+		case cmdGetField:
+			if _, ok := pop(1); !ok {
+				return
+			}
+			finfo := cmd.Value.(*StructFieldInfo)
+			stack = append(stack, staticValue{Type: finfo.Var.Owner.Vars[finfo.Var.Obj.Value.(int)].Elem().Field(finfo.Index).Type})
+		case cmdWhile, cmdJumpFalse:
+			if _, ok := pop(1); !ok {
+				return
+			}
+		case cmdJump:
+			if _, ok := pop(1); !ok {
+				return
+			}
+			jumps[ci+cmd.Value.(int)] = true
+		case cmdFuncName:
+			if _, ok := pop(cmd.Value.(FuncNameCmd).Count); !ok || len(stack) == 0 {
+				return
+			}
+		case cmdCall, cmdCallVari:
+			results, ok := a.checkCall(block, cmd, &stack, usage)
+			if !ok {
+				return
+			}
+			stack = append(stack, results...)
+		case cmdReturn, cmdCallExtend:
+			// the count of parameters of the extend functions is unknown
+			return
+		default:
+			count = int(cmd.Cmd >> 8)
+		}
+		if count > 0 {
+			if _, ok := pop(count); !ok {
+				return
+			}
+			var value staticValue
+			switch cmd.Cmd {
+			case cmdNot, cmdAnd, cmdOr, cmdEqual, cmdNotEq, cmdLess, cmdNotLess, cmdGreat, cmdNotGreat:
+				value.Type = reflect.TypeOf(true)
+			}
+			stack = append(stack, value)
+		}
+	}
+}
+
+// checkCall checks the parameters of the function and returns the values of the results
+func (a *analyzer) checkCall(block *Block, cmd *ByteCode, stack *[]staticValue,
+	usage *contractUsage) ([]staticValue, bool) {
+	pop := func(count int) ([]staticValue, bool) {
+		if count > len(*stack) || count < 0 {
+			return nil, false
+		}
+		ret := (*stack)[len(*stack)-count:]
+		*stack = (*stack)[:len(*stack)-count]
+		return ret, true
+	}
+	count := -1
+	if cmd.Cmd == cmdCallVari {
+		top, ok := pop(1)
+		if !ok || !top[0].Const {
+			return nil, false
+		}
+		if count, ok = top[0].Value.(int); !ok {
+			return nil, false
+		}
+	}
+	obj := cmd.Value.(*ObjInfo)
+	switch obj.Type {
+	case ObjExtFunc:
+		finfo := obj.Value.(ExtFuncInfo)
+		if usage != nil {
+			usage.calls[finfo.Name] = true
+		}
+		params := make([]reflect.Type, 0, len(finfo.Params))
+		for i, ptype := range finfo.Params {
+			if len(finfo.Auto[i]) == 0 {
+				params = append(params, ptype)
+			}
+		}
+		if count < 0 {
+			count = len(params)
+		}
+		args, ok := pop(count)
+		if !ok {
+			return nil, false
+		}
+		for i, arg := range args {
+			var ptype reflect.Type
+			if finfo.Variadic && i >= len(params)-1 {
+				ptype = params[len(params)-1].Elem()
+			} else if i < len(params) {
+				ptype = params[i]
+			} else {
+				break
+			}
+			atype := arg.Type
+			if atype != nil && isStructType(atype) {
+				atype = reflect.TypeOf(map[string]interface{}{})
+			}
+			if atype != nil && ptype.Kind() != reflect.Interface && !atype.AssignableTo(ptype) {
+				a.add(IssueError, block, `parameter %d of %s must be %s instead of %s`, i+1, finfo.Name,
+					typeName(ptype), typeName(atype))
+			}
+			if ind, ok := contractFuncs[finfo.Name]; ok && (ind < 0 || ind == i) && arg.Const {
+				if name, ok := arg.Value.(string); ok && len(name) > 0 {
+					// the contract can be created later, e.g. by the next contract of the import
+					a.checkContractName(block, name, IssueWarning)
+				}
+			}
+		}
+		results := make([]staticValue, 0, len(finfo.Results))
+		for i, rtype := range finfo.Results {
+			if i == 0 && a.vm.FuncCallsDB != nil {
+				if _, ok := a.vm.FuncCallsDB[finfo.Name]; ok {
+					continue
+				}
+			}
+			if rtype.String() == `error` {
+				continue
+			}
+			var value staticValue
+			if rtype.Kind() != reflect.Interface {
+				value.Type = rtype
+			}
+			results = append(results, value)
+		}
+		return results, true
+	case ObjFunc:
+		fblock := obj.Value.(*Block)
+		finfo := fblock.Info.(*FuncInfo)
+		if finfo.Names != nil {
+			if _, ok := pop(1); !ok {
+				return nil, false
+			}
+		}
+		if count < 0 {
+			count = len(finfo.Params)
+		}
+		args, ok := pop(count)
+		if !ok {
+			return nil, false
+		}
+		for i, arg := range args {
+			if i >= len(finfo.Params) || (finfo.Variadic && i >= len(finfo.Params)-1) {
+				break
+			}
+			switch finfo.Params[i].String() {
+			case `string`, `int64`:
+				if arg.Type != nil && arg.Type != finfo.Params[i] {
+					a.add(IssueError, block, `parameter %d of %s must be %s instead of %s`, i+1,
+						blockName(fblock), typeName(finfo.Params[i]), typeName(arg.Type))
+				}
+			}
+		}
+		return make([]staticValue, len(finfo.Results)), true
+	}
+	return nil, false
+}
+
+// typeName returns the name of the type in the terms of the contract language
+func typeName(t reflect.Type) string {
+	for name, itype := range types {
+		if itype == t {
+			return name
+		}
+	}
+	return strings.TrimPrefix(t.String(), `*`)
+}
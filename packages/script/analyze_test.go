@@ -0,0 +1,108 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package script
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	test := []TestComp{
+		{`contract Pay {
+			data {
+				Amount int
+			}
+			action {
+				var unused, i int
+				DBInsert("pays", "amount,total", $Amount, $Total)
+				Str("10")
+				ContractConditions("MainCondition", "Missing")
+				while true {
+					i = i + 1
+				}
+				return
+				i = 2
+			}
+		}`, `warning @1Pay.action: variable unused is not used
+warning @1Pay.action: infinite loop without break
+warning @1Pay.action: unreachable code
+error @1Pay.action: parameter 1 of Str must be int instead of string
+warning @1Pay.action: unknown contract @1Missing
+warning @1Pay: $Total is not defined
+warning @1Pay: contract changes the state without conditions`},
+		{`contract Pay {
+			conditions {
+				$Total = 10
+			}
+			action {
+				var i int
+				while true {
+					if i > 10 {
+						break
+					}
+					i = i + 1
+				}
+				DBInsert("pays", "total", $Total)
+				ContractConditions("MainCondition", "Pay")
+			}
+		}
+		func sum(a, b int) int {
+			return a + b
+		}`, ``},
+		{`func sum(a int) int {
+			return a + b
+		}`, `error: unknown identifier b`},
+		// the contract which is imported after this one
+		{`contract Withdraw {
+			conditions {
+				ContractConditions("WithdrawLimits")
+			}
+		}`, `warning @1Withdraw.conditions: unknown contract @1WithdrawLimits`},
+	}
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]interface{}{"Sprintf": fmt.Sprintf,
+		"DBInsert": func(table, params string, vals ...interface{}) int64 {
+			return 0
+		},
+		"Str": func(v int64) string {
+			return fmt.Sprint(v)
+		},
+		"ContractConditions": func(names ...interface{}) bool {
+			return true
+		},
+	}, nil})
+	if err := vm.Compile([]rune(`contract MainCondition {}`), &OwnerInfo{StateID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range test {
+		issues := Analyze(vm, item.Input, &OwnerInfo{StateID: 1}, &AnalyzeOptions{StateFuncs: []string{`DBInsert`}})
+		out := make([]string, len(issues))
+		for i, issue := range issues {
+			out[i] = issue.String()
+		}
+		if strings.Join(out, "\n") != item.Output {
+			t.Errorf("wrong analysis %s != %s", strings.Join(out, "\n"), item.Output)
+		}
+	}
+}
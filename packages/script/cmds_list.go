@@ -45,12 +45,18 @@ const (
 	cmdFuncName              // set func name Func(...).Name(...)
 	cmdUnwrapArr             // unwrap array to stack
 	cmdError                 // error command
+	cmdJump                  // jump to the relative position of the bytecode
+	cmdJumpFalse             // jump to the relative position of the bytecode if Value is false
+	cmdGetField              // get the field of the user-defined type
+	cmdSetField              // set the field of the user-defined type
 )
 
 // the commands for operations in expressions are listed below
 const (
 	cmdNot = iota | 0x0100
 	cmdSign
+	cmdBitNot
+	cmdToStr
 )
 
 const (
@@ -66,7 +72,15 @@ const (
 	cmdNotLess
 	cmdGreat
 	cmdNotGreat
+	cmdMod
+	cmdBitAnd
+	cmdBitOr
+	cmdBitXor
+	cmdShiftL
+	cmdShiftR
 
 	cmdSys          = 0xff
 	cmdUnary uint16 = 50
+	// the priority of the ternary operator ?:
+	cmdTernary uint16 = 5
 )
@@ -152,6 +152,7 @@ const (
 	cfContinue
 	cfBreak
 	cfCmdError
+	cfAssignOper
 
 //	cfEval
 )
@@ -163,6 +164,15 @@ var (
 		isLess: {cmdLess, 22}, isGrEq: {cmdNotLess, 22}, isGreat: {cmdGreat, 22}, isLessEq: {cmdNotGreat, 22},
 		isPlus: {cmdAdd, 25}, isMinus: {cmdSub, 25}, isAsterisk: {cmdMul, 30},
 		isSolidus: {cmdDiv, 30}, isSign: {cmdSign, cmdUnary}, isNot: {cmdNot, cmdUnary}, isLPar: {cmdSys, 0xff}, isRPar: {cmdSys, 0},
+		isPercent: {cmdMod, 30}, isBitAnd: {cmdBitAnd, 30}, isShiftL: {cmdShiftL, 30}, isShiftR: {cmdShiftR, 30},
+		isBitOr: {cmdBitOr, 25}, isBitXor: {cmdBitXor, 25}, isBitNot: {cmdBitNot, cmdUnary},
+		isToStr: {cmdToStr, cmdUnary},
+	}
+	// Compound assignments and the corresponding operations
+	assignOpers = map[uint32]uint32{
+		isAddEq: isPlus, isSubEq: isMinus, isMulEq: isAsterisk, isDivEq: isSolidus, isModEq: isPercent,
+		isAndEq: isBitAnd, isOrEq: isBitOr, isXorEq: isBitXor, isShiftLEq: isShiftL, isShiftREq: isShiftR,
+		isInc: isPlus, isDec: isMinus,
 	}
 	// The array of functions corresponding to the constants cf...
 	funcs = []compileFunc{nil,
@@ -189,6 +199,7 @@ var (
 		fContinue,
 		fBreak,
 		fCmdError,
+		fAssignOper,
 	}
 
 	// 'states' describes a finite machine with states on the base of which a bytecode will be generated
@@ -288,6 +299,7 @@ var (
 		{ // stateAssignEval
 			isLPar:   {stateEval | stateToFork | stateToBody, 0},
 			isLBrack: {stateEval | stateToFork | stateToBody, 0},
+			isDot:    {stateEval | stateToFork | stateToBody, 0},
 			0:        {stateAssign | stateToFork | stateStay, 0},
 		},
 		{ // stateAssign
@@ -295,6 +307,7 @@ var (
 			lexIdent:  {stateAssign, cfAssignVar},
 			lexExtend: {stateAssign, cfAssignVar},
 			isEq:      {stateEval | stateToBody, cfAssign},
+			lexAssign: {stateEval | stateToBody, cfAssignOper},
 			0:         {errAssign, cfError},
 		},
 		{ // stateTX
@@ -364,12 +377,12 @@ func fFuncResult(buf *[]*Block, state int, lexem *Lexem) error {
 }
 
 func fReturn(buf *[]*Block, state int, lexem *Lexem) error {
-	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{cmdReturn, 0})
+	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{Cmd: cmdReturn, Value: 0, Line: lexem.Line})
 	return nil
 }
 
 func fCmdError(buf *[]*Block, state int, lexem *Lexem) error {
-	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{cmdError, lexem.Value})
+	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{Cmd: cmdError, Value: lexem.Value, Line: lexem.Line})
 	return nil
 }
 
@@ -493,23 +506,23 @@ func fFNameParam(buf *[]*Block, state int, lexem *Lexem) error {
 }
 
 func fIf(buf *[]*Block, state int, lexem *Lexem) error {
-	(*(*buf)[len(*buf)-2]).Code = append((*(*buf)[len(*buf)-2]).Code, &ByteCode{cmdIf, (*buf)[len(*buf)-1]})
+	(*(*buf)[len(*buf)-2]).Code = append((*(*buf)[len(*buf)-2]).Code, &ByteCode{Cmd: cmdIf, Value: (*buf)[len(*buf)-1], Line: lexem.Line})
 	return nil
 }
 
 func fWhile(buf *[]*Block, state int, lexem *Lexem) error {
-	(*(*buf)[len(*buf)-2]).Code = append((*(*buf)[len(*buf)-2]).Code, &ByteCode{cmdWhile, (*buf)[len(*buf)-1]})
-	(*(*buf)[len(*buf)-2]).Code = append((*(*buf)[len(*buf)-2]).Code, &ByteCode{cmdContinue, 0})
+	(*(*buf)[len(*buf)-2]).Code = append((*(*buf)[len(*buf)-2]).Code, &ByteCode{Cmd: cmdWhile, Value: (*buf)[len(*buf)-1], Line: lexem.Line})
+	(*(*buf)[len(*buf)-2]).Code = append((*(*buf)[len(*buf)-2]).Code, &ByteCode{Cmd: cmdContinue, Value: 0, Line: lexem.Line})
 	return nil
 }
 
 func fContinue(buf *[]*Block, state int, lexem *Lexem) error {
-	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{cmdContinue, 0})
+	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{Cmd: cmdContinue, Value: 0, Line: lexem.Line})
 	return nil
 }
 
 func fBreak(buf *[]*Block, state int, lexem *Lexem) error {
-	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{cmdBreak, 0})
+	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{Cmd: cmdBreak, Value: 0, Line: lexem.Line})
 	return nil
 }
 
@@ -541,15 +554,54 @@ func fAssignVar(buf *[]*Block, state int, lexem *Lexem) error {
 	}
 	prev = append(prev, &ivar)
 	if len(prev) == 1 {
-		(*(*buf)[len(*buf)-1]).Code = append((*block).Code, &ByteCode{cmdAssignVar, prev})
+		(*(*buf)[len(*buf)-1]).Code = append((*block).Code, &ByteCode{Cmd: cmdAssignVar, Value: prev, Line: lexem.Line})
 	} else {
-		(*(*buf)[len(*buf)-1]).Code[len(block.Code)-1] = &ByteCode{cmdAssignVar, prev}
+		(*(*buf)[len(*buf)-1]).Code[len(block.Code)-1] = &ByteCode{Cmd: cmdAssignVar, Value: prev, Line: lexem.Line}
 	}
 	return nil
 }
 
 func fAssign(buf *[]*Block, state int, lexem *Lexem) error {
-	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{cmdAssign, 0})
+	(*(*buf)[len(*buf)-1]).Code = append((*(*buf)[len(*buf)-1]).Code, &ByteCode{Cmd: cmdAssign, Value: 0, Line: lexem.Line})
+	return nil
+}
+
+// fAssignOper compiles var op= expr as var = var op (expr), var++ and var-- are compiled as var += 1 and var -= 1
+func fAssignOper(buf *[]*Block, state int, lexem *Lexem) error {
+	block := (*buf)[len(*buf)-1]
+	logger := lexem.GetLogger()
+	ind := len(block.Code) - 1
+	for ; ind >= 0 && block.Code[ind].Cmd != cmdAssignVar; ind-- {
+	}
+	if ind < 0 || len(block.Code[ind].Value.([]*VarInfo)) != 1 {
+		logger.WithFields(log.Fields{"type": consts.ParseError}).Error("compound assignment requires one variable")
+		return fmt.Errorf(`compound assignment requires one variable`)
+	}
+	ivar := block.Code[ind].Value.([]*VarInfo)[0]
+	code := make(ByteCodes, 0, len(block.Code)-ind+3)
+	if ivar.Owner == nil {
+		code = append(code, &ByteCode{Cmd: cmdExtend, Value: ivar.Obj.Value.(string), Line: lexem.Line})
+	} else {
+		code = append(code, &ByteCode{Cmd: cmdVar, Value: ivar, Line: lexem.Line})
+	}
+	code = append(code, block.Code[ind+1:]...)
+	value := lexem.Value.(uint32)
+	if value == isInc || value == isDec {
+		if len(code) > 1 {
+			logger.WithFields(log.Fields{"type": consts.ParseError}).Error("unexpected expression after increment")
+			return fmt.Errorf(`unexpected expression after increment`)
+		}
+		code = append(code, &ByteCode{Cmd: cmdPush, Value: int64(1), Line: lexem.Line})
+	} else if len(code) == 1 {
+		logger.WithFields(log.Fields{"type": consts.ParseError}).Error("there is not eval expression")
+		return fmt.Errorf(`there is not eval expression`)
+	}
+	if len(code) == 1 {
+		block.Lines = append(block.Lines, SourcePos{Offset: ind, Line: lexem.Line, Column: lexem.Column})
+	}
+	oper := opers[assignOpers[value]]
+	code = append(code, &ByteCode{Cmd: oper.Cmd, Value: oper.Priority, Line: lexem.Line}, &ByteCode{Cmd: cmdAssign, Value: 0, Line: lexem.Line})
+	block.Code = append(block.Code[:ind+1], code...)
 	return nil
 }
 
@@ -626,7 +678,7 @@ func fElse(buf *[]*Block, state int, lexem *Lexem) error {
 		logger.WithFields(log.Fields{"type": consts.ParseError}).Error("there is not if before")
 		return fmt.Errorf(`there is not if before %v [Ln:%d Col:%d]`, lexem.Type, lexem.Line, lexem.Column)
 	}
-	(*(*buf)[len(*buf)-2]).Code = append(code, &ByteCode{cmdElse, (*buf)[len(*buf)-1]})
+	(*(*buf)[len(*buf)-2]).Code = append(code, &ByteCode{Cmd: cmdElse, Value: (*buf)[len(*buf)-1], Line: lexem.Line})
 	return nil
 }
 
@@ -686,6 +738,21 @@ func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*Block, error) {
 			ok       bool
 		)
 		lexem := lexems[i]
+		switch curState {
+		case stateRoot, stateBody:
+			if isTypeDecl(lexems, i) {
+				if err := vm.compileStruct(&lexems, &i, &blockstack); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		case stateVarType, stateFParamTYPE, stateFResult:
+			if lexem.Type == lexIdent {
+				if objInfo, _ := vm.findObj(lexem.Value.(string), &blockstack); objInfo != nil && objInfo.Type == ObjStruct {
+					lexem = &Lexem{lexType, objInfo.Value, lexem.Line, lexem.Column}
+				}
+			}
+		}
 		if newState, ok = states[curState][int(lexem.Type)]; !ok {
 			newState = states[curState][0]
 		}
@@ -706,7 +773,7 @@ func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*Block, error) {
 		}
 		if nextState == stateEval {
 			if newState.NewState&stateLabel > 0 {
-				(*blockstack[len(blockstack)-1]).Code = append((*blockstack[len(blockstack)-1]).Code, &ByteCode{cmdLabel, 0})
+				(*blockstack[len(blockstack)-1]).Code = append((*blockstack[len(blockstack)-1]).Code, &ByteCode{Cmd: cmdLabel, Value: 0})
 			}
 			curlen := len((*blockstack[len(blockstack)-1]).Code)
 			if err := vm.compileEval(&lexems, &i, &blockstack); err != nil {
@@ -740,7 +807,7 @@ func (vm *VM) CompileBlock(input []rune, owner *OwnerInfo) (*Block, error) {
 				if len(prev.Code) > 0 && (*prev).Code[len((*prev).Code)-1].Cmd == cmdContinue {
 					(*prev).Code = (*prev).Code[:len((*prev).Code)-1]
 					prev = blockstack[len(blockstack)-1]
-					(*prev).Code = append((*prev).Code, &ByteCode{cmdContinue, 0})
+					(*prev).Code = append((*prev).Code, &ByteCode{Cmd: cmdContinue, Value: 0})
 				}
 			}
 			blockstack = blockstack[:len(blockstack)-1]
@@ -853,16 +920,53 @@ func (vm *VM) compileEval(lexems *Lexems, ind *int, block *[]*Block) error {
 
 	i := *ind
 	curBlock := (*block)[len(*block)-1]
+	start := (*lexems)[i]
 
 	buffer := make(ByteCodes, 0, 20)
 	bytecode := make(ByteCodes, 0, 100)
 	parcount := make([]int, 0, 20)
+	jumps := make([]int, 0)
 	setIndex := false
+	var setField, fieldOper *ByteCode
+	fieldStart := 0
+
+	// flushOper moves the operation from the buffer to the bytecode. The markers of the ternary operator
+	// are not moved, the marker of ':' sets the position of the jump to the end of the expression
+	flushOper := func(oper *ByteCode) error {
+		switch oper.Cmd {
+		case cmdJumpFalse:
+			log.WithFields(log.Fields{"type": consts.ParseError}).Error("there is not ':' in the ternary operator")
+			return errTernary
+		case cmdJump:
+			jump := jumps[len(jumps)-1]
+			jumps = jumps[:len(jumps)-1]
+			bytecode[jump].Value = len(bytecode) - jump
+		default:
+			bytecode = append(bytecode, oper)
+		}
+		return nil
+	}
+	// setLine sets the line of the previous lexem to the commands which have been added for it
+	line, marked := start.Line, 0
+	setLine := func() {
+		for ; marked < len(bytecode); marked++ {
+			if bytecode[marked].Line == 0 {
+				bytecode[marked].Line = line
+			}
+		}
+		for _, item := range buffer {
+			if item.Line == 0 {
+				item.Line = line
+			}
+		}
+	}
 main:
 	for ; i < len(*lexems); i++ {
 		var cmd *ByteCode
 		var call bool
 		lexem := (*lexems)[i]
+		setLine()
+		line = lexem.Line
 		logger := lexem.GetLogger()
 		switch lexem.Type {
 		case isRCurly, isLCurly:
@@ -879,9 +983,9 @@ main:
 			}
 			break main
 		case isLPar:
-			buffer = append(buffer, &ByteCode{cmdSys, uint16(0xff)})
+			buffer = append(buffer, &ByteCode{Cmd: cmdSys, Value: uint16(0xff)})
 		case isLBrack:
-			buffer = append(buffer, &ByteCode{cmdSys, uint16(0xff)})
+			buffer = append(buffer, &ByteCode{Cmd: cmdSys, Value: uint16(0xff)})
 		case isComma:
 			if len(parcount) > 0 {
 				parcount[len(parcount)-1]++
@@ -891,7 +995,9 @@ main:
 				if prev.Cmd == cmdSys && prev.Value.(uint16) == 0xff {
 					break
 				} else {
-					bytecode = append(bytecode, prev)
+					if err := flushOper(prev); err != nil {
+						return err
+					}
 					buffer = buffer[:len(buffer)-1]
 				}
 			}
@@ -905,8 +1011,8 @@ main:
 				buffer = buffer[:len(buffer)-1]
 				if prev.Value.(uint16) == 0xff {
 					break
-				} else {
-					bytecode = append(bytecode, prev)
+				} else if err := flushOper(prev); err != nil {
+					return err
 				}
 			}
 			if len(buffer) > 0 {
@@ -921,7 +1027,7 @@ main:
 				if prev := buffer[len(buffer)-1]; prev.Cmd == cmdCall || prev.Cmd == cmdCallVari {
 					if prev.Value.(*ObjInfo).Type == ObjFunc && prev.Value.(*ObjInfo).Value.(*Block).Info.(*FuncInfo).Names != nil {
 						if len(bytecode) == 0 || bytecode[len(bytecode)-1].Cmd != cmdFuncName {
-							bytecode = append(bytecode, &ByteCode{cmdPush, nil})
+							bytecode = append(bytecode, &ByteCode{Cmd: cmdPush, Value: nil})
 						}
 						if i < len(*lexems)-4 && (*lexems)[i+1].Type == isDot {
 							if (*lexems)[i+2].Type != lexIdent {
@@ -934,7 +1040,7 @@ main:
 								if i < len(*lexems)-5 && (*lexems)[i+3].Type == isLPar {
 									objInfo, _ := vm.findObj((*lexems)[i+2].Value.(string), block)
 									if objInfo != nil && objInfo.Type == ObjFunc || objInfo.Type == ObjExtFunc {
-										tail = &ByteCode{uint16(cmdCall), objInfo}
+										tail = &ByteCode{Cmd: uint16(cmdCall), Value: objInfo}
 									}
 								}
 								if tail == nil {
@@ -943,7 +1049,7 @@ main:
 								}
 							}
 							if tail == nil {
-								buffer = append(buffer, &ByteCode{cmdFuncName, FuncNameCmd{Name: (*lexems)[i+2].Value.(string)}})
+								buffer = append(buffer, &ByteCode{Cmd: cmdFuncName, Value: FuncNameCmd{Name: (*lexems)[i+2].Value.(string)}})
 								count := 0
 								if (*lexems)[i+3].Type != isRPar {
 									count++
@@ -965,14 +1071,14 @@ main:
 								wantlen--
 							}
 						}
-						if count != wantlen && (!extinfo.Variadic || count < wantlen) {
+						if count != wantlen && (!extinfo.Variadic || count < wantlen-1) {
 							errtext = fmt.Sprintf(eWrongParams, extinfo.Name, wantlen)
 							logger.WithFields(log.Fields{"error": errtext, "type": consts.ParseError}).Error(errtext)
 							return fmt.Errorf(errtext)
 						}
 					}
 					if prev.Cmd == cmdCallVari {
-						bytecode = append(bytecode, &ByteCode{cmdPush, count})
+						bytecode = append(bytecode, &ByteCode{Cmd: cmdPush, Value: count})
 					}
 					buffer = buffer[:len(buffer)-1]
 					bytecode = append(bytecode, prev)
@@ -993,14 +1099,18 @@ main:
 				buffer = buffer[:len(buffer)-1]
 				if prev.Value.(uint16) == 0xff {
 					break
-				} else {
-					bytecode = append(bytecode, prev)
+				} else if err := flushOper(prev); err != nil {
+					return err
 				}
 			}
 			if len(buffer) > 0 {
 				if prev := buffer[len(buffer)-1]; prev.Cmd == cmdIndex {
 					buffer = buffer[:len(buffer)-1]
 					if i < len(*lexems)-1 && (*lexems)[i+1].Type == isEq {
+						if prev.Value.(*IndexInfo) == nil {
+							logger.WithFields(log.Fields{"type": consts.ParseError}).Error(errFieldIndex.Error())
+							return errFieldIndex
+						}
 						i++
 						setIndex = true
 						indexInfo = prev.Value.(*IndexInfo)
@@ -1010,6 +1120,40 @@ main:
 				}
 			}
 		case lexOper:
+			if lexem.Value.(uint32) == isQuestion || lexem.Value.(uint32) == isColon {
+				// the condition of the ternary operator jumps to the else-expression and the end of
+				// the then-expression jumps to the end of the ternary operator
+				for len(buffer) > 0 {
+					prev := buffer[len(buffer)-1]
+					if prev.Cmd == cmdSys || prev.Cmd == cmdJumpFalse {
+						break
+					}
+					if prev.Value.(uint16) > cmdTernary || (prev.Cmd == cmdJump && lexem.Value.(uint32) == isColon) {
+						if err := flushOper(prev); err != nil {
+							return err
+						}
+						buffer = buffer[:len(buffer)-1]
+					} else {
+						break
+					}
+				}
+				if lexem.Value.(uint32) == isQuestion {
+					jumps = append(jumps, len(bytecode))
+					bytecode = append(bytecode, &ByteCode{Cmd: cmdJumpFalse, Value: 0})
+					buffer = append(buffer, &ByteCode{Cmd: cmdJumpFalse, Value: cmdTernary})
+					continue main
+				}
+				if len(buffer) == 0 || buffer[len(buffer)-1].Cmd != cmdJumpFalse {
+					logger.WithFields(log.Fields{"type": consts.ParseError}).Error("there is not '?' in the ternary operator")
+					return errTernary
+				}
+				jump := jumps[len(jumps)-1]
+				jumps[len(jumps)-1] = len(bytecode)
+				bytecode = append(bytecode, &ByteCode{Cmd: cmdJump, Value: 0})
+				bytecode[jump].Value = len(bytecode) - jump
+				buffer[len(buffer)-1] = &ByteCode{Cmd: cmdJump, Value: cmdTernary}
+				continue main
+			}
 			if oper, ok := opers[lexem.Value.(uint32)]; ok {
 				var prevType uint32
 				if i > 0 {
@@ -1021,7 +1165,7 @@ main:
 					oper.Cmd = cmdSign
 					oper.Priority = cmdUnary
 				}
-				byteOper := &ByteCode{oper.Cmd, oper.Priority}
+				byteOper := &ByteCode{Cmd: oper.Cmd, Value: oper.Priority}
 				for {
 					if len(buffer) == 0 {
 						buffer = append(buffer, byteOper)
@@ -1050,7 +1194,7 @@ main:
 				return fmt.Errorf(`unknown operator %d`, lexem.Value.(uint32))
 			}
 		case lexNumber, lexString:
-			cmd = &ByteCode{cmdPush, lexem.Value}
+			cmd = &ByteCode{Cmd: cmdPush, Value: lexem.Value}
 		case lexExtend:
 			if i < len(*lexems)-2 {
 				if (*lexems)[i+1].Type == isLPar {
@@ -1059,14 +1203,14 @@ main:
 						count++
 					}
 					parcount = append(parcount, count)
-					buffer = append(buffer, &ByteCode{cmdCallExtend, lexem.Value.(string)})
+					buffer = append(buffer, &ByteCode{Cmd: cmdCallExtend, Value: lexem.Value.(string)})
 					call = true
 				}
 			}
 			if !call {
-				cmd = &ByteCode{cmdExtend, lexem.Value.(string)}
+				cmd = &ByteCode{Cmd: cmdExtend, Value: lexem.Value.(string)}
 				if i < len(*lexems)-1 && (*lexems)[i+1].Type == isLBrack {
-					buffer = append(buffer, &ByteCode{cmdIndex, &IndexInfo{Extend: lexem.Value.(string)}})
+					buffer = append(buffer, &ByteCode{Cmd: cmdIndex, Value: &IndexInfo{Extend: lexem.Value.(string)}})
 				}
 			}
 		case lexIdent:
@@ -1075,6 +1219,48 @@ main:
 				logger.WithFields(log.Fields{"lex_value": lexem.Value.(string), "type": consts.ParseError}).Error("unknown identifier")
 				return fmt.Errorf(`unknown identifier %s`, lexem.Value.(string))
 			}
+			if objInfo != nil && objInfo.Type == ObjVar && tobj != nil && i < len(*lexems)-2 &&
+				(*lexems)[i+1].Type == isDot && isStructType(tobj.Vars[objInfo.Value.(int)]) {
+				// the field of the user-defined type is checked at the compile time
+				ivar := &VarInfo{objInfo, tobj}
+				field := -1
+				if (*lexems)[i+2].Type == lexIdent {
+					field = fieldIndex(tobj.Vars[objInfo.Value.(int)], (*lexems)[i+2].Value.(string))
+				}
+				if field < 0 {
+					logger.WithFields(log.Fields{"lex_value": (*lexems)[i+2].Value, "type": consts.ParseError}).Error("unknown field")
+					return fmt.Errorf(eUnknownField, fmt.Sprint((*lexems)[i+2].Value), lexem.Value.(string))
+				}
+				bytecode = append(bytecode, &ByteCode{Cmd: cmdVar, Value: ivar})
+				start := i == *ind
+				i += 2
+				if start && i < len(*lexems)-1 && ((*lexems)[i+1].Type == isEq || (*lexems)[i+1].Type == lexAssign) {
+					i++
+					setField = &ByteCode{Cmd: cmdSetField, Value: &StructFieldInfo{field, ivar}}
+					if (*lexems)[i].Type == lexAssign {
+						bytecode = append(bytecode, &ByteCode{Cmd: cmdVar, Value: ivar}, &ByteCode{Cmd: cmdGetField, Value: &StructFieldInfo{field, ivar}})
+						value := (*lexems)[i].Value.(uint32)
+						oper := opers[assignOpers[value]]
+						fieldOper = &ByteCode{Cmd: oper.Cmd, Value: oper.Priority}
+						fieldStart = len(bytecode)
+						if value == isInc || value == isDec {
+							if i < len(*lexems)-1 && (*lexems)[i+1].Type != lexNewLine && (*lexems)[i+1].Type != isRCurly {
+								logger.WithFields(log.Fields{"type": consts.ParseError}).Error("unexpected expression after increment")
+								return fmt.Errorf(`unexpected expression after increment`)
+							}
+							bytecode = append(bytecode, &ByteCode{Cmd: cmdPush, Value: int64(1)})
+						}
+					} else {
+						fieldStart = len(bytecode)
+					}
+					continue main
+				}
+				bytecode = append(bytecode, &ByteCode{Cmd: cmdGetField, Value: &StructFieldInfo{field, ivar}})
+				if i < len(*lexems)-1 && (*lexems)[i+1].Type == isLBrack {
+					buffer = append(buffer, &ByteCode{Cmd: cmdIndex, Value: (*IndexInfo)(nil)})
+				}
+				continue main
+			}
 			if i < len(*lexems)-2 {
 				if (*lexems)[i+1].Type == isLPar {
 					var isContract bool
@@ -1099,7 +1285,7 @@ main:
 					if (*lexems)[i+2].Type != isRPar {
 						count++
 					}
-					buffer = append(buffer, &ByteCode{cmdCall, objInfo})
+					buffer = append(buffer, &ByteCode{Cmd: cmdCall, Value: objInfo})
 					if isContract {
 						name := StateName((*block)[0].Info.(uint32), lexem.Value.(string))
 						for j := len(*block) - 1; j >= 0; j-- {
@@ -1114,17 +1300,17 @@ main:
 								topblock.Info.(*ContractInfo).Used[name] = true
 							}
 						}
-						bytecode = append(bytecode, &ByteCode{cmdPush, name})
+						bytecode = append(bytecode, &ByteCode{Cmd: cmdPush, Value: name})
 						if count == 0 {
 							count = 2
-							bytecode = append(bytecode, &ByteCode{cmdPush, ""})
-							bytecode = append(bytecode, &ByteCode{cmdPush, ""})
+							bytecode = append(bytecode, &ByteCode{Cmd: cmdPush, Value: ""})
+							bytecode = append(bytecode, &ByteCode{Cmd: cmdPush, Value: ""})
 						}
 						count++
 					}
 					if lexem.Value.(string) == `CallContract` {
 						count++
-						bytecode = append(bytecode, &ByteCode{cmdPush, (*block)[0].Info.(uint32)})
+						bytecode = append(bytecode, &ByteCode{Cmd: cmdPush, Value: (*block)[0].Info.(uint32)})
 					}
 					parcount = append(parcount, count)
 					call = true
@@ -1134,16 +1320,16 @@ main:
 						logger.WithFields(log.Fields{"lex_value": lexem.Value.(string), "type": consts.ParseError}).Error("unknown variable")
 						return fmt.Errorf(`unknown variable %s`, lexem.Value.(string))
 					}
-					buffer = append(buffer, &ByteCode{cmdIndex, &IndexInfo{objInfo.Value.(int), tobj, ``}})
+					buffer = append(buffer, &ByteCode{Cmd: cmdIndex, Value: &IndexInfo{objInfo.Value.(int), tobj, ``}})
 				}
 			}
 			if !call {
-				cmd = &ByteCode{cmdVar, &VarInfo{objInfo, tobj}}
+				cmd = &ByteCode{Cmd: cmdVar, Value: &VarInfo{objInfo, tobj}}
 			}
 		}
 		if lexem.Type&0xff == lexKeyword {
 			if lexem.Value.(uint32) == keyTail {
-				cmd = &ByteCode{cmdUnwrapArr, 0}
+				cmd = &ByteCode{Cmd: cmdUnwrapArr, Value: 0}
 			}
 		}
 		if cmd != nil {
@@ -1156,10 +1342,28 @@ main:
 			log.WithFields(log.Fields{"type": consts.ParseError}).Error("there is not pair")
 			return fmt.Errorf(`there is not pair`)
 		}
-		bytecode = append(bytecode, buffer[i])
+		if err := flushOper(buffer[i]); err != nil {
+			return err
+		}
 	}
 	if setIndex {
-		bytecode = append(bytecode, &ByteCode{cmdSetIndex, indexInfo})
+		bytecode = append(bytecode, &ByteCode{Cmd: cmdSetIndex, Value: indexInfo})
+	}
+	if setField != nil {
+		if fieldStart == len(bytecode) {
+			log.WithFields(log.Fields{"type": consts.ParseError}).Error("there is not eval expression")
+			return fmt.Errorf(`there is not eval expression`)
+		}
+		if fieldOper != nil {
+			bytecode = append(bytecode, fieldOper)
+		}
+		bytecode = append(bytecode, setField)
+	}
+	marked = 0
+	setLine()
+	if len(bytecode) > 0 {
+		curBlock.Lines = append(curBlock.Lines, SourcePos{Offset: len(curBlock.Code), Line: start.Line,
+			Column: start.Column})
 	}
 	curBlock.Code = append(curBlock.Code, bytecode...)
 	return nil
@@ -426,6 +426,110 @@ func TestVMCompile(t *testing.T) {
 				m1 = 1.2
 				return Sprintf( "Account %v %v %v", my2/Money(3),  my2 - Money(5.6), m1*Money(5) + Money(my2))
 			}`, `money_test`, `Account 33 95 105`},
+		{`func opers_test string {
+				var i, j int
+				var f float
+				var m money
+				var s string
+				i = 17 % 5 + (6 & 3) + (6 | 3) + (6 ^ 3) + (1 << 4) + (256 >> 2) + ~7
+				j = 10
+				j += 5
+				j -= 3
+				j *= 2
+				j /= 4
+				j %= 4
+				j <<= 3
+				j |= 1
+				j &= 25
+				j ^= 3
+				j >>= 1
+				j++
+				j++
+				j--
+				f = 7.5 % 2
+				m = Money(1000) % Money(300)
+				s = "a"
+				s += "b"
+				$ext = 5
+				$ext += 2
+				return Sprintf("%d %d %v %v %s %d", i, j, f, m, s, $ext)
+			}`, `opers_test`, `88 10 1.5 100 ab 7`},
+		{`func result string {
+				var a, b int
+				var m map
+				a = 5
+				b = a--1
+				m["name"] = "test"
+				return f"{a} {b} {a---1} {a * 2.5 + 0.25} {Money(a)}{{}} {m[\"name\"]} {m} {f\"<{a + 1}>\"}"
+			}`, `result`, `5 6 4 12.75 5{} test map[name:test] <6>`},
+		{`func ternary(i int) string {
+				return i > 10 ? "big" : i > 5 ? "middle" : i == 0 ? "zero" : "small"
+			}
+			func result string {
+				var i int
+				i = 0
+				return Sprintf("%s %s %s %s %d %d %s", ternary(20), ternary(7), ternary(3), ternary(0),
+					i != 0 ? 10 / i : -1, true ? false ? 1 : 2 : 3, ternary(i + 1 > 0 ? 11 : 0))
+			}`, `result`, `big middle small zero -1 2 big`},
+		{`func result string {
+				var i int
+				i = true ? 1 
+				return "ok"
+			}`, `result`, `wrong ternary operator`},
+		{`func result string {
+				var i, j int
+				i, j += 1
+				return "ok"
+			}`, `result`, `compound assignment requires one variable`},
+		{`func result string {
+				return Sprintf("%d", 1 << -1)
+			}`, `result`, `negative shift count`},
+		{`func result string {
+				return Sprintf("%d", 1.5 & 1)
+			}`, `result`, `unsupported combination of types in the operator`},
+		{`type Invoice {
+				id int; amount money
+				memo, tag string
+			}
+			func total(inv Invoice) money {
+				return inv.amount + inv.amount
+			}
+			func result string {
+				var inv Invoice
+				var m map
+				var type string
+				inv.id = 7
+				inv.amount = "150"
+				inv.memo = "test"
+				inv.id += 3
+				inv.id++
+				m = inv
+				m["amount"] = 5
+				m["extra"] = 1
+				inv = m
+				type = "menu"
+				type Point { x, y float }
+				var p Point
+				p.x = 2
+				p.y = p.x * 1.5
+				return Sprintf("%d %v %s %v %v %v %s %v", inv.id, inv.amount, inv.memo, total(inv), m["id"], p.y, type, p)
+			}`, `result`, `11 5 test 10 11 3 menu map[x:2 y:3]`},
+		{`type Point { x, y int }
+			func result string {
+				var p Point
+				p.z = 1
+				return "ok"
+			}`, `result`, `unknown field z in p`},
+		{`func result string {
+				type Point { x, y int }
+				var p Point
+				p.x = true
+				return "ok"
+			}`, `result`, `bool cannot be assigned to the field of int64 type`},
+		{`func result string {
+				type Point { x int; x string }
+				return "ok"
+			}`, `result`, `duplicate field x in type Point`},
 		{`func long() int {
 				return  99999999999999999999
 				}
@@ -0,0 +1,316 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package script
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	debugRun = iota
+	debugPause
+	debugNext
+	debugStepIn
+	debugStepOut
+	debugTerminate
+	debugStopped
+)
+
+const (
+	// StopBreakpoint is the reason of the stop at the breakpoint
+	StopBreakpoint = `breakpoint`
+	// StopStep is the reason of the stop after stepping
+	StopStep = `step`
+	// StopPause is the reason of the stop after the pause request
+	StopPause = `pause`
+)
+
+var errDebugTerminated = errors.New(`terminated by debugger`)
+
+// DebugFrame is the frame of the call stack of the stopped execution
+type DebugFrame struct {
+	Name   string // the name of the function, the functions of contracts are named like Contract.action
+	Source string // the name of the top-level contract or function, breakpoints are set for it
+	Line   uint32
+	Column uint32
+	Vars   map[string]interface{}
+}
+
+// DebugState describes the stopped execution
+type DebugState struct {
+	Reason string
+	Frames []*DebugFrame // the innermost frame is the first
+	Extend map[string]interface{}
+}
+
+// Debugger stops the execution of contracts at breakpoints and steps through the statements.
+// It is supposed that one contract is executed at the same time
+type Debugger struct {
+	// OnStop is called when the execution has been stopped, the execution waits for
+	// Continue, Next, StepIn, StepOut or Terminate
+	OnStop func(state *DebugState)
+
+	mutex       sync.Mutex
+	mode        int
+	depth       int // the count of frames when the stepping was started
+	breakpoints map[string]map[uint32]bool
+	runtimes    []*RunTime
+	names       map[*Block]string
+	state       *DebugState
+	resume      chan int
+}
+
+// NewDebugger returns the new debugger
+func NewDebugger() *Debugger {
+	return &Debugger{
+		breakpoints: make(map[string]map[uint32]bool),
+		names:       make(map[*Block]string),
+		resume:      make(chan int, 1),
+	}
+}
+
+// SetBreakpoints replaces breakpoints of the specified contracts and functions with lines
+func (dbg *Debugger) SetBreakpoints(sources []string, lines []uint32) {
+	dbg.mutex.Lock()
+	defer dbg.mutex.Unlock()
+	for _, source := range sources {
+		delete(dbg.breakpoints, source)
+		if len(lines) == 0 {
+			continue
+		}
+		bp := make(map[uint32]bool)
+		for _, line := range lines {
+			bp[line] = true
+		}
+		dbg.breakpoints[source] = bp
+	}
+}
+
+// State returns the state of the stopped execution or nil if the execution is running
+func (dbg *Debugger) State() *DebugState {
+	dbg.mutex.Lock()
+	defer dbg.mutex.Unlock()
+	return dbg.state
+}
+
+// Continue resumes the execution until the next breakpoint
+func (dbg *Debugger) Continue() {
+	dbg.setMode(debugRun)
+}
+
+// Next resumes the execution until the next statement of the current function
+func (dbg *Debugger) Next() {
+	dbg.setMode(debugNext)
+}
+
+// StepIn resumes the execution until the next statement including called functions
+func (dbg *Debugger) StepIn() {
+	dbg.setMode(debugStepIn)
+}
+
+// StepOut resumes the execution until the return from the current function
+func (dbg *Debugger) StepOut() {
+	dbg.setMode(debugStepOut)
+}
+
+// Pause stops the running execution at the next statement
+func (dbg *Debugger) Pause() {
+	dbg.setMode(debugPause)
+}
+
+// Terminate breaks the execution with an error
+func (dbg *Debugger) Terminate() {
+	dbg.setMode(debugTerminate)
+}
+
+func (dbg *Debugger) setMode(mode int) {
+	dbg.mutex.Lock()
+	defer dbg.mutex.Unlock()
+	if dbg.mode != debugStopped {
+		if mode == debugPause || mode == debugTerminate || mode == debugRun {
+			dbg.mode = mode
+		}
+		return
+	}
+	dbg.mode = mode
+	dbg.depth = len(dbg.state.Frames)
+	dbg.state = nil
+	dbg.resume <- mode
+}
+
+func (dbg *Debugger) enter(rt *RunTime) {
+	dbg.mutex.Lock()
+	dbg.runtimes = append(dbg.runtimes, rt)
+	dbg.mutex.Unlock()
+}
+
+func (dbg *Debugger) leave(rt *RunTime) {
+	dbg.mutex.Lock()
+	defer dbg.mutex.Unlock()
+	for i := len(dbg.runtimes) - 1; i >= 0; i-- {
+		if dbg.runtimes[i] == rt {
+			dbg.runtimes = append(dbg.runtimes[:i], dbg.runtimes[i+1:]...)
+			break
+		}
+	}
+	if len(dbg.runtimes) == 0 && dbg.mode == debugTerminate {
+		dbg.mode = debugRun
+	}
+}
+
+// statement returns the position of the statement which starts from the command with the specified index
+func (block *Block) statement(ci int) *SourcePos {
+	i := sort.Search(len(block.Lines), func(i int) bool { return block.Lines[i].Offset >= ci })
+	if i < len(block.Lines) && block.Lines[i].Offset == ci {
+		return &block.Lines[i]
+	}
+	return nil
+}
+
+// check is called before each command and stops the execution at the beginning of statements
+// if it is required
+func (dbg *Debugger) check(rt *RunTime, block *Block, ci int) error {
+	pos := block.statement(ci)
+	if pos == nil {
+		return nil
+	}
+	rt.blocks[len(rt.blocks)-1].pos = pos
+
+	dbg.mutex.Lock()
+	var reason string
+	switch dbg.mode {
+	case debugTerminate:
+		dbg.mutex.Unlock()
+		return errDebugTerminated
+	case debugPause:
+		reason = StopPause
+	case debugStepIn:
+		reason = StopStep
+	case debugNext:
+		if dbg.frameCount() <= dbg.depth {
+			reason = StopStep
+		}
+	case debugStepOut:
+		if dbg.frameCount() < dbg.depth {
+			reason = StopStep
+		}
+	}
+	if len(reason) == 0 {
+		if bp, ok := dbg.breakpoints[dbg.blockName(topBlock(block))]; ok && bp[pos.Line] {
+			reason = StopBreakpoint
+		}
+	}
+	if len(reason) == 0 {
+		dbg.mutex.Unlock()
+		return nil
+	}
+	dbg.mode = debugStopped
+	dbg.state = dbg.getState(reason)
+	state, onStop := dbg.state, dbg.OnStop
+	dbg.mutex.Unlock()
+
+	if onStop != nil {
+		onStop(state)
+	}
+	if mode := <-dbg.resume; mode == debugTerminate {
+		return errDebugTerminated
+	}
+	return nil
+}
+
+// frameCount returns the count of the called functions in all runtimes
+func (dbg *Debugger) frameCount() (count int) {
+	for _, rt := range dbg.runtimes {
+		for _, item := range rt.blocks {
+			if item.Block.Type == ObjFunc {
+				count++
+			}
+		}
+	}
+	return
+}
+
+// topBlock returns the contract or the function which is defined at the top level
+func topBlock(block *Block) *Block {
+	for block.Parent != nil && block.Parent.Parent != nil {
+		block = block.Parent
+	}
+	return block
+}
+
+// blockName returns the name of the contract or the function
+func (dbg *Debugger) blockName(block *Block) string {
+	if name, ok := dbg.names[block]; ok {
+		return name
+	}
+	name := funcName(block)
+	dbg.names[block] = name
+	return name
+}
+
+// getState collects the call stack with the values of variables
+func (dbg *Debugger) getState(reason string) *DebugState {
+	state := &DebugState{Reason: reason, Extend: make(map[string]interface{})}
+	for _, rt := range dbg.runtimes {
+		var frame *DebugFrame
+		for _, item := range rt.blocks {
+			if item.Block.Type == ObjFunc || frame == nil {
+				frame = &DebugFrame{
+					Name:   dbg.blockName(item.Block),
+					Source: dbg.blockName(topBlock(item.Block)),
+					Vars:   make(map[string]interface{}),
+				}
+				state.Frames = append([]*DebugFrame{frame}, state.Frames...)
+			}
+			if item.pos != nil {
+				frame.Line, frame.Column = item.pos.Line, item.pos.Column
+			}
+			for name, obj := range item.Block.Objects {
+				if obj.Type != ObjVar || item.Offset+obj.Value.(int) >= len(rt.vars) {
+					continue
+				}
+				frame.Vars[name] = debugValue(rt.vars[item.Offset+obj.Value.(int)])
+			}
+		}
+		if rt.extend == nil {
+			continue
+		}
+		for key, val := range *rt.extend {
+			if key == `sc` || key == `contract` || strings.HasPrefix(key, `rt_`) {
+				continue
+			}
+			state.Extend[key] = debugValue(val)
+		}
+	}
+	return state
+}
+
+func debugValue(v interface{}) interface{} {
+	if v != nil && isStructType(reflect.TypeOf(v)) {
+		return structToMap(v)
+	}
+	return v
+}
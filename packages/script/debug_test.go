@@ -0,0 +1,89 @@
+package script
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugger(t *testing.T) {
+	vm := NewVM()
+	err := vm.Compile([]rune(`func double(a int) int {
+	var r int
+	r = a * 2
+	return r
+}
+func debugged() int {
+	var x int
+	x = 5
+	x = double(x)
+	return x + 1
+}`), &OwnerInfo{StateID: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	dbg := NewDebugger()
+	stops := make(chan *DebugState, 1)
+	dbg.OnStop = func(state *DebugState) {
+		stops <- state
+	}
+	dbg.SetBreakpoints([]string{`debugged`}, []uint32{9})
+	vm.Debugger = dbg
+
+	type result struct {
+		out []interface{}
+		err error
+	}
+	done := make(chan result)
+	run := func() {
+		out, err := vm.Call(`debugged`, nil, &map[string]interface{}{`rt_state`: uint32(1)})
+		done <- result{out, err}
+	}
+	go run()
+
+	state := <-stops
+	assert.Equal(t, StopBreakpoint, state.Reason)
+	assert.Len(t, state.Frames, 1)
+	assert.Equal(t, `debugged`, state.Frames[0].Name)
+	assert.Equal(t, uint32(9), state.Frames[0].Line)
+	assert.Equal(t, int64(5), state.Frames[0].Vars[`x`])
+
+	dbg.StepIn()
+	state = <-stops
+	assert.Equal(t, StopStep, state.Reason)
+	if assert.Len(t, state.Frames, 2) {
+		assert.Equal(t, `double`, state.Frames[0].Name)
+		assert.Equal(t, uint32(3), state.Frames[0].Line)
+		assert.Equal(t, int64(5), state.Frames[0].Vars[`a`])
+		assert.Equal(t, uint32(9), state.Frames[1].Line)
+	}
+
+	dbg.Next()
+	state = <-stops
+	assert.Equal(t, uint32(4), state.Frames[0].Line)
+	assert.Equal(t, int64(10), state.Frames[0].Vars[`r`])
+
+	dbg.StepOut()
+	state = <-stops
+	assert.Len(t, state.Frames, 1)
+	assert.Equal(t, uint32(10), state.Frames[0].Line)
+	assert.Equal(t, int64(10), state.Frames[0].Vars[`x`])
+
+	dbg.Continue()
+	res := <-done
+	if assert.NoError(t, res.err) {
+		assert.Equal(t, []interface{}{int64(11)}, res.out)
+	}
+	assert.Nil(t, dbg.State())
+
+	go run()
+	<-stops
+	dbg.Terminate()
+	res = <-done
+	assert.EqualError(t, res.err, errDebugTerminated.Error())
+
+	dbg.SetBreakpoints([]string{`debugged`}, nil)
+	go run()
+	res = <-done
+	assert.NoError(t, res.err)
+}
@@ -32,6 +32,13 @@ const (
 	eWrongParams     = `function %s must have %d parameters`
 	eArrIndex        = `index of array cannot be type %s`
 	eMapIndex        = `index of map cannot be type %s`
+	eTypeDeclared    = `type %s has already been declared`
+	eDuplicateField  = `duplicate field %s in type %s`
+	eEmptyType       = `type %s must have fields`
+	eUnknownField    = `unknown field %s in %s`
+	eStructValue     = `%s cannot be converted to the type`
+	eFieldType       = `%s cannot be assigned to the field of %s type`
+	eFuelLimit       = `fuel limit %d of %s contract is over`
 )
 
 var (
@@ -42,4 +49,9 @@ var (
 	errMaxArrayIndex   = errors.New(`The index is out of range`)
 	errMaxMapCount     = errors.New(`The maxumim length of map`)
 	errRecursion       = errors.New(`The contract can't call itself recursively`)
+	errTernary         = errors.New(`wrong ternary operator`)
+	errShift           = errors.New(`negative shift count`)
+	errFieldIndex      = errors.New(`the field cannot be changed by index`)
+	errNotStruct       = errors.New(`the value is not a structure`)
+	errFuelLimit       = errors.New(`fuel limit must be a positive integer`)
 )
@@ -50,6 +50,7 @@ const (
 	lexKeyword            // Key word
 	lexType               // Name of the type
 	lexExtend             // Referring to an external variable or function - $myname
+	lexAssign             // Compound assignment +=, -=, ++ and so on
 
 	lexError = 0xff
 	// flags of lexical states
@@ -75,6 +76,7 @@ const (
 	isPlus     = 0x002b // +
 	isMinus    = 0x002d // -
 	isSign     = 0x012d // - unary
+	isToStr    = 0x0122 // conversion to string in the interpolated string
 	isSolidus  = 0x002f // /
 	isLess     = 0x003c // <
 	isGreat    = 0x003e // >
@@ -84,7 +86,29 @@ const (
 	isEqEq     = 0x3d3d // ==
 	isGrEq     = 0x3e3d // >=
 	isOr       = 0x7c7c // ||
+	isPercent  = 0x0025 // %
+	isBitAnd   = 0x0026 // &
+	isColon    = 0x003a // :
+	isQuestion = 0x003f // ?
+	isBitXor   = 0x005e // ^
+	isBitOr    = 0x007c // |
+	isBitNot   = 0x007e // ~
+	isShiftL   = 0x3c3c // <<
+	isShiftR   = 0x3e3e // >>
 
+	// Constants for compound assignments
+	isModEq    = 0x253d   // %=
+	isAndEq    = 0x263d   // &=
+	isMulEq    = 0x2a3d   // *=
+	isInc      = 0x2b2b   // ++
+	isAddEq    = 0x2b3d   // +=
+	isDec      = 0x2d2d   // --
+	isSubEq    = 0x2d3d   // -=
+	isDivEq    = 0x2f3d   // /=
+	isXorEq    = 0x5e3d   // ^=
+	isOrEq     = 0x7c3d   // |=
+	isShiftLEq = 0x3c3c3d // <<=
+	isShiftREq = 0x3e3e3d // >>=
 )
 
 const (
@@ -159,6 +183,89 @@ type ifBuf struct {
 // Lexems is a slice of lexems
 type Lexems []*Lexem
 
+// isInterpolation returns true if the string at the position follows f like f"text {expr}"
+func isInterpolation(lexems Lexems, line, column uint32) bool {
+	if len(lexems) == 0 {
+		return false
+	}
+	prev := lexems[len(lexems)-1]
+	return prev.Type == lexIdent && prev.Value == `f` && prev.Line == line && prev.Column+1 == column
+}
+
+// interpolate returns the lexems of the concatenation of text and expressions of the interpolated string.
+// The values of expressions are converted to strings, {{ and }} mean the braces in the text
+func interpolate(value string, line, column uint32) (Lexems, error) {
+	newLexem := func(lexType uint32, val interface{}) *Lexem {
+		return &Lexem{lexType, val, line, column}
+	}
+	sysLexem := func(ch rune) *Lexem {
+		return newLexem(lexSys|(uint32(ch)<<8), uint32(ch))
+	}
+	input := []rune(value)
+	lexems := Lexems{sysLexem('(')}
+	text := make([]rune, 0, len(input))
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+		if ch != '{' && ch != '}' {
+			text = append(text, ch)
+			continue
+		}
+		if i+1 < len(input) && input[i+1] == ch {
+			text = append(text, ch)
+			i++
+			continue
+		}
+		if ch == '}' {
+			return nil, fmt.Errorf(`unexpected } in string [Ln:%d Col:%d]`, line, column)
+		}
+		end, depth := i+1, 1
+		for ; end < len(input); end++ {
+			if input[end] == '{' {
+				depth++
+			} else if input[end] == '}' {
+				if depth--; depth == 0 {
+					break
+				}
+			}
+		}
+		if end == len(input) {
+			return nil, fmt.Errorf(`unclosed { in string [Ln:%d Col:%d]`, line, column)
+		}
+		expr, err := lexParser(input[i+1 : end])
+		if err != nil {
+			return nil, err
+		}
+		if len(expr) == 0 {
+			return nil, fmt.Errorf(`empty expression in string [Ln:%d Col:%d]`, line, column)
+		}
+		for _, item := range expr {
+			item.Line, item.Column = line, column
+		}
+		lexems = append(lexems, newLexem(lexString, string(text)), newLexem(lexOper, uint32(isPlus)),
+			newLexem(lexOper, uint32(isToStr)), sysLexem('('))
+		lexems = append(append(lexems, expr...), sysLexem(')'), newLexem(lexOper, uint32(isPlus)))
+		text = text[:0]
+		i = end
+	}
+	return append(lexems, newLexem(lexString, string(text)), sysLexem(')')), nil
+}
+
+// isStatementEnd returns true if there is only the end of the statement or a comment after off
+func isStatementEnd(input []rune, off uint32) bool {
+	for ; off < uint32(len(input)); off++ {
+		switch input[off] {
+		case ' ', '\t':
+			continue
+		case '\n', '\r', ';', '}':
+			return true
+		case '/':
+			return off+1 < uint32(len(input)) && (input[off+1] == '/' || input[off+1] == '*')
+		}
+		return false
+	}
+	return true
+}
+
 // The lexical analysis is based on the finite machine which is described in the file
 // tools/lextable/lextable.go. lextable.go generates a representation of a finite machine as an array
 // and records it in the file lex_table.go. In fact, the lexTable array is a set of states and
@@ -261,15 +368,34 @@ func lexParser(input []rune) (Lexems, error) {
 					value = strings.Replace(value.(string), `\"`, `"`, -1)
 					value = strings.Replace(strings.Replace(value.(string), `\r`, "\r", -1), `\n`, "\n", -1)
 				}
+				strLine, strColumn := line, lexOff-offline+1
 				for i, ch := range value.(string) {
 					if ch == 0xa {
 						line++
 						offline = off + uint32(i) + 1
 					}
 				}
+				if lexID == lexString && isInterpolation(lexems, strLine, strColumn) {
+					parts, err := interpolate(value.(string), strLine, strColumn)
+					if err != nil {
+						return nil, err
+					}
+					lexems = append(lexems[:len(lexems)-1], parts...)
+					lexID = lexComment
+				}
 			case lexOper:
 				oper := []byte(string(input[lexOff:right]))
 				value = binary.BigEndian.Uint32(append(make([]byte, 4-len(oper)), oper...))
+				if _, ok := assignOpers[value.(uint32)]; ok {
+					lexID = lexAssign
+				}
+				if (value == uint32(isInc) || value == uint32(isDec)) && !isStatementEnd(input, right) {
+					// a--1 means a - (-1) so ++ and -- are the increment and decrement only at the end of the statement
+					value = value.(uint32) & 0xff
+					lexID = lexOper
+					lexems = append(lexems, &Lexem{lexID, value, line, lexOff - offline + 1})
+					lexOff++
+				}
 			case lexNumber:
 				name := string(input[lexOff:right])
 				if strings.ContainsAny(name, `.`) {
@@ -52,7 +52,15 @@ func TestLexParser(t *testing.T) {
 		{`!ab < !b && 12>=56 && qwe!=asd`, `[2 33][4 ab][2 60][2 33][4 b][2 9766][3 12][2 15933][3 56][2 9766][4 qwe][2 8509][4 asd]`},
 		{`ab || 12 && 56`, `[4 ab][2 31868][3 12][2 9766][3 56]`},
 		{"12 /*rue \n weweswe*/ 42", `[3 12][3 42]`},
-		{`true | 42`, `unknown lexem   [Ln:1 Col:7]`},
+		{`true | 42`, `[3 true][2 124][3 42]`},
+		{`a % b & c | d ^ ~e << 2 >> 1`, `[4 a][2 37][4 b][2 38][4 c][2 124][4 d][2 94][2 126][4 e][2 15420][3 2][2 15934][3 1]`},
+		{`a ? b : c`, `[4 a][2 63][4 b][2 58][4 c]`},
+		{`a += 1 b++; c-- } d <<= 2 e/=f`, `[4 a][11 11069][3 1][4 b][11 11051][5 <nil>][4 c][11 11565][32001 125][4 d][11 3947581][3 2][4 e][11 12093][4 f]`},
+		{`a-- // comment`, `[4 a][11 11565]`},
+		{`a--1 b++c d---e`, `[4 a][2 45][2 45][3 1][4 b][2 43][2 43][4 c][4 d][2 45][2 45][2 45][4 e]`},
+		{`f"a{b}c{{}}"`, `[10241 40][6 a][2 43][2 290][10241 40][4 b][10497 41][2 43][6 c{}][10497 41]`},
+		{`f "{b}" f"{}"`, `empty expression in string [Ln:1 Col:10]`},
+		{"f`{a}}`", `unexpected } in string [Ln:1 Col:2]`},
 		{"(\r\n)\x03 -", "unknown lexem  [Ln:2 Col:3]"},
 		{` +( - )	/ + // edeld lklm  3edwd`, `[2 43][10241 40][2 45][10497 41][2 47][2 43]`},
 		{`23+13424 * 1000.01 Тест`, `[3 23][2 43][3 13424][2 42][3 1000.01][4 Тест]`},
@@ -38,7 +38,7 @@ type States map[string]Action
 
 const (
 	// AlphaSize is the length of alphabet
-	AlphaSize = 33
+	AlphaSize = 38
 )
 
 /* Здесь мы определяем алфавит, с которым будет работать наш язык и описываем конечный автомат, который
@@ -55,7 +55,7 @@ var (
 	alphabet = []byte{0x01, 0x0a, ' ', '`', '"', ';', '(', ')', '[', ']', '{', '}', '&',
 		//           default  n    s    q    Q
 		'|', '#', '.', ',', '<', '>', '=', '!', '*', '$', '@',
-		'+', '-', '/', '\\', '0', '1', 'a', '_', 128}
+		'+', '-', '/', '\\', '%', '^', '?', ':', '~', '0', '1', 'a', '_', 128}
 	//													r
 
 	// В states мы обозначили за d - все символы, которые не указаны в состоянии
@@ -83,8 +83,12 @@ var (
 			"|": ["or", "", "push next"],
 			"=": ["eq", "", "push next"],
 			"/": ["solidus", "", "push next"],
-			"<>!": ["oneq", "", "push next"],
-			"*+-": ["main", "oper", "next"],
+			"<": ["less", "", "push next"],
+			">": ["great", "", "push next"],
+			"!*%^": ["oneq", "", "push next"],
+			"+": ["plus", "", "push next"],
+			"-": ["minus", "", "push next"],
+			"?:~": ["main", "oper", "next"],
 			"01": ["number", "", "push next"],
 			"a_r": ["ident", "", "push next"],
 			"@$": ["mustident", "", "push next"],
@@ -113,12 +117,30 @@ var (
 		"d": ["error", "", ""]
 	},
 	"and": {
-			"&": ["main", "oper", "pop next"],
-			"d": ["error", "", ""]
+			"&=": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
 		},
 	"or": {
-			"|": ["main", "oper", "pop next"],
-			"d": ["error", "", ""]
+			"|=": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
+		},
+	"plus": {
+			"+=": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
+		},
+	"minus": {
+			"-=": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
+		},
+	"less": {
+			"<": ["oneq", "", "next"],
+			"=": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
+		},
+	"great": {
+			">": ["oneq", "", "next"],
+			"=": ["main", "oper", "pop next"],
+			"d": ["main", "oper", "pop"]
 		},
 	"eq": {
 			"=": ["main", "oper", "pop next"],
@@ -127,6 +149,7 @@ var (
 	"solidus": {
 			"/": ["comline", "", "pop next"],
 			"*": ["comment", "", "next"],
+			"=": ["main", "oper", "pop next"],
 			"d": ["main", "oper", "pop"]
 		},
 	"oneq": {
@@ -0,0 +1,252 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package script
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+// User-defined types are declared as
+//  type Invoice {
+//	  id int
+//	  amount money; memo string
+//  }
+// The type is compiled into the pointer to the go structure with the fields F0, F1... which
+// have the tag `script:"name"`. Variables of the type are checked at the compile time and
+// can be converted to and from map.
+
+const tagStruct = `script`
+
+// isTypeDecl returns true if the lexems start with the declaration of the type. 'type' is not a keyword
+// so it can be used as an identifier in other cases
+func isTypeDecl(lexems Lexems, i int) bool {
+	return i+2 < len(lexems) && lexems[i].Type == lexIdent && lexems[i].Value.(string) == `type` &&
+		lexems[i+1].Type == lexIdent && lexems[i+2].Type == isLCurly
+}
+
+// compileStruct compiles the declaration of the type and adds it to the objects of the current block
+func (vm *VM) compileStruct(lexems *Lexems, ind *int, block *[]*Block) error {
+	i := *ind + 1
+	top := (*block)[len(*block)-1]
+	name := (*lexems)[i].Value.(string)
+	if len(*block) == 1 {
+		name = StateName(top.Info.(uint32), name)
+	}
+	if top.Objects == nil {
+		top.Objects = make(map[string]*ObjInfo)
+	}
+	if _, ok := top.Objects[name]; ok {
+		(*lexems)[i].GetLogger().WithFields(log.Fields{"type": consts.ParseError, "lex_value": name}).Error("type has already been declared")
+		return fmt.Errorf(eTypeDeclared, name)
+	}
+	var (
+		fields  []reflect.StructField
+		pending int
+	)
+	names := make(map[string]bool)
+	for i += 2; i < len(*lexems); i++ {
+		lexem := (*lexems)[i]
+		switch lexem.Type {
+		case lexNewLine, isComma:
+		case lexIdent:
+			field := lexem.Value.(string)
+			if names[field] {
+				lexem.GetLogger().WithFields(log.Fields{"type": consts.ParseError, "lex_value": field}).Error("duplicate field")
+				return fmt.Errorf(eDuplicateField, field, name)
+			}
+			names[field] = true
+			fields = append(fields, reflect.StructField{Name: fmt.Sprintf(`F%d`, len(fields)),
+				Tag: reflect.StructTag(fmt.Sprintf(`%s:"%s"`, tagStruct, field))})
+			pending++
+		case lexType:
+			if pending == 0 {
+				return fError(block, errMustName, lexem)
+			}
+			for k := len(fields) - pending; k < len(fields); k++ {
+				fields[k].Type = lexem.Value.(reflect.Type)
+			}
+			pending = 0
+		case isRCurly:
+			if pending > 0 {
+				return fError(block, errVarType, lexem)
+			}
+			if len(fields) == 0 {
+				lexem.GetLogger().WithFields(log.Fields{"type": consts.ParseError, "lex_value": name}).Error("type without fields")
+				return fmt.Errorf(eEmptyType, name)
+			}
+			top.Objects[name] = &ObjInfo{Type: ObjStruct, Value: reflect.PtrTo(reflect.StructOf(fields))}
+			*ind = i
+			return nil
+		default:
+			if pending > 0 {
+				return fError(block, errVarType, lexem)
+			}
+			return fError(block, errMustRCurly, lexem)
+		}
+	}
+	return fError(block, errMustRCurly, (*lexems)[len(*lexems)-1])
+}
+
+// isStructType returns true if t is the user-defined type
+func isStructType(t reflect.Type) bool {
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct || t.Elem().NumField() == 0 {
+		return false
+	}
+	_, ok := t.Elem().Field(0).Tag.Lookup(tagStruct)
+	return ok
+}
+
+// fieldIndex returns the index of the field of the user-defined type or -1 if there is not such field
+func fieldIndex(t reflect.Type, name string) int {
+	for i := 0; i < t.Elem().NumField(); i++ {
+		if t.Elem().Field(i).Tag.Get(tagStruct) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// structToMap converts the value of the user-defined type to map
+func structToMap(v interface{}) map[string]interface{} {
+	rv := reflect.ValueOf(v).Elem()
+	ret := make(map[string]interface{}, rv.NumField())
+	for i := 0; i < rv.NumField(); i++ {
+		ret[rv.Type().Field(i).Tag.Get(tagStruct)] = rv.Field(i).Interface()
+	}
+	return ret
+}
+
+// valueToStruct converts map to the value of the user-defined type. The keys which are not the fields
+// are ignored and the missing fields have zero values
+func valueToStruct(t reflect.Type, v interface{}) (interface{}, error) {
+	if v != nil && reflect.TypeOf(v) == t {
+		return v, nil
+	}
+	ret := reflect.New(t.Elem())
+	var get func(string) (interface{}, bool)
+	switch val := v.(type) {
+	case nil:
+		return ret.Interface(), nil
+	case map[string]interface{}:
+		get = func(key string) (item interface{}, ok bool) {
+			item, ok = val[key]
+			return
+		}
+	case map[string]string:
+		get = func(key string) (item interface{}, ok bool) {
+			item, ok = val[key]
+			return
+		}
+	default:
+		return nil, fmt.Errorf(eStructValue, reflect.TypeOf(v).String())
+	}
+	for i := 0; i < t.Elem().NumField(); i++ {
+		name := t.Elem().Field(i).Tag.Get(tagStruct)
+		if item, ok := get(name); ok {
+			field, err := valueToField(t.Elem().Field(i).Type, item)
+			if err != nil {
+				return nil, fmt.Errorf(`%s: %v`, name, err)
+			}
+			ret.Elem().Field(i).Set(field)
+		}
+	}
+	return ret.Interface(), nil
+}
+
+// valueToField converts the value to the type of the field
+func valueToField(t reflect.Type, v interface{}) (ret reflect.Value, err error) {
+	if v == nil {
+		return reflect.Zero(t), nil
+	}
+	if reflect.TypeOf(v) == t {
+		return reflect.ValueOf(v), nil
+	}
+	var value interface{}
+	switch t.String() {
+	case Decimal:
+		switch v.(type) {
+		case int64, float64, string:
+			value, err = ValueToDecimal(v)
+		}
+	case `int64`:
+		switch val := v.(type) {
+		case string:
+			value, err = converter.ValueToInt(val)
+		case float64:
+			value = int64(val)
+		case decimal.Decimal:
+			value = val.IntPart()
+		}
+	case `uint64`:
+		switch val := v.(type) {
+		case int64:
+			value = uint64(val)
+		case string:
+			value, err = strconv.ParseUint(val, 10, 64)
+		}
+	case `float64`:
+		switch val := v.(type) {
+		case int64:
+			value = float64(val)
+		case string:
+			value, err = strconv.ParseFloat(val, 64)
+		case decimal.Decimal:
+			value, _ = val.Float64()
+		}
+	case `string`:
+		switch v.(type) {
+		case int64, float64, decimal.Decimal:
+			value = fmt.Sprint(v)
+		}
+	case `bool`:
+		switch val := v.(type) {
+		case string:
+			value, err = strconv.ParseBool(val)
+		case int64:
+			value = val != 0
+		}
+	case `[]uint8`:
+		if val, ok := v.(string); ok {
+			value = []byte(val)
+		}
+	case `map[string]interface {}`:
+		if isStructType(reflect.TypeOf(v)) {
+			value = structToMap(v)
+		}
+	}
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConversionError, "error": err, "value": v}).Error("converting value to the field")
+		return
+	}
+	if value == nil {
+		return ret, fmt.Errorf(eFieldType, reflect.TypeOf(v).String(), t.String())
+	}
+	return reflect.ValueOf(value), nil
+}
@@ -25,10 +25,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"runtime/debug"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 	"unsafe"
 
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
@@ -75,19 +78,35 @@ var sysVars = map[string]struct{}{
 	`txcost`:            {},
 	`txhash`:            {},
 	`role_id`:           {},
+	FuelLeft:            {},
 }
 
 var ErrMemoryLimit = errors.New("Memory limit exceeded")
 
 // VMError represents error of VM
 type VMError struct {
-	Type  string `json:"type"`
-	Error string `json:"error"`
+	Type  string   `json:"type"`
+	Error string   `json:"error"`
+	Func  string   `json:"func,omitempty"`  // the function where the error has occurred
+	Line  uint32   `json:"line,omitempty"`  // the line of the source code where the error has occurred
+	Stack []string `json:"stack,omitempty"` // the stack of called contracts
+}
+
+// SourceError is the runtime error with the position in the source code where it has occurred
+type SourceError struct {
+	Err  error
+	Func string // the name of the function, the functions of contracts are named like Contract.action
+	Line uint32
+}
+
+func (e *SourceError) Error() string {
+	return e.Err.Error()
 }
 
 type blockStack struct {
 	Block  *Block
 	Offset int
+	pos    *SourcePos // the current statement, it is set only in the debug mode
 }
 
 // RunTime is needed for the execution of the byte-code
@@ -103,6 +122,9 @@ type RunTime struct {
 	callDepth uint16
 	mem       int64
 	memVars   map[interface{}]int64
+	debugger  *Debugger
+	// extendCost is $txcost when the code has been started, it is decreased by the nested calls of VM
+	extendCost int64
 }
 
 func isSysVar(name string) bool {
@@ -223,14 +245,20 @@ func (rt *RunTime) callFunc(cmd uint16, obj *ObjInfo) (err error) {
 				pars[count-i] = reflect.ValueOf((*rt.extend)[finfo.Auto[count-i]])
 				auto--
 			} else {
-				pars[count-i] = reflect.ValueOf(rt.stack[size-i+auto])
+				pars[count-i] = reflect.ValueOf(extValue(rt.stack[size-i+auto]))
 			}
 			if !pars[count-i].IsValid() {
 				pars[count-i] = reflect.Zero(reflect.TypeOf(int64(0)))
 			}
 		}
 		if i > 0 {
+			for k := size - i; k < size; k++ {
+				rt.stack[k] = extValue(rt.stack[k])
+			}
 			pars[in-1] = reflect.ValueOf(rt.stack[size-i : size])
+		} else if finfo.Variadic {
+			// the variadic parameter can be omitted
+			pars[in-1] = reflect.MakeSlice(foo.Type().In(in-1), 0, 0)
 		}
 		if finfo.Name == `ExecContract` && (pars[2].Type().String() != `string` || !pars[3].IsValid()) {
 			return fmt.Errorf(`unknown function %v`, pars[1])
@@ -334,6 +362,15 @@ func calcMem(v interface{}) (mem int64) {
 			mem += calcMem(k.Interface())
 			mem += calcMem(rv.MapIndex(k).Interface())
 		}
+	case reflect.Ptr:
+		if !isStructType(rv.Type()) || rv.IsNil() {
+			mem = int64(unsafe.Sizeof(v))
+			break
+		}
+		mem = 4
+		for i := 0; i < rv.Elem().NumField(); i++ {
+			mem += calcMem(rv.Elem().Field(i).Interface())
+		}
 	default:
 		mem = int64(unsafe.Sizeof(v))
 	}
@@ -341,6 +378,26 @@ func calcMem(v interface{}) (mem int64) {
 	return
 }
 
+// convertVar converts the value to the user-defined type or the value of the user-defined type to map
+// if it is necessary for the variable of the type t
+func convertVar(t reflect.Type, v interface{}) (interface{}, error) {
+	if isStructType(t) {
+		return valueToStruct(t, v)
+	}
+	if t == reflect.TypeOf(map[string]interface{}{}) && v != nil && isStructType(reflect.TypeOf(v)) {
+		return structToMap(v), nil
+	}
+	return v, nil
+}
+
+// extValue converts the values of the user-defined types to map for the extended functions
+func extValue(v interface{}) interface{} {
+	if v != nil && isStructType(reflect.TypeOf(v)) {
+		return structToMap(v)
+	}
+	return v
+}
+
 func (rt *RunTime) setExtendVar(k string, v interface{}) {
 	(*rt.extend)[k] = v
 	rt.recalcMemExtendVar(k)
@@ -403,6 +460,30 @@ func valueToBool(v interface{}) bool {
 	return false
 }
 
+// valueToString converts the value of the expression in the interpolated string
+func valueToString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ``
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// valueToBitInt converts the operand of the bitwise operation to int64
+func valueToBitInt(v interface{}) (int64, error) {
+	switch val := v.(type) {
+	case int64:
+		return val, nil
+	case string:
+		return converter.ValueToInt(val)
+	}
+	return 0, errUnsupportedType
+}
+
 // ValueToFloat converts interface (string, float64 or int64) to float64
 func ValueToFloat(v interface{}) (ret float64) {
 	var err error
@@ -450,20 +531,57 @@ func (rt *RunTime) Cost() int64 {
 	return rt.cost
 }
 
+// FuelLeft returns the fuel which can be spent by the running code. It takes into account
+// the fuel which has been spent by the nested calls of VM
+func (rt *RunTime) FuelLeft() int64 {
+	left := rt.cost
+	if rt.extend != nil {
+		if cost, ok := (*rt.extend)[`txcost`].(int64); ok && cost < rt.extendCost {
+			left -= rt.extendCost - cost
+		}
+	}
+	if left < 0 {
+		left = 0
+	}
+	return left
+}
+
 // RunInit creates a new RunTime for the virtual machine
 func (vm *VM) RunInit(cost int64) *RunTime {
 	rt := RunTime{
-		stack:   make([]interface{}, 0, 1024),
-		vm:      vm,
-		cost:    cost,
-		memVars: make(map[interface{}]int64),
+		stack:    make([]interface{}, 0, 1024),
+		vm:       vm,
+		cost:     cost,
+		memVars:  make(map[interface{}]int64),
+		debugger: vm.Debugger,
 	}
 	return &rt
 }
 
 // SetVMError sets error of VM
 func SetVMError(eType string, eText interface{}) error {
-	out, err := json.Marshal(&VMError{Type: eType, Error: fmt.Sprintf(`%v`, eText)})
+	return vmErrorJSON(&VMError{Type: eType, Error: fmt.Sprintf(`%v`, eText)})
+}
+
+// SetVMErrorInfo returns the error of VM with the function and the line where the error has occurred
+// and the stack of called contracts. The errors which are already VMError keep their type and text
+func SetVMErrorInfo(err error, stack []string) error {
+	var vmErr VMError
+	eText := err.Error()
+	if !strings.HasPrefix(eText, `{`) || json.Unmarshal([]byte(eText), &vmErr) != nil {
+		vmErr = VMError{Type: `panic`, Error: eText}
+	}
+	if srcErr, ok := err.(*SourceError); ok && vmErr.Line == 0 {
+		vmErr.Func, vmErr.Line = srcErr.Func, srcErr.Line
+	}
+	if len(vmErr.Stack) == 0 {
+		vmErr.Stack = stack
+	}
+	return vmErrorJSON(&vmErr)
+}
+
+func vmErrorJSON(vmErr *VMError) error {
+	out, err := json.Marshal(vmErr)
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling VMError")
 		out = []byte(`{"type": "panic", "error": "marshalling VMError"}`)
@@ -471,10 +589,102 @@ func SetVMError(eType string, eText interface{}) error {
 	return fmt.Errorf(string(out))
 }
 
+// TruncateVMError returns the error text which isn't longer than maxLength. The outer calls are removed
+// from the stack of VM error, then the position and the message are dropped so the result is still valid JSON.
+// Other texts are cut on the rune boundary
+func TruncateVMError(errText string, maxLength int) string {
+	if len(errText) <= maxLength {
+		return errText
+	}
+	var vmErr VMError
+	if !strings.HasPrefix(errText, `{`) || json.Unmarshal([]byte(errText), &vmErr) != nil {
+		return truncateRunes(errText, maxLength)
+	}
+	for {
+		out, err := json.Marshal(&vmErr)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling VMError")
+			return truncateRunes(errText, maxLength)
+		}
+		if len(out) <= maxLength {
+			return string(out)
+		}
+		switch {
+		case len(vmErr.Stack) > 0:
+			vmErr.Stack = vmErr.Stack[1:]
+		case len(vmErr.Func) > 0 || vmErr.Line > 0:
+			vmErr.Func, vmErr.Line = ``, 0
+		case len(vmErr.Error) > 0:
+			vmErr.Error = truncateRunes(vmErr.Error, len(vmErr.Error)-(len(out)-maxLength))
+		default:
+			return truncateRunes(string(out), maxLength)
+		}
+	}
+}
+
+// truncateRunes returns the beginning of the text which isn't longer than maxLength bytes and doesn't split runes
+func truncateRunes(text string, maxLength int) string {
+	if maxLength <= 0 {
+		return ``
+	}
+	if len(text) <= maxLength {
+		return text
+	}
+	for maxLength > 0 && !utf8.RuneStart(text[maxLength]) {
+		maxLength--
+	}
+	return text[:maxLength]
+}
+
+// position returns the position of the statement which contains the command with the specified index
+func (block *Block) position(ci int) *SourcePos {
+	i := sort.Search(len(block.Lines), func(i int) bool { return block.Lines[i].Offset > ci })
+	if i > 0 {
+		return &block.Lines[i-1]
+	}
+	return nil
+}
+
+// funcName returns the name of the function which contains the block without the prefix of the ecosystem
+func funcName(block *Block) string {
+	name := blockName(block)
+	if strings.HasPrefix(name, `@`) {
+		name = strings.TrimLeft(name[1:], `0123456789`)
+	}
+	return name
+}
+
+// sourceError adds the position of the command to the error if it hasn't been added in the called function
+func sourceError(err error, block *Block, ci int) error {
+	if _, ok := err.(*SourceError); ok {
+		return err
+	}
+	srcErr := &SourceError{Err: err, Func: funcName(block)}
+	if ci < len(block.Code) {
+		srcErr.Line = block.Code[ci].Line
+	}
+	if srcErr.Line == 0 {
+		if pos := block.position(ci); pos != nil {
+			srcErr.Line = pos.Line
+		}
+	}
+	return srcErr
+}
+
 // RunCode executes Block
 func (rt *RunTime) RunCode(block *Block) (status int, err error) {
+	var ci int
+	defer func() {
+		if r := recover(); r != nil {
+			rt.vm.logger.WithFields(log.Fields{"type": consts.PanicRecoveredError, "error_info": r, "stack": string(debug.Stack())}).Error("runtime panic error")
+			err = fmt.Errorf(`runtime panic error`)
+		}
+		if err != nil {
+			err = sourceError(err, block, ci)
+		}
+	}()
 	top := make([]interface{}, 8)
-	rt.blocks = append(rt.blocks, &blockStack{block, len(rt.vars)})
+	rt.blocks = append(rt.blocks, &blockStack{Block: block, Offset: len(rt.vars)})
 	var namemap map[string][]interface{}
 	if block.Type == ObjFunc && block.Info.(*FuncInfo).Names != nil {
 		if rt.stack[len(rt.stack)-1] != nil {
@@ -489,9 +699,14 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 		var value interface{}
 		if block.Type == ObjFunc && vkey < len(block.Info.(*FuncInfo).Params) {
 			value = rt.stack[start-len(block.Info.(*FuncInfo).Params)+vkey]
+			if value, err = convertVar(vpar, value); err != nil {
+				return
+			}
 		} else {
 			value = reflect.New(vpar).Elem().Interface()
-			if vpar == reflect.TypeOf(map[string]interface{}{}) {
+			if isStructType(vpar) {
+				value = reflect.New(vpar.Elem()).Interface()
+			} else if vpar == reflect.TypeOf(map[string]interface{}{}) {
 				value = make(map[string]interface{})
 			} else if vpar == reflect.TypeOf([]interface{}{}) {
 				value = make([]interface{}, 0, len(rt.vars)+1)
@@ -524,7 +739,7 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 		tmpDec decimal.Decimal
 	)
 	labels := make([]int, 0)
-	for ci := 0; ci < len(block.Code); ci++ {
+	for ci = 0; ci < len(block.Code); ci++ {
 		rt.cost--
 		if rt.cost <= 0 {
 			rt.vm.logger.WithFields(log.Fields{"type": consts.VMError}).Warn("paid CPU resource is over")
@@ -536,6 +751,12 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 			return 0, ErrMemoryLimit
 		}
 
+		if rt.debugger != nil {
+			if err = rt.debugger.check(rt, block, ci); err != nil {
+				return 0, err
+			}
+		}
+
 		cmd := block.Code[ci]
 		var bin interface{}
 		size := len(rt.stack)
@@ -609,7 +830,12 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 								}
 								rt.setVar(k, v)
 							default:
-								rt.setVar(k, rt.stack[len(rt.stack)-count+ivar])
+								v, err := convertVar(rt.blocks[i].Block.Vars[item.Obj.Value.(int)],
+									rt.stack[len(rt.stack)-count+ivar])
+								if err != nil {
+									return 0, err
+								}
+								rt.setVar(k, v)
 							}
 							break
 						}
@@ -680,7 +906,10 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 				return 0, fmt.Errorf(`wrong var %v`, ivar.Obj.Value)
 			}
 		case cmdExtend, cmdCallExtend:
-			if val, ok := (*rt.extend)[cmd.Value.(string)]; ok {
+			if cmd.Cmd == cmdExtend && cmd.Value.(string) == FuelLeft {
+				rt.cost -= CostExtend
+				rt.stack = append(rt.stack, rt.FuelLeft())
+			} else if val, ok := (*rt.extend)[cmd.Value.(string)]; ok {
 				rt.cost -= CostExtend
 				if cmd.Cmd == cmdCallExtend {
 					err = rt.extendFunc(cmd.Value.(string))
@@ -731,6 +960,33 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 				rt.vm.logger.WithFields(log.Fields{"type": consts.VMError, "vm_type": itype}).Error("type does not support indexing")
 				err = fmt.Errorf(`Type %s doesn't support indexing`, itype)
 			}
+		case cmdGetField:
+			rv := reflect.ValueOf(rt.stack[size-1])
+			if !isStructType(rv.Type()) || rv.IsNil() {
+				err = errNotStruct
+				break
+			}
+			rt.stack[size-1] = rv.Elem().Field(cmd.Value.(*StructFieldInfo).Index).Interface()
+		case cmdSetField:
+			fieldInfo := cmd.Value.(*StructFieldInfo)
+			rv := reflect.ValueOf(rt.stack[size-2])
+			if !isStructType(rv.Type()) || rv.IsNil() {
+				err = errNotStruct
+				break
+			}
+			field := rv.Elem().Field(fieldInfo.Index)
+			var val reflect.Value
+			if val, err = valueToField(field.Type(), rt.stack[size-1]); err != nil {
+				break
+			}
+			field.Set(val)
+			rt.stack = rt.stack[:size-2]
+			for i := len(rt.blocks) - 1; i >= 0; i-- {
+				if fieldInfo.Var.Owner == rt.blocks[i].Block {
+					rt.recalcMemVar(rt.blocks[i].Offset + fieldInfo.Var.Obj.Value.(int))
+					break
+				}
+			}
 		case cmdSetIndex:
 			itype := reflect.TypeOf(rt.stack[size-3]).String()
 			indexInfo := cmd.Value.(*IndexInfo)
@@ -807,6 +1063,20 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 			}
 		case cmdNot:
 			rt.stack[size-1] = !valueToBool(top[0])
+		case cmdBitNot:
+			if tmpInt, err = valueToBitInt(top[0]); err == nil {
+				rt.stack[size-1] = ^tmpInt
+			}
+		case cmdToStr:
+			rt.stack[size-1] = valueToString(top[0])
+		case cmdJump:
+			ci += cmd.Value.(int) - 1
+		case cmdJumpFalse:
+			val := rt.stack[size-1]
+			rt.stack = rt.stack[:size-1]
+			if !valueToBool(val) {
+				ci += cmd.Value.(int) - 1
+			}
 
 		case cmdAdd:
 			switch top[1].(type) {
@@ -985,6 +1255,89 @@ func (rt *RunTime) RunCode(block *Block) (status int, err error) {
 					return 0, errUnsupportedType
 				}
 			}
+		case cmdMod:
+			switch top[1].(type) {
+			case string:
+				switch v := top[0].(type) {
+				case int64:
+					if v == 0 {
+						return 0, errDivZero
+					}
+					if tmpInt, err = converter.ValueToInt(top[1]); err == nil {
+						bin = tmpInt % v
+					}
+				case float64:
+					if v == 0 {
+						return 0, errDivZero
+					}
+					bin = math.Mod(ValueToFloat(top[1]), v)
+				default:
+					return 0, errUnsupportedType
+				}
+			case float64:
+				switch top[0].(type) {
+				case string, int64, float64:
+					vFloat := ValueToFloat(top[0])
+					if vFloat == 0 {
+						return 0, errDivZero
+					}
+					bin = math.Mod(top[1].(float64), vFloat)
+				default:
+					return 0, errUnsupportedType
+				}
+			case int64:
+				switch top[0].(type) {
+				case int64, string:
+					if tmpInt, err = converter.ValueToInt(top[0]); err == nil {
+						if tmpInt == 0 {
+							return 0, errDivZero
+						}
+						bin = top[1].(int64) % tmpInt
+					}
+				case float64:
+					if top[0].(float64) == 0 {
+						return 0, errDivZero
+					}
+					bin = math.Mod(ValueToFloat(top[1]), top[0].(float64))
+				default:
+					return 0, errUnsupportedType
+				}
+			default:
+				if reflect.TypeOf(top[1]).String() == Decimal &&
+					reflect.TypeOf(top[0]).String() == Decimal {
+					if top[0].(decimal.Decimal).Cmp(decimal.New(0, 0)) == 0 {
+						return 0, errDivZero
+					}
+					bin = top[1].(decimal.Decimal).Mod(top[0].(decimal.Decimal))
+				} else {
+					return 0, errUnsupportedType
+				}
+			}
+		case cmdBitAnd, cmdBitOr, cmdBitXor, cmdShiftL, cmdShiftR:
+			var left, right int64
+			if left, err = valueToBitInt(top[1]); err != nil {
+				break
+			}
+			if right, err = valueToBitInt(top[0]); err != nil {
+				break
+			}
+			switch cmd.Cmd {
+			case cmdBitAnd:
+				bin = left & right
+			case cmdBitOr:
+				bin = left | right
+			case cmdBitXor:
+				bin = left ^ right
+			default:
+				if right < 0 {
+					return 0, errShift
+				}
+				if cmd.Cmd == cmdShiftL {
+					bin = left << uint64(right)
+				} else {
+					bin = left >> uint64(right)
+				}
+			}
 		case cmdAnd:
 			bin = valueToBool(top[1]) && valueToBool(top[0])
 		case cmdOr:
@@ -1159,6 +1512,11 @@ func (rt *RunTime) Run(block *Block, params []interface{}, extend *map[string]in
 	}()
 	info := block.Info.(*FuncInfo)
 	rt.extend = extend
+	rt.extendCost, _ = (*extend)[`txcost`].(int64)
+	if rt.debugger != nil {
+		rt.debugger.enter(rt)
+		defer rt.debugger.leave(rt)
+	}
 	if _, err = rt.RunCode(block); err == nil {
 		off := len(rt.stack) - len(info.Results)
 		for i := 0; i < len(info.Results); i++ {
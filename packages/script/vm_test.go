@@ -1,7 +1,11 @@
 package script
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -25,3 +29,150 @@ func TestCalcMem(t *testing.T) {
 		assert.Equal(t, v.mem, calcMem(v.v))
 	}
 }
+
+func TestSourceError(t *testing.T) {
+	vm := NewVM()
+	err := vm.Compile([]rune(`func inner(a int) int {
+	return 10 / a
+}
+func outer() int {
+	var x int
+	x = 1
+	return inner(x - 1)
+}
+contract Failed {
+	action {
+		var a int
+		a = 1
+		if a > 0 {
+			error "failed"
+		}
+	}
+}`), &OwnerInfo{StateID: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = vm.Call(`outer`, nil, &map[string]interface{}{`rt_state`: uint32(1)})
+	if srcErr, ok := err.(*SourceError); assert.True(t, ok) {
+		assert.Equal(t, errDivZero, srcErr.Err)
+		assert.Equal(t, `inner`, srcErr.Func)
+		assert.Equal(t, uint32(2), srcErr.Line)
+	}
+	assert.EqualError(t, SetVMErrorInfo(err, []string{`@1Outer`}),
+		`{"type":"panic","error":"divided by zero","func":"inner","line":2,"stack":["@1Outer"]}`)
+
+	action := vm.Objects[`@1Failed`].Value.(*Block).Objects[`action`].Value.(*Block)
+	_, err = vm.RunInit(CostDefault).Run(action, nil, &map[string]interface{}{`rt_state`: uint32(1)})
+	assert.EqualError(t, SetVMErrorInfo(err, []string{`@1Failed`}),
+		`{"type":"error","error":"failed","func":"Failed.action","line":14,"stack":["@1Failed"]}`)
+	assert.EqualError(t, SetVMErrorInfo(errors.New(`{"type":"info","error":"text","stack":["A","B"]}`), []string{`C`}),
+		`{"type":"info","error":"text","stack":["A","B"]}`)
+}
+
+func TestTruncateVMError(t *testing.T) {
+	errText := `{"type":"panic","error":"текст","func":"inner","line":2,"stack":["@1First","@1Second","@1Third"]}`
+	assert.Equal(t, errText, TruncateVMError(errText, len(errText)))
+	assert.Equal(t, `{"type":"panic","error":"текст","func":"inner","line":2,"stack":["@1Second","@1Third"]}`,
+		TruncateVMError(errText, len(errText)-1))
+	assert.Equal(t, `{"type":"panic","error":"текст","func":"inner","line":2}`, TruncateVMError(errText, 61))
+	assert.Equal(t, `{"type":"panic","error":"текст"}`, TruncateVMError(errText, 40))
+	// the error text is cut on the rune boundary at last
+	assert.Equal(t, `{"type":"panic","error":"те"}`, TruncateVMError(errText, 32))
+	for limit := len(`{"type":"panic","error":""}`); limit < len(errText); limit++ {
+		out := TruncateVMError(errText, limit)
+		var vmErr VMError
+		assert.True(t, len(out) <= limit && utf8.ValidString(out), out)
+		assert.NoError(t, json.Unmarshal([]byte(out), &vmErr), out)
+	}
+	assert.Equal(t, `ab`, TruncateVMError(`abcd`, 2))
+	assert.Equal(t, `т`, TruncateVMError(`текст`, 3))
+}
+
+type fuelRecorder []int64
+
+func (fr *fuelRecorder) RecordFuel(contract string, limit, used int64) {
+	*fr = append(*fr, limit, used)
+}
+
+func TestFuelLimit(t *testing.T) {
+	vm := NewVM()
+	err := vm.Compile([]rune(`func left() int {
+	return $fuel_left
+}
+contract Loop {
+	action {
+		var i int
+		while i < 100 {
+			i = i + 1
+		}
+	}
+}`), &OwnerInfo{StateID: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	ret, err := vm.Call(`left`, nil, &map[string]interface{}{`rt_state`: uint32(1)})
+	if assert.NoError(t, err) {
+		assert.True(t, ret[0].(int64) > 0 && ret[0].(int64) < CostDefault)
+	}
+	ret, err = vm.Call(`left`, nil, &map[string]interface{}{`rt_state`: uint32(1), `txcost`: int64(100)})
+	if assert.NoError(t, err) {
+		assert.True(t, ret[0].(int64) > 0 && ret[0].(int64) < CostDefault)
+	}
+
+	var recorder fuelRecorder
+	run := func(fuel ...interface{}) (*RunTime, error) {
+		rt := vm.RunInit(CostDefault)
+		rt.extend = &map[string]interface{}{`rt_state`: uint32(1), `sc`: &recorder}
+		_, err := ExContract(rt, 1, `Loop`, nil, fuel...)
+		return rt, err
+	}
+	rt, err := run()
+	if assert.NoError(t, err) && assert.Len(t, recorder, 2) {
+		assert.Equal(t, int64(0), recorder[0])
+		assert.True(t, recorder[1] > 100)
+		assert.Equal(t, CostDefault-CostContract-recorder[1], rt.cost)
+	}
+	used := recorder[1]
+	recorder = nil
+	_, err = run(used + 1)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int64{used + 1, used}, []int64(recorder))
+	}
+	recorder = nil
+	rt, err = run(int64(100))
+	assert.EqualError(t, err, `fuel limit 100 of @1Loop contract is over`)
+	assert.Equal(t, CostDefault-CostContract-100, rt.cost)
+	assert.Len(t, recorder, 0)
+	for _, fuel := range [][]interface{}{{int64(0)}, {`100`}, {int64(10), int64(10)}} {
+		_, err = run(fuel...)
+		assert.Equal(t, errFuelLimit, err)
+	}
+}
+
+func TestVariadicParams(t *testing.T) {
+	vm := NewVM()
+	vm.Extend(&ExtendData{map[string]interface{}{
+		"Sum": func(prefix string, vals ...interface{}) string {
+			var sum int64
+			for _, v := range vals {
+				sum += v.(int64)
+			}
+			return fmt.Sprintf(`%s%d`, prefix, sum)
+		},
+	}, nil})
+	err := vm.Compile([]rune(`func sums() string {
+	return Sum("a") + Sum("b", 1) + Sum("c", 1, 2)
+}`), &OwnerInfo{StateID: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	ret, err := vm.Call(`sums`, nil, &map[string]interface{}{`rt_state`: uint32(1)})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `a0b1c3`, ret[0])
+	}
+	// the parameters before the variadic one are required
+	err = vm.Compile([]rune(`func empty() string {
+	return Sum()
+}`), &OwnerInfo{StateID: 1})
+	assert.EqualError(t, err, fmt.Sprintf(eWrongParams, `Sum`, 2))
+}
@@ -37,6 +37,7 @@ import (
 type ByteCode struct {
 	Cmd   uint16
 	Value interface{}
+	Line  uint32 // the line in the source code
 }
 
 // ByteCodes is the slice of ByteCode items
@@ -60,6 +61,8 @@ const (
 	ObjVar
 	// ObjExtend is an extended variable. $myvar
 	ObjExtend
+	// ObjStruct is a user-defined type. type mytype {...}
+	ObjStruct
 
 	// CostCall is the cost of the function calling
 	CostCall = 50
@@ -69,6 +72,8 @@ const (
 	CostExtend = 10
 	// CostDefault is the default maximum cost of F
 	CostDefault = int64(10000000)
+	// FuelLeft is the name of the extend variable which contains the fuel left for the running code
+	FuelLeft = `fuel_left`
 
 	// VMTypeSmart is smart vm type
 	VMTypeSmart VMType = 1
@@ -150,6 +155,12 @@ type IndexInfo struct {
 	Extend    string
 }
 
+// StructFieldInfo contains the information for GetField and SetField
+type StructFieldInfo struct {
+	Index int
+	Var   *VarInfo
+}
+
 // ObjInfo is the common object type
 type ObjInfo struct {
 	Type  int
@@ -174,9 +185,17 @@ type Block struct {
 	Parent   *Block
 	Vars     []reflect.Type
 	Code     ByteCodes
+	Lines    []SourcePos // the positions of statements in the source code
 	Children Blocks
 }
 
+// SourcePos is the position of the statement in the source code
+type SourcePos struct {
+	Offset int // the index of the first command of the statement in Block.Code
+	Line   uint32
+	Column uint32
+}
+
 // Blocks is a slice of blocks
 type Blocks []*Block
 
@@ -185,7 +204,8 @@ type VM struct {
 	Block
 	ExtCost     func(string) int64
 	FuncCallsDB map[string]struct{}
-	Extern      bool // extern mode of compilation
+	Extern      bool      // extern mode of compilation
+	Debugger    *Debugger // the debugger of contracts, it is used only in VDE and test modes
 	logger      *log.Entry
 }
 
@@ -200,6 +220,11 @@ type Stacker interface {
 	AppendStack(contract string) error
 }
 
+// FuelRecorder represents interface for collecting the fuel spent by the nested calls of contracts
+type FuelRecorder interface {
+	RecordFuel(contract string, limit, used int64)
+}
+
 // ParseContract gets a state identifier and the name of the contract from the full name like @[id]name
 func ParseContract(in string) (id uint64, name string) {
 	var err error
@@ -218,6 +243,12 @@ func ParseContract(in string) (id uint64, name string) {
 // ExecContract runs the name contract where txs contains the list of parameters and
 // params are the values of parameters
 func ExecContract(rt *RunTime, name, txs string, params ...interface{}) (interface{}, error) {
+	return execContract(rt, name, txs, 0, params...)
+}
+
+// execContract runs the contract which can spend no more than limit of fuel. If limit is 0 then
+// the contract can spend all fuel of the caller
+func execContract(rt *RunTime, name, txs string, limit int64, params ...interface{}) (interface{}, error) {
 
 	contract, ok := rt.vm.Objects[name]
 	if !ok {
@@ -226,6 +257,8 @@ func ExecContract(rt *RunTime, name, txs string, params ...interface{}) (interfa
 	}
 	logger := log.WithFields(log.Fields{"contract_name": name, "type": consts.ContractError})
 	cblock := contract.Value.(*Block)
+	// the contract pinned to the major version is called by its own name
+	name = cblock.Info.(*ContractInfo).Name
 	parnames := make(map[string]bool)
 	pars := strings.Split(txs, `,`)
 	if len(pars) != len(params) {
@@ -307,18 +340,38 @@ func ExecContract(rt *RunTime, name, txs string, params ...interface{}) (interfa
 			return nil, err
 		}
 	}
+	startCost, _ := (*rt.extend)[`txcost`].(int64)
+	// used returns the fuel spent by the contract including the nested calls of VM
+	var spent int64
+	used := func() int64 {
+		if cost, ok := (*rt.extend)[`txcost`].(int64); ok && cost < startCost {
+			return spent + startCost - cost
+		}
+		return spent
+	}
 	for _, method := range []string{`init`, `conditions`, `action`} {
 		if block, ok := (*cblock).Objects[method]; ok && block.Type == ObjFunc {
-			rtemp := rt.vm.RunInit(rt.cost)
+			cost := rt.cost
+			if limit > 0 && limit-used() < cost {
+				cost = limit - used()
+			}
+			rtemp := rt.vm.RunInit(cost)
 			(*rt.extend)[`parent`] = parent
 			_, err := rtemp.Run(block.Value.(*Block), nil, rt.extend)
-			rt.cost = rtemp.cost
+			spent += cost - rtemp.cost
+			rt.cost -= cost - rtemp.cost
+			if limit > 0 && used() >= limit && (err != nil || used() > limit) {
+				err = fmt.Errorf(eFuelLimit, limit, name)
+			}
 			if err != nil {
 				logger.WithFields(log.Fields{"error": err, "method_name": method, "type": consts.ContractError}).Error("executing contract method")
 				return nil, err
 			}
 		}
 	}
+	if recorder, ok := (*rt.extend)["sc"].(FuelRecorder); ok {
+		recorder.RecordFuel(name, limit, used())
+	}
 	if stack != nil {
 		stack.AppendStack("")
 	}
@@ -473,8 +526,16 @@ func (vm *VM) Call(name string, params []interface{}, extend *map[string]interfa
 	return ret, err
 }
 
-// ExContract executes the name contract in the state with specified parameters
-func ExContract(rt *RunTime, state uint32, name string, params map[string]interface{}) (interface{}, error) {
+// ExContract executes the name contract in the state with specified parameters. The optional
+// parameter is the limit of fuel which can be spent by the contract
+func ExContract(rt *RunTime, state uint32, name string, params map[string]interface{}, fuel ...interface{}) (interface{}, error) {
+	var limit int64
+	if len(fuel) > 0 {
+		var ok bool
+		if limit, ok = fuel[0].(int64); !ok || limit <= 0 || len(fuel) > 1 {
+			return nil, errFuelLimit
+		}
+	}
 
 	name = StateName(state, name)
 	contract, ok := rt.vm.Objects[name]
@@ -506,7 +567,7 @@ func ExContract(rt *RunTime, state uint32, name string, params map[string]interf
 	if len(vals) == 0 {
 		vals = append(vals, ``)
 	}
-	return ExecContract(rt, name, strings.Join(names, `,`), vals...)
+	return execContract(rt, name, strings.Join(names, `,`), limit, vals...)
 }
 
 // GetSettings returns the value of the parameter
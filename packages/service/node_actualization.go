@@ -43,7 +43,7 @@ func (n *NodeActualizer) Run() {
 				n.pauseNodeActivity()
 			}
 
-			if actual && IsNodePaused() {
+			if actual && NodePauseType() == PauseTypeUpdatingBlockchain {
 				log.Info("Node Actualizer is resuming node activity")
 				n.resumeNodeActivity()
 			}
@@ -7,11 +7,22 @@ const (
 
 	PauseTypeUpdatingBlockchain PauseType = 1 + iota
 	PauseTypeStopingNetwork
+	// PauseTypeDeepReorg means that the fork is deeper than the allowed count of blocks
+	// and the node has been halted until it is resumed by the administrator
+	PauseTypeDeepReorg
 )
 
 // np contains the reason why a node should not generating blocks
 var np = &NodePaused{PauseType: NoPause}
 
+// deepReorg contains our last block when the node has been halted by the deep fork
+// and the last block of the fork which is allowed to be rolled back by the administrator
+var deepReorg struct {
+	sync.Mutex
+	haltedBlockID   int64
+	acceptedBlockID int64
+}
+
 type PauseType int
 
 type NodePaused struct {
@@ -59,3 +70,39 @@ func PauseNodeActivity(pt PauseType) {
 func NodePauseType() PauseType {
 	return np.Get()
 }
+
+// HaltOnDeepReorg halts the node because the fork after our block blockID is deeper than the allowed count of blocks
+func HaltOnDeepReorg(blockID int64) {
+	deepReorg.Lock()
+	defer deepReorg.Unlock()
+
+	deepReorg.haltedBlockID = blockID
+	np.Set(PauseTypeDeepReorg)
+}
+
+// ResumeAfterDeepReorg resumes the node which has been halted by the deep fork and allows to roll back
+// this fork. It returns the last block of the fork and false if the node hasn't been halted
+func ResumeAfterDeepReorg() (int64, bool) {
+	deepReorg.Lock()
+	defer deepReorg.Unlock()
+
+	if np.Get() != PauseTypeDeepReorg {
+		return 0, false
+	}
+	deepReorg.acceptedBlockID = deepReorg.haltedBlockID
+	np.Unset()
+	return deepReorg.acceptedBlockID, true
+}
+
+// IsDeepReorgAccepted returns true if the administrator has allowed to roll back the fork after block blockID.
+// The permission is used only once
+func IsDeepReorgAccepted(blockID int64) bool {
+	deepReorg.Lock()
+	defer deepReorg.Unlock()
+
+	if deepReorg.acceptedBlockID == 0 || deepReorg.acceptedBlockID != blockID {
+		return false
+	}
+	deepReorg.acceptedBlockID = 0
+	return true
+}
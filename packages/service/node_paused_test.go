@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestResumeAfterDeepReorg(t *testing.T) {
+	if _, ok := ResumeAfterDeepReorg(); ok {
+		t.Error("node which is not halted must not be resumed")
+	}
+	HaltOnDeepReorg(100)
+	if NodePauseType() != PauseTypeDeepReorg {
+		t.Errorf("wrong pause type %d", NodePauseType())
+	}
+	if IsDeepReorgAccepted(100) {
+		t.Error("fork must not be accepted before resuming")
+	}
+	blockID, ok := ResumeAfterDeepReorg()
+	if !ok || blockID != 100 {
+		t.Errorf("wrong resumed block %d", blockID)
+	}
+	if IsNodePaused() {
+		t.Error("node must be resumed")
+	}
+	if IsDeepReorgAccepted(101) {
+		t.Error("other fork must not be accepted")
+	}
+	if !IsDeepReorgAccepted(100) {
+		t.Error("fork must be accepted after resuming")
+	}
+	if IsDeepReorgAccepted(100) {
+		t.Error("fork must be accepted only once")
+	}
+}
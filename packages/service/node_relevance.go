@@ -43,7 +43,7 @@ func (n *NodeRelevanceService) Run() {
 				n.pauseNodeActivity()
 			}
 
-			if relevance && IsNodePaused() {
+			if relevance && NodePauseType() == PauseTypeUpdatingBlockchain {
 				log.Info("Node Relevance Service is resuming node activity")
 				n.resumeNodeActivity()
 			}
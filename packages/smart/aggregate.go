@@ -0,0 +1,247 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package smart
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/model/querycost"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	regexpAggregate  = regexp.MustCompile(`^(count|sum|min|max|avg)\s*\(\s*(\*|[a-z_][a-z0-9_]*)\s*\)$`)
+	regexpColumnName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	regexpHavingTerm = regexp.MustCompile(`^(.+?)\s*(<=|>=|<>|!=|=|<|>)\s*(-?\d+(\.\d+)?)$`)
+	regexpHavingJoin = regexp.MustCompile(`\s+(and|or)\s+`)
+	regexpOrderItem  = regexp.MustCompile(`^(.+?)(\s+(asc|desc))?$`)
+)
+
+// aggregateQuery contains the parsed parts of the aggregate query
+type aggregateQuery struct {
+	fields  []string // the expressions of select
+	groupBy []string
+	having  string
+	order   []string
+	columns []string // the columns of the table which are used in the query
+}
+
+func (query *aggregateQuery) addColumn(column string) {
+	for _, item := range query.columns {
+		if item == column {
+			return
+		}
+	}
+	query.columns = append(query.columns, column)
+}
+
+// parseAggregate returns the sql expression of the aggregate function like sum(amount)
+func (query *aggregateQuery) parseAggregate(expr string) (string, bool) {
+	match := regexpAggregate.FindStringSubmatch(expr)
+	if len(match) != 3 {
+		return ``, false
+	}
+	if match[2] == `*` {
+		if match[1] != `count` {
+			return ``, false
+		}
+		return `count(*)`, true
+	}
+	query.addColumn(match[2])
+	return fmt.Sprintf(`%s("%s")`, match[1], match[2]), true
+}
+
+// parseAggregateQuery validates columns, group by, having and order of the aggregate query
+func parseAggregateQuery(columns, groupBy, having, order string) (*aggregateQuery, error) {
+	query := &aggregateQuery{}
+	groups := make(map[string]bool)
+	if len(strings.TrimSpace(groupBy)) > 0 {
+		for _, col := range strings.Split(strings.ToLower(groupBy), `,`) {
+			col = strings.TrimSpace(col)
+			if !regexpColumnName.MatchString(col) {
+				return nil, fmt.Errorf(`wrong group by column %s`, col)
+			}
+			groups[col] = true
+			query.addColumn(col)
+			query.groupBy = append(query.groupBy, `"`+col+`"`)
+		}
+	}
+	aliases := make(map[string]bool)
+	for _, col := range strings.Split(strings.ToLower(columns), `,`) {
+		col = strings.Join(strings.Fields(col), ``)
+		if groups[col] {
+			query.fields = append(query.fields, `"`+col+`"`)
+		} else if expr, ok := query.parseAggregate(col); ok {
+			query.fields = append(query.fields, fmt.Sprintf(`%s as "%s"`, expr, col))
+		} else {
+			return nil, fmt.Errorf(`column %s must be an aggregate function or be in group by`, col)
+		}
+		aliases[col] = true
+	}
+	if len(strings.TrimSpace(having)) > 0 {
+		var terms []string
+		having = strings.ToLower(strings.TrimSpace(having))
+		items := regexpHavingJoin.Split(having, -1)
+		joins := regexpHavingJoin.FindAllStringSubmatch(having, -1)
+		for i, item := range items {
+			match := regexpHavingTerm.FindStringSubmatch(item)
+			if len(match) == 0 {
+				return nil, fmt.Errorf(`wrong having condition %s`, item)
+			}
+			expr, ok := query.parseAggregate(strings.Join(strings.Fields(match[1]), ``))
+			if !ok {
+				return nil, fmt.Errorf(`having condition %s must use an aggregate function`, item)
+			}
+			if i > 0 {
+				terms = append(terms, joins[i-1][1])
+			}
+			terms = append(terms, fmt.Sprintf(`%s %s %s`, expr, match[2], match[3]))
+		}
+		query.having = strings.Join(terms, ` `)
+	}
+	if len(strings.TrimSpace(order)) > 0 {
+		for _, item := range strings.Split(strings.ToLower(order), `,`) {
+			match := regexpOrderItem.FindStringSubmatch(strings.TrimSpace(item))
+			col := strings.Join(strings.Fields(match[1]), ``)
+			if !aliases[col] {
+				return nil, fmt.Errorf(`order by %s must be one of the selected columns`, col)
+			}
+			query.order = append(query.order, strings.TrimSpace(`"`+col+`" `+match[3]))
+		}
+	} else {
+		query.order = query.groupBy
+	}
+	return query, nil
+}
+
+// DBAggregate returns the values of aggregate functions count, sum, min, max and avg
+// which can be grouped by the columns of the table
+func DBAggregate(sc *SmartContract, tblname, columns, groupBy, having, order string, offset, limit, ecosystem int64,
+	where string, params []interface{}) (int64, []interface{}, error) {
+
+	if len(columns) == 0 {
+		columns = `count(*)`
+	}
+	if err := checkNow(columns, where, having); err != nil {
+		return 0, nil, err
+	}
+	query, err := parseAggregateQuery(columns, groupBy, having, order)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "error": err}).Error("parsing aggregate query")
+		return 0, nil, err
+	}
+	where = PrepareWhere(strings.Replace(converter.Escape(where), `$`, `?`, -1))
+	if limit == 0 {
+		limit = 25
+	}
+	if limit < 0 || limit > 250 {
+		limit = 250
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if ecosystem == 0 {
+		ecosystem = sc.TxSmart.EcosystemID
+	}
+	tblname = GetTableName(sc, tblname, ecosystem)
+
+	perm, err := sc.AccessTablePerm(tblname, `read`)
+	if err != nil {
+		return 0, nil, err
+	}
+	if perm != nil && len(perm[`filter`]) > 0 {
+		log.WithFields(log.Fields{"type": consts.AccessDenied, "table": tblname}).Error("aggregate of table with filter")
+		return 0, nil, errAccessDenied
+	}
+	if len(query.columns) > 0 {
+		colsList := append([]string{}, query.columns...)
+		if err = sc.AccessColumns(tblname, &colsList, false); err != nil {
+			return 0, nil, err
+		}
+		if len(colsList) != len(query.columns) {
+			return 0, nil, errAccessDenied
+		}
+	}
+
+	sqlQuery := fmt.Sprintf(`SELECT %s FROM "%s"`, strings.Join(query.fields, `, `), tblname)
+	if len(where) > 0 {
+		sqlQuery += ` WHERE ` + where
+	}
+	if len(query.groupBy) > 0 {
+		sqlQuery += ` GROUP BY ` + strings.Join(query.groupBy, `, `)
+	}
+	if len(query.having) > 0 {
+		sqlQuery += ` HAVING ` + query.having
+	}
+	if len(query.order) > 0 {
+		sqlQuery += ` ORDER BY ` + strings.Join(query.order, `, `)
+	}
+	sqlQuery += fmt.Sprintf(` OFFSET %d LIMIT %d`, offset, limit)
+
+	// the cost must be the same on all nodes so the plan of the query isn't used
+	queryCoster := querycost.GetQueryCoster(querycost.FormulaQueryCosterType)
+	cost, err := queryCoster.QueryCost(sc.DbTransaction, sqlQuery, params...)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": sqlQuery}).Error("getting aggregate query cost")
+		return 0, nil, err
+	}
+	rows, err := model.GetDB(sc.DbTransaction).Raw(sqlQuery, params...).Rows()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": sqlQuery}).Error("selecting aggregate values")
+		return 0, nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting rows columns")
+		return 0, nil, err
+	}
+	values := make([][]byte, len(cols))
+	scanArgs := make([]interface{}, len(values))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	result := make([]interface{}, 0, 50)
+	for rows.Next() {
+		if err = rows.Scan(scanArgs...); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("scanning next row")
+			return 0, nil, err
+		}
+		row := make(map[string]interface{})
+		for i, col := range values {
+			var value string
+			if col != nil {
+				value = string(col)
+			}
+			row[cols[i]] = value
+		}
+		result = append(result, reflect.ValueOf(row).Interface())
+	}
+	return cost, result, nil
+}
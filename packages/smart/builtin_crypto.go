@@ -0,0 +1,162 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package smart
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxRecoverKeys is the maximum count of public keys which can be checked by RecoverAddress
+const maxRecoverKeys = 10
+
+// dataToBytes converts the string or binary value of the contract to []byte
+func dataToBytes(data interface{}) ([]byte, error) {
+	switch v := data.(type) {
+	case []uint8:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+	err := fmt.Errorf("Unsupported type %T", data)
+	log.WithFields(log.Fields{"type": consts.ConversionError, "error": err}).Error("converting to bytes")
+	return nil, err
+}
+
+// decodePubKey converts the public key from the hexadecimal form. The key can be with 04 prefix
+func decodePubKey(pubkey string) ([]byte, error) {
+	key, err := hex.DecodeString(pubkey)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConversionError, "error": err, "value": pubkey}).Error("decoding public key from hex")
+		return nil, err
+	}
+	if len(key) == consts.PubkeySizeLength+1 && key[0] == 4 {
+		key = key[1:]
+	}
+	if len(key) != consts.PubkeySizeLength {
+		log.WithFields(log.Fields{"type": consts.SizeDoesNotMatch, "size": len(key)}).Error("invalid public key")
+		return nil, crypto.ErrIncorrectPubKeyLength
+	}
+	return key, nil
+}
+
+func decodeSignature(signature string) ([]byte, error) {
+	sign, err := hex.DecodeString(signature)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConversionError, "error": err, "value": signature}).Error("decoding signature from hex")
+		return nil, err
+	}
+	return sign, nil
+}
+
+// checkSign returns false without the error if the signature is correct but it doesn't match the key
+func checkSign(key []byte, data string, sign []byte) (bool, error) {
+	ok, err := crypto.CheckSign(key, data, sign)
+	if err == crypto.ErrIncorrectSign {
+		return false, nil
+	}
+	return ok, err
+}
+
+// VerifySignature checks that data has been signed by the private key of pubkey. The public key
+// and the signature must be in the hexadecimal form
+func VerifySignature(pubkey, data, signature string) (bool, error) {
+	key, err := decodePubKey(pubkey)
+	if err != nil {
+		return false, err
+	}
+	sign, err := decodeSignature(signature)
+	if err != nil {
+		return false, err
+	}
+	return checkSign(key, data, sign)
+}
+
+// Sha3 returns SHA3-256 hash value in the hexadecimal form
+func Sha3(data interface{}) (string, error) {
+	b, err := dataToBytes(data)
+	if err != nil {
+		return ``, err
+	}
+	return hex.EncodeToString(crypto.SHA3256(b)), nil
+}
+
+// Keccak256 returns Keccak-256 hash value in the hexadecimal form
+func Keccak256(data interface{}) (string, error) {
+	b, err := dataToBytes(data)
+	if err != nil {
+		return ``, err
+	}
+	return hex.EncodeToString(crypto.Keccak256(b)), nil
+}
+
+// EncryptForKey encrypts data so that it can be only decrypted with the private key of pubkey.
+// It returns the cipher text in the hexadecimal form. The ephemeral key is random so the function
+// is available only in VDE contracts
+func EncryptForKey(pubkey string, data interface{}) (string, error) {
+	key, err := decodePubKey(pubkey)
+	if err != nil {
+		return ``, err
+	}
+	b, err := dataToBytes(data)
+	if err != nil {
+		return ``, err
+	}
+	encrypted, err := crypto.SharedEncrypt(key, b)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("encrypting data for public key")
+		return ``, err
+	}
+	return hex.EncodeToString(encrypted), nil
+}
+
+// RecoverAddress returns the address of the public key from pubkeys which data has been signed with.
+// It returns 0 if the signature doesn't match any of the public keys
+func RecoverAddress(data, signature string, pubkeys ...interface{}) (int64, error) {
+	if len(pubkeys) > maxRecoverKeys {
+		return 0, fmt.Errorf(eTooManyKeys, len(pubkeys), maxRecoverKeys)
+	}
+	sign, err := decodeSignature(signature)
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range pubkeys {
+		key, err := decodePubKey(fmt.Sprint(item))
+		if err != nil {
+			return 0, err
+		}
+		ok, err := checkSign(key, data, sign)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return crypto.Address(key), nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,184 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package smart
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/script"
+
+	"github.com/shopspring/decimal"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// maxRegexpLength is the maximum length of the regular expression
+	maxRegexpLength = 1024
+	// regexpCostChunk is the size of the text which is processed for the one unit of fuel per instruction
+	regexpCostChunk = 256
+	// maxRegexpResult is the maximum length of the result of RegexpReplace
+	maxRegexpResult = 1 << 20
+)
+
+// moneySeparators contains the thousands and decimal separators of the locales
+var moneySeparators = map[string][2]string{
+	``:   {`,`, `.`},
+	`en`: {`,`, `.`},
+	`zh`: {`,`, `.`},
+	`ja`: {`,`, `.`},
+	`de`: {`.`, `,`},
+	`es`: {`.`, `,`},
+	`it`: {`.`, `,`},
+	`nl`: {`.`, `,`},
+	`pt`: {`.`, `,`},
+	`fr`: {` `, `,`},
+	`ru`: {` `, `,`},
+	`uk`: {` `, `,`},
+	`ch`: {`'`, `.`},
+}
+
+// compileRegexp compiles RE2 regular expression and returns the fuel for processing the text.
+// The fuel depends on the count of instructions of the regular expression and the length of the text
+func compileRegexp(pattern string, size int) (*regexp.Regexp, int64, error) {
+	if len(pattern) > maxRegexpLength {
+		return nil, 0, fmt.Errorf(eRegexpLength, len(pattern), maxRegexpLength)
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ParseError, "error": err, "value": pattern}).Error("parsing regexp")
+		return nil, 0, err
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ParseError, "error": err, "value": pattern}).Error("compiling regexp")
+		return nil, 0, err
+	}
+	ret, err := regexp.Compile(pattern)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ParseError, "error": err, "value": pattern}).Error("compiling regexp")
+		return nil, 0, err
+	}
+	return ret, int64(len(prog.Inst)) * int64(size/regexpCostChunk+1), nil
+}
+
+// RegexpMatch reports whether the text contains any match of RE2 regular expression
+func RegexpMatch(pattern, text string) (int64, bool, error) {
+	re, cost, err := compileRegexp(pattern, len(text))
+	if err != nil {
+		return 0, false, err
+	}
+	return cost, re.MatchString(text), nil
+}
+
+// RegexpReplace replaces the matches of RE2 regular expression with the replacement text.
+// $1 or ${name} in the replacement are replaced with the corresponding submatches
+func RegexpReplace(pattern, text, replace string) (int64, string, error) {
+	re, cost, err := compileRegexp(pattern, len(text))
+	if err != nil {
+		return 0, ``, err
+	}
+	// the replacement is expanded by parts so the result can't exceed the limit more than by one submatch
+	parts := splitTemplate(replace)
+	ret := make([]byte, 0, len(text))
+	last := 0
+	for _, match := range re.FindAllStringSubmatchIndex(text, -1) {
+		ret = append(ret, text[last:match[0]]...)
+		for _, part := range parts {
+			if ret = re.ExpandString(ret, part, text, match); len(ret) > maxRegexpResult {
+				return cost, ``, fmt.Errorf(eRegexpResult, maxRegexpResult)
+			}
+		}
+		last = match[1]
+	}
+	ret = append(ret, text[last:]...)
+	if len(ret) > maxRegexpResult {
+		return cost, ``, fmt.Errorf(eRegexpResult, maxRegexpResult)
+	}
+	return cost + int64(len(ret)/regexpCostChunk), string(ret), nil
+}
+
+// splitTemplate splits the replacement before each $ so every part contains no more than one submatch.
+// $$ is the escaped $ and it isn't split
+func splitTemplate(template string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(template); i++ {
+		if template[i] != '$' {
+			continue
+		}
+		if i+1 < len(template) && template[i+1] == '$' {
+			i++
+			continue
+		}
+		if i > start {
+			parts = append(parts, template[start:i])
+		}
+		start = i
+	}
+	return append(parts, template[start:])
+}
+
+// FormatMoney formats the amount in the minimal units with digits decimal places according to the locale.
+// For example, FormatMoney("123456789", 2, "de") returns "1.234.567,89"
+func FormatMoney(amount interface{}, digits int64, locale string) (string, error) {
+	var (
+		value decimal.Decimal
+		err   error
+	)
+	switch v := amount.(type) {
+	case decimal.Decimal, string, int64, float64:
+		if value, err = script.ValueToDecimal(v); err != nil {
+			return ``, err
+		}
+	default:
+		return ``, fmt.Errorf(eTypeMoney, amount)
+	}
+	if digits < 0 || digits > consts.MoneyLength {
+		return ``, fmt.Errorf(eMoneyDigits, digits)
+	}
+	separators, ok := moneySeparators[strings.ToLower(strings.SplitN(strings.Replace(locale, `_`, `-`, -1), `-`, 2)[0])]
+	if !ok {
+		return ``, fmt.Errorf(eLocale, locale)
+	}
+	value = value.Shift(int32(-digits))
+	text := value.Abs().StringFixed(int32(digits))
+	fraction := ``
+	if off := strings.IndexByte(text, '.'); off >= 0 {
+		text, fraction = text[:off], separators[1]+text[off+1:]
+	}
+	var out []string
+	for len(text) > 3 {
+		out = append([]string{text[len(text)-3:]}, out...)
+		text = text[:len(text)-3]
+	}
+	out = append([]string{text}, out...)
+	sign := ``
+	if value.Sign() < 0 {
+		sign = `-`
+	}
+	return sign + strings.Join(out, separators[0]) + fraction, nil
+}
@@ -0,0 +1,180 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package smart
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultDateLayout is the layout of dates if it isn't specified
+const defaultDateLayout = `2006-01-02 15:04:05`
+
+// dateUnits contains the durations of the units of time. Months and years have the zero
+// duration because they are calculated by the calendar
+var dateUnits = map[string]time.Duration{
+	`second`: time.Second,
+	`minute`: time.Minute,
+	`hour`:   time.Hour,
+	`day`:    24 * time.Hour,
+	`week`:   7 * 24 * time.Hour,
+	`month`:  0,
+	`year`:   0,
+}
+
+// The time zones are only fixed offsets, because the database of time zones can be different
+// on the nodes and the result must be the same
+var reTimeZone = regexp.MustCompile(`^([+-])(\d\d):?(\d\d)?$`)
+
+// timeZone returns the time zone which is specified as UTC, Z, +hh, +hhmm or +hh:mm
+func timeZone(zone []interface{}) (*time.Location, error) {
+	if len(zone) == 0 {
+		return time.UTC, nil
+	}
+	if len(zone) > 1 {
+		return nil, fmt.Errorf(eTimeZone, fmt.Sprint(zone...))
+	}
+	name := strings.TrimSpace(fmt.Sprint(zone[0]))
+	switch strings.ToUpper(name) {
+	case ``, `UTC`, `Z`:
+		return time.UTC, nil
+	}
+	match := reTimeZone.FindStringSubmatch(name)
+	if match == nil {
+		log.WithFields(log.Fields{"type": consts.ParameterExceeded, "value": name}).Error("unknown time zone")
+		return nil, fmt.Errorf(eTimeZone, name)
+	}
+	var minutes int
+	hours, _ := strconv.Atoi(match[2])
+	if len(match[3]) > 0 {
+		minutes, _ = strconv.Atoi(match[3])
+	}
+	if hours > 14 || minutes > 59 {
+		return nil, fmt.Errorf(eTimeZone, name)
+	}
+	offset := hours*3600 + minutes*60
+	if match[1] == `-` {
+		offset = -offset
+	}
+	return time.FixedZone(name, offset), nil
+}
+
+// dateUnit returns the unit of time. The plural form of the unit is allowed
+func dateUnit(unit string) (string, time.Duration, error) {
+	unit = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(unit)), `s`)
+	duration, ok := dateUnits[unit]
+	if !ok {
+		return ``, 0, fmt.Errorf(eDateUnit, unit)
+	}
+	return unit, duration, nil
+}
+
+// Date formats the unix timestamp according to the layout. The optional parameter is the time zone,
+// the default time zone is UTC
+func Date(layout string, timestamp int64, zone ...interface{}) (string, error) {
+	loc, err := timeZone(zone)
+	if err != nil {
+		return ``, err
+	}
+	if len(layout) == 0 {
+		layout = defaultDateLayout
+	}
+	return time.Unix(timestamp, 0).In(loc).Format(layout), nil
+}
+
+// ParseDate parses the date according to the layout and returns the unix timestamp. The optional
+// parameter is the time zone of the date if it isn't specified in value
+func ParseDate(layout, value string, zone ...interface{}) (int64, error) {
+	loc, err := timeZone(zone)
+	if err != nil {
+		return 0, err
+	}
+	if len(layout) == 0 {
+		layout = defaultDateLayout
+	}
+	t, err := time.ParseInLocation(layout, value, loc)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.ConversionError, "error": err, "value": value}).Error("parsing date")
+		return 0, err
+	}
+	return t.Unix(), nil
+}
+
+// DateAdd adds count units of time to the unix timestamp. Months and years are added by the calendar
+// of the optional time zone. Use $block_time as the current time
+func DateAdd(timestamp, count int64, unit string, zone ...interface{}) (int64, error) {
+	loc, err := timeZone(zone)
+	if err != nil {
+		return 0, err
+	}
+	unit, duration, err := dateUnit(unit)
+	if err != nil {
+		return 0, err
+	}
+	t := time.Unix(timestamp, 0).In(loc)
+	switch unit {
+	case `month`:
+		t = t.AddDate(0, int(count), 0)
+	case `year`:
+		t = t.AddDate(int(count), 0, 0)
+	default:
+		t = t.Add(time.Duration(count) * duration)
+	}
+	return t.Unix(), nil
+}
+
+// DateDiff returns the count of the whole units of time between from and to. The result is negative
+// if to is before from
+func DateDiff(from, to int64, unit string, zone ...interface{}) (int64, error) {
+	loc, err := timeZone(zone)
+	if err != nil {
+		return 0, err
+	}
+	unit, duration, err := dateUnit(unit)
+	if err != nil {
+		return 0, err
+	}
+	if duration > 0 {
+		return (to - from) / int64(duration/time.Second), nil
+	}
+	sign := int64(1)
+	if to < from {
+		from, to, sign = to, from, -1
+	}
+	start, end := time.Unix(from, 0).In(loc), time.Unix(to, 0).In(loc)
+	months := int64(end.Year()-start.Year())*12 + int64(end.Month()-start.Month())
+	if months > 0 && start.AddDate(0, int(months), 0).After(end) {
+		months--
+	}
+	if unit == `year` {
+		months /= 12
+	}
+	return sign * months, nil
+}
@@ -28,6 +28,16 @@ const (
 	eContractLoop  = `There is loop in %s contract`
 	eContractExist = `Contract %s already exists`
 	eLatin         = `Name %s must only contain latin, digit and '_', '-' characters`
+	eVersion       = `Version %s must be in the format major or major.minor`
+	eVersionLess   = `Version %s must be greater than the current version %s`
+	eTooManyKeys   = `There are %d public keys, the maximum is %d`
+	eRegexpLength  = `Regular expression is too long %d, the maximum is %d`
+	eRegexpResult  = `Result of the replacement is longer than %d`
+	eTypeMoney     = `Unsupported type %T of money`
+	eMoneyDigits   = `Wrong count of digits %d`
+	eLocale        = `Unknown locale %s`
+	eTimeZone      = `Unknown time zone %s`
+	eDateUnit      = `Unknown unit of time %s`
 )
 
 var (
@@ -40,4 +50,5 @@ var (
 	errWrongColumn            = errors.New(`Column name cannot begin with digit`)
 	errNotFound               = errors.New(`Record has not been found`)
 	errNow                    = errors.New(`It is prohibited to use NOW() or current time functions`)
+	errMigrateParams          = errors.New(`migrate function cannot have parameters`)
 )
@@ -0,0 +1,48 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package smart
+
+// FuelCall contains the fuel which has been spent by the nested call of the contract
+type FuelCall struct {
+	Contract string `json:"contract"`
+	Limit    int64  `json:"limit,omitempty"`
+	Used     int64  `json:"used"`
+}
+
+// FuelInfo is the breakdown of the fuel of the transaction
+type FuelInfo struct {
+	Limit int64      `json:"limit"`
+	Used  int64      `json:"used"`
+	Calls []FuelCall `json:"calls,omitempty"`
+}
+
+// RecordFuel appends the fuel spent by the nested call of the contract. Limit is 0 if the contract
+// has been called without the limit of fuel
+func (sc *SmartContract) RecordFuel(contract string, limit, used int64) {
+	sc.TxFuelCalls = append(sc.TxFuelCalls, FuelCall{Contract: contract, Limit: limit, Used: used})
+}
+
+// FuelInfo returns the breakdown of the fuel which has been spent by the transaction
+func (sc *SmartContract) FuelInfo() *FuelInfo {
+	return &FuelInfo{Limit: sc.TxFuelLimit, Used: sc.TxFuel, Calls: sc.TxFuelCalls}
+}
@@ -28,6 +28,7 @@ import (
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -55,6 +56,7 @@ import (
 	"github.com/GenesisCommunity/go-genesis/packages/vdemanager"
 	uuid "github.com/satori/go.uuid"
 
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 )
@@ -62,9 +64,14 @@ import (
 const (
 	nodeBanNotificationHeader = "Your node was banned"
 	historyLimit              = 250
+	maxDecimalPrecision       = 100
 )
 
-var BOM = []byte{0xEF, 0xBB, 0xBF}
+var (
+	BOM = []byte{0xEF, 0xBB, 0xBF}
+
+	regexpDecimalType = regexp.MustCompile(`^decimal\s*\(\s*(\d+)\s*,\s*(\d+)\s*\)$`)
+)
 
 type permTable struct {
 	Insert    string `json:"insert"`
@@ -72,11 +79,13 @@ type permTable struct {
 	NewColumn string `json:"new_column"`
 	Read      string `json:"read,omitempty"`
 	Filter    string `json:"filter,omitempty"`
+	Delete    string `json:"delete,omitempty"`
 }
 
 type permColumn struct {
-	Update string `json:"update"`
-	Read   string `json:"read,omitempty"`
+	Update     string `json:"update"`
+	Read       string `json:"read,omitempty"`
+	References string `json:"references,omitempty"`
 }
 
 // SmartContract is storing smart contract data
@@ -92,6 +101,8 @@ type SmartContract struct {
 	TxFuel        int64           // The fuel of executing contract
 	TxCost        int64           // Maximum cost of executing contract
 	TxUsedCost    decimal.Decimal // Used cost of CPU resources
+	TxFuelLimit   int64           // The fuel which is available for executing contract
+	TxFuelCalls   []FuelCall      // The fuel spent by the nested calls of contracts
 	BlockData     *utils.BlockData
 	Loop          map[string]bool
 	TxHash        []byte
@@ -99,6 +110,18 @@ type SmartContract struct {
 	DbTransaction *model.DbTransaction
 }
 
+// stackNames returns the names of contracts in the stack of calls
+func (sc *SmartContract) stackNames() []string {
+	if sc.TxContract == nil {
+		return nil
+	}
+	names := make([]string, 0, len(sc.TxContract.StackCont))
+	for _, item := range sc.TxContract.StackCont {
+		names = append(names, fmt.Sprint(item))
+	}
+	return names
+}
+
 // AppendStack adds an element to the stack of contract call or removes the top element when name is empty
 func (sc *SmartContract) AppendStack(contract string) error {
 	cont := sc.TxContract
@@ -120,9 +143,14 @@ var (
 	funcCallsDB = map[string]struct{}{
 		"DBInsert":    {},
 		"DBSelect":    {},
+		"DBAggregate": {},
 		"DBUpdate":    {},
 		"DBUpdateExt": {},
+		"DBDelete":    {},
 		"SetPubKey":   {},
+		// the fuel of regular expressions depends on the complexity
+		"RegexpMatch":   {},
+		"RegexpReplace": {},
 	}
 	extendCost = map[string]int64{
 		"AddressToId":                  10,
@@ -132,6 +160,11 @@ var (
 		"ContractConditions":           50,
 		"ContractName":                 10,
 		"CreateColumn":                 50,
+		"DropColumn":                   50,
+		"RenameColumn":                 50,
+		"AlterColumnType":              50,
+		"CreateIndex":                  100,
+		"DropIndex":                    50,
 		"CreateTable":                  100,
 		"CreateLanguage":               50,
 		"EditLanguage":                 50,
@@ -147,6 +180,18 @@ var (
 		"Join":                         10,
 		"JSONToMap":                    50,
 		"Sha256":                       50,
+		"Sha3":                         50,
+		"Keccak256":                    50,
+		"VerifySignature":              200,
+		"EncryptForKey":                200,
+		"RecoverAddress":               1000,
+		"Date":                         10,
+		"ParseDate":                    10,
+		"DateAdd":                      10,
+		"DateDiff":                     10,
+		"RegexpMatch":                  10,
+		"RegexpReplace":                20,
+		"FormatMoney":                  10,
 		"IdToAddress":                  10,
 		"Len":                          5,
 		"Replace":                      10,
@@ -161,6 +206,16 @@ var (
 		"TableConditions":              100,
 		"ValidateCondition":            30,
 		"ValidateEditContractNewValue": 10,
+		"AnalyzeContract":              50,
+	}
+	// analyzeOptions describes the environment of contracts for the static analysis
+	analyzeOptions = &script.AnalyzeOptions{
+		Extend: []string{`result`, `contract`, `migrate_from`},
+		StateFuncs: []string{`DBInsert`, `DBUpdate`, `DBUpdateExt`, `DBDelete`, `DBUpdateSysParam`,
+			`CreateTable`, `CreateColumn`, `DropColumn`, `RenameColumn`, `AlterColumnType`, `CreateIndex`,
+			`DropIndex`, `PermTable`, `PermColumn`, `CreateEcosystem`, `EditEcosysName`, `CreateContract`,
+			`UpdateContract`, `CreateLanguage`, `EditLanguage`, `Activate`, `Deactivate`, `SetPubKey`,
+			`UpdateNodesBan`, `UpdateCron`, `CreateVDE`, `DeleteVDE`},
 	}
 	// map for table name to parameter with conditions
 	tableParamConditions = map[string]string{
@@ -191,11 +246,19 @@ func EmbedFuncs(vm *script.VM, vt script.VMType) {
 		"ContractConditions":           ContractConditions,
 		"ContractName":                 contractName,
 		"ValidateEditContractNewValue": ValidateEditContractNewValue,
+		"AnalyzeContract":              AnalyzeContract,
 		"CreateColumn":                 CreateColumn,
+		"DropColumn":                   DropColumn,
+		"RenameColumn":                 RenameColumn,
+		"AlterColumnType":              AlterColumnType,
+		"CreateIndex":                  CreateIndex,
+		"DropIndex":                    DropIndex,
 		"CreateTable":                  CreateTable,
 		"DBInsert":                     DBInsert,
 		"DBSelect":                     DBSelect,
+		"DBAggregate":                  DBAggregate,
 		"DBUpdate":                     DBUpdate,
+		"DBDelete":                     DBDelete,
 		"DBUpdateSysParam":             UpdateSysParam,
 		"DBUpdateExt":                  DBUpdateExt,
 		"EcosysParam":                  EcosysParam,
@@ -226,6 +289,10 @@ func EmbedFuncs(vm *script.VM, vt script.VMType) {
 		"Replace":                      Replace,
 		"Size":                         Size,
 		"Sha256":                       Sha256,
+		"Sha3":                         Sha3,
+		"Keccak256":                    Keccak256,
+		"VerifySignature":              VerifySignature,
+		"RecoverAddress":               RecoverAddress,
 		"PubToID":                      PubToID,
 		"HexToBytes":                   HexToBytes,
 		"LangRes":                      LangRes,
@@ -276,22 +343,29 @@ func EmbedFuncs(vm *script.VM, vt script.VMType) {
 		"GetDataFromXLSX":              GetDataFromXLSX,
 		"GetRowsCountXLSX":             GetRowsCountXLSX,
 		"BlockTime":                    BlockTime,
+		"Date":                         Date,
+		"ParseDate":                    ParseDate,
+		"DateAdd":                      DateAdd,
+		"DateDiff":                     DateDiff,
+		"RegexpMatch":                  RegexpMatch,
+		"RegexpReplace":                RegexpReplace,
+		"FormatMoney":                  FormatMoney,
 	}
 
 	switch vt {
 	case script.VMTypeVDE:
+		f["EncryptForKey"] = EncryptForKey
 		f["HTTPRequest"] = HTTPRequest
-		f["Date"] = Date
 		f["HTTPPostJSON"] = HTTPPostJSON
 		f["ValidateCron"] = ValidateCron
 		f["UpdateCron"] = UpdateCron
 		vmExtendCost(vm, getCost)
 		vmFuncCallsDB(vm, funcCallsDB)
 	case script.VMTypeVDEMaster:
+		f["EncryptForKey"] = EncryptForKey
 		f["HTTPRequest"] = HTTPRequest
 		f["GetMapKeys"] = GetMapKeys
 		f["SortedKeys"] = SortedKeys
-		f["Date"] = Date
 		f["HTTPPostJSON"] = HTTPPostJSON
 		f["ValidateCron"] = ValidateCron
 		f["UpdateCron"] = UpdateCron
@@ -352,6 +426,25 @@ func CompileContract(sc *SmartContract, code string, state, id, token int64) (in
 	return VMCompileBlock(sc.VM, code, &script.OwnerInfo{StateID: uint32(state), WalletID: id, TokenID: token})
 }
 
+// AnalyzeContract checks the source code of the contract before the compilation. It returns the list
+// of warnings and the error if the source code has problems which lead to the errors at runtime
+func AnalyzeContract(sc *SmartContract, code string, state int64) ([]interface{}, error) {
+	warnings := make([]interface{}, 0)
+	var errs []string
+	for _, issue := range script.Analyze(sc.VM, code, &script.OwnerInfo{StateID: uint32(state)}, analyzeOptions) {
+		if issue.Level == script.IssueError {
+			errs = append(errs, issue.String())
+		} else {
+			warnings = append(warnings, issue.String())
+		}
+	}
+	if len(errs) > 0 {
+		log.WithFields(log.Fields{"type": consts.ParseError, "error": errs}).Error("analyzing contract")
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+	return warnings, nil
+}
+
 // ContractAccess checks whether the name of the executable contract matches one of the names listed in the parameters.
 func ContractAccess(sc *SmartContract, names ...interface{}) bool {
 	for _, iname := range names {
@@ -451,7 +544,9 @@ func ValidateEditContractNewValue(sc *SmartContract, newValue, oldValue string)
 	return nil
 }
 
-func UpdateContract(sc *SmartContract, id int64, value, conditions, walletID string, recipient int64, active, tokenID string) error {
+// UpdateContract changes the contract. The new source of the contract is added to the version history,
+// the optional parameter is the new version of the contract
+func UpdateContract(sc *SmartContract, id int64, value, conditions, walletID string, recipient int64, active, tokenID string, version ...interface{}) error {
 	if !accessContracts(sc, `EditContract`, `Import`) {
 		log.WithFields(log.Fields{"type": consts.IncorrectCallingContract}).Error("UpdateContract can be only called from EditContract")
 		return fmt.Errorf(`UpdateContract can be only called from EditContract`)
@@ -466,6 +561,15 @@ func UpdateContract(sc *SmartContract, id int64, value, conditions, walletID str
 		if err != nil {
 			return err
 		}
+		if !sc.VDE {
+			var ver string
+			if len(version) > 0 && version[0] != nil {
+				ver = fmt.Sprint(version[0])
+			}
+			if err = sc.upgradeContract(id, value, root.(*script.Block), ver); err != nil {
+				return err
+			}
+		}
 		pars = append(pars, "value")
 		vals = append(vals, value)
 	}
@@ -486,6 +590,9 @@ func UpdateContract(sc *SmartContract, id int64, value, conditions, walletID str
 		if err := FlushContract(sc, root, id, converter.StrToInt64(active) == 1); err != nil {
 			return err
 		}
+		if !sc.VDE {
+			sc.pinLastVersion(id, root.(*script.Block))
+		}
 	} else {
 		if walletID != "" {
 			if err := SetContractWallet(sc, id, ecosystemID, recipient); err != nil {
@@ -518,6 +625,13 @@ func CreateContract(sc *SmartContract, name, value, conditions string, walletID,
 	if err := FlushContract(sc, root, id, false); err != nil {
 		return 0, err
 	}
+	if !sc.VDE {
+		if err = sc.insertContractVersion(id, name, contractVersion{major: 1}, value, sc.TxSmart.KeyID,
+			sc.blockID(), 0); err != nil {
+			return 0, err
+		}
+		sc.pinLastVersion(id, root.(*script.Block))
+	}
 	return id, nil
 }
 
@@ -604,6 +718,11 @@ func CreateTable(sc *SmartContract, name, columns, permissions string, applicati
 			}
 			condition = string(out)
 		}
+		references, _ := data[`references`].(string)
+		if condition, err = setColumnReferences(sc, name, data["type"].(string), condition,
+			references); err != nil {
+			return err
+		}
 		colperm[colname] = condition
 	}
 	colout, err := json.Marshal(colperm)
@@ -620,6 +739,11 @@ func CreateTable(sc *SmartContract, name, columns, permissions string, applicati
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating VDE tables")
 		return err
 	}
+	for colname, condition := range colperm {
+		if err = sc.createReferenceIndex(tableName, colname, condition); err != nil {
+			return err
+		}
+	}
 
 	var perm permTable
 	err = json.Unmarshal([]byte(permissions), &perm)
@@ -691,13 +815,48 @@ func columnType(colType string) (sqlColType string, err error) {
 		sqlColType = `decimal (30, 0) NOT NULL DEFAULT '0'`
 	case "text":
 		sqlColType = "text"
+	case "bool":
+		sqlColType = `boolean NOT NULL DEFAULT false`
+	case "date":
+		sqlColType = `date`
+	case "uuid":
+		sqlColType = `uuid`
+	case "number[]":
+		sqlColType = `bigint[] NOT NULL DEFAULT '{}'`
+	case "varchar[]":
+		sqlColType = `varchar(102400)[] NOT NULL DEFAULT '{}'`
 	default:
+		if precision, scale, ok := parseDecimalType(colType); ok {
+			sqlColType = fmt.Sprintf(`decimal (%d, %d) NOT NULL DEFAULT '0'`, precision, scale)
+			break
+		}
 		err = fmt.Errorf("Type '%s' of columns is not supported", colType)
 	}
 
 	return
 }
 
+// parseDecimalType parses the column type of the form decimal(precision,scale)
+func parseDecimalType(colType string) (precision, scale int64, ok bool) {
+	match := regexpDecimalType.FindStringSubmatch(colType)
+	if len(match) != 3 {
+		return
+	}
+	precision = converter.StrToInt64(match[1])
+	scale = converter.StrToInt64(match[2])
+	ok = precision > 0 && precision <= maxDecimalPrecision && scale <= precision
+	return
+}
+
+// isColumnType returns true if the type can be used for columns of ecosystem tables
+func isColumnType(colType string) bool {
+	if colType == `bytea` {
+		return true
+	}
+	_, err := columnType(colType)
+	return err == nil
+}
+
 // DBInsert inserts a record into the specified database table
 func DBInsert(sc *SmartContract, tblname string, params string, val ...interface{}) (qcost int64, ret int64, err error) {
 	if tblname == "system_parameters" {
@@ -721,11 +880,17 @@ func DBInsert(sc *SmartContract, tblname string, params string, val ...interface
 	if reflect.TypeOf(val[0]) == reflect.TypeOf([]interface{}{}) {
 		val = val[0].([]interface{})
 	}
-	qcost, lastID, err = sc.selectiveLoggingAndUpd(strings.Split(params, `,`), val, tblname, nil,
+	fields := strings.Split(params, `,`)
+	var refCost int64
+	if refCost, err = sc.checkReferences(tblname, fields, val); err != nil {
+		return
+	}
+	qcost, lastID, err = sc.selectiveLoggingAndUpd(fields, val, tblname, nil,
 		nil, !sc.VDE && sc.Rollback, false)
 	if ind > 0 {
 		qcost *= int64(ind)
 	}
+	qcost += refCost
 	if err == nil {
 		ret, _ = strconv.ParseInt(lastID, 10, 64)
 	}
@@ -855,6 +1020,11 @@ func DBSelect(sc *SmartContract, tblname string, columns string, id int64, order
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting rows columns")
 		return 0, nil, err
 	}
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting rows column types")
+		return 0, nil, err
+	}
 	values := make([][]byte, len(cols))
 	scanArgs := make([]interface{}, len(values))
 	for i := range values {
@@ -874,7 +1044,10 @@ func DBSelect(sc *SmartContract, tblname string, columns string, id int64, order
 			if col != nil {
 				value = string(col)
 			}
-			row[cols[i]] = value
+			if row[cols[i]], err = columnValue(colTypes[i].DatabaseTypeName(), value); err != nil {
+				log.WithFields(log.Fields{"type": consts.ConversionError, "error": err}).Error("converting column value")
+				return 0, nil, err
+			}
 		}
 		result = append(result, reflect.ValueOf(row).Interface())
 	}
@@ -895,6 +1068,30 @@ func DBSelect(sc *SmartContract, tblname string, columns string, id int64, order
 	return 0, result, nil
 }
 
+// columnValue converts the value of the column to the value of the contract
+func columnValue(dbType, value string) (interface{}, error) {
+	if len(value) == 0 {
+		return value, nil
+	}
+	switch {
+	case dbType == `DATE`:
+		if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+			return t.Format(`2006-01-02`), nil
+		}
+	case strings.HasPrefix(dbType, `_`):
+		var list pq.StringArray
+		if err := list.Scan([]byte(value)); err != nil {
+			return nil, err
+		}
+		ret := make([]interface{}, len(list))
+		for i, item := range list {
+			ret[i] = item
+		}
+		return ret, nil
+	}
+	return value, nil
+}
+
 // DBUpdate updates the item with the specified id in the table
 func DBUpdate(sc *SmartContract, tblname string, id int64, params string, val ...interface{}) (qcost int64, err error) {
 	if tblname == "system_parameters" {
@@ -913,7 +1110,35 @@ func DBUpdate(sc *SmartContract, tblname string, id int64, params string, val ..
 	if err = sc.AccessColumns(tblname, &columns, true); err != nil {
 		return
 	}
+	var refCost int64
+	if refCost, err = sc.checkReferences(tblname, columns, val); err != nil {
+		return
+	}
 	qcost, _, err = sc.selectiveLoggingAndUpd(columns, val, tblname, []string{`id`}, []string{converter.Int64ToStr(id)}, !sc.VDE && sc.Rollback, true)
+	qcost += refCost
+	return
+}
+
+// DBDelete deletes the record with the specified id from the database table
+func DBDelete(sc *SmartContract, tblname string, id int64) (qcost int64, err error) {
+	if tblname == "system_parameters" {
+		return 0, fmt.Errorf("system parameters access denied")
+	}
+
+	tblname = getDefTableName(sc, tblname)
+	if err = sc.AccessTable(tblname, "delete"); err != nil {
+		return
+	}
+	if strings.Contains(tblname, `_reports_`) {
+		err = fmt.Errorf(`Access denied to report table`)
+		return
+	}
+	var refCost int64
+	if refCost, err = sc.checkDependents(tblname, id); err != nil {
+		return
+	}
+	qcost, err = sc.deleteAndLog(tblname, id, !sc.VDE && sc.Rollback)
+	qcost += refCost
 	return
 }
 
@@ -1051,7 +1276,7 @@ func TableConditions(sc *SmartContract, name, columns, permissions string) (err
 	for i := 0; i < v.NumField(); i++ {
 		cond := v.Field(i).Interface().(string)
 		name := v.Type().Field(i).Name
-		if len(cond) == 0 && name != `Read` && name != `Filter` {
+		if len(cond) == 0 && name != `Read` && name != `Filter` && name != `Delete` {
 			log.WithFields(log.Fields{"condition_type": name, "type": consts.EmptyObject}).Error("condition is empty")
 			return fmt.Errorf(`%v condition is empty`, name)
 		}
@@ -1102,9 +1327,7 @@ func TableConditions(sc *SmartContract, name, columns, permissions string) (err
 			return fmt.Errorf(`worng column`)
 		}
 		itype := data[`type`].(string)
-		if itype != `varchar` && itype != `number` && itype != `datetime` && itype != `text` &&
-			itype != `bytea` && itype != `double` && itype != `json` && itype != `money` &&
-			itype != `character` {
+		if !isColumnType(itype) {
 			log.WithFields(log.Fields{"type": consts.InvalidObject}).Error("incorrect type")
 			return fmt.Errorf(`incorrect type`)
 		}
@@ -1213,9 +1436,7 @@ func ColumnCondition(sc *SmartContract, tableName, name, coltype, permissions st
 		log.WithFields(log.Fields{"size": count, "max_size": syspar.GetMaxColumns(), "type": consts.ParameterExceeded}).Error("Too many columns")
 		return fmt.Errorf(`Too many columns. Limit is %d`, syspar.GetMaxColumns())
 	}
-	if coltype != `varchar` && coltype != `number` && coltype != `datetime` &&
-		coltype != `character` && coltype != `json` &&
-		coltype != `text` && coltype != `bytea` && coltype != `double` && coltype != `money` {
+	if !isColumnType(coltype) {
 		log.WithFields(log.Fields{"column_type": coltype, "type": consts.InvalidObject}).Error("Unknown column type")
 		return fmt.Errorf(`incorrect type`)
 	}
@@ -1296,12 +1517,18 @@ func CreateColumn(sc *SmartContract, tableName, name, colType, permissions strin
 	if err != nil {
 		return
 	}
+	if permissions, err = setColumnReferences(sc, tableName, colType, permissions, ``); err != nil {
+		return
+	}
 
 	err = model.AlterTableAddColumn(sc.DbTransaction, tblname, name, sqlColType)
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("adding column to the table")
 		return
 	}
+	if err = sc.createReferenceIndex(tblname, name, permissions); err != nil {
+		return
+	}
 
 	tables := getDefTableName(sc, `tables`)
 	type cols struct {
@@ -1396,7 +1623,9 @@ func PermColumn(sc *SmartContract, tableName, name, permissions string) error {
 		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling columns permissions from json")
 		return err
 	}
-	perm[name] = permissions
+	if perm[name], err = keepColumnReferences(perm[name], permissions); err != nil {
+		return err
+	}
 	permout, err := json.Marshal(perm)
 	if err != nil {
 		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling column permissions to json")
@@ -1449,7 +1678,7 @@ func HMac(key, data string, raw_output bool) (ret string, err error) {
 	}
 }
 
-//Returns the array of keys of the map
+// Returns the array of keys of the map
 func GetMapKeys(in map[string]interface{}) []interface{} {
 	keys := make([]interface{}, 0, len(in))
 	for k := range in {
@@ -1458,7 +1687,7 @@ func GetMapKeys(in map[string]interface{}) []interface{} {
 	return keys
 }
 
-//Returns the sorted array of keys of the map
+// Returns the sorted array of keys of the map
 func SortedKeys(m map[string]interface{}) []interface{} {
 	i, sorted := 0, make([]string, len(m))
 	for k := range m {
@@ -1474,12 +1703,6 @@ func SortedKeys(m map[string]interface{}) []interface{} {
 	return ret
 }
 
-//Formats timestamp to specified date format
-func Date(time_format string, timestamp int64) string {
-	t := time.Unix(timestamp, 0)
-	return t.Format(time_format)
-}
-
 // HTTPRequest sends http request
 func HTTPRequest(requrl, method string, headers map[string]interface{},
 	params map[string]interface{}) (string, error) {
@@ -1736,16 +1959,8 @@ func EncodeBase64(input string) (out string) {
 
 // MD5 returns md5 hash sum of data
 func MD5(data interface{}) (string, error) {
-	var b []byte
-
-	switch v := data.(type) {
-	case []uint8:
-		b = []byte(v)
-	case string:
-		b = []byte(v)
-	default:
-		err := fmt.Errorf("Unsupported type %T", v)
-		log.WithFields(log.Fields{"type": consts.ConversionError, "error": err}).Error("converting to bytes")
+	b, err := dataToBytes(data)
+	if err != nil {
 		return "", err
 	}
 
@@ -1841,7 +2056,6 @@ func GetHistory(transaction *model.DbTransaction, ecosystem int64, tableName str
 		}
 		curVal[columns[i]] = value
 	}
-	rollbackList := []interface{}{}
 	rollbackTx := &model.RollbackTx{}
 	txs, err := rollbackTx.GetRollbackTxsByTableIDAndTableName(converter.Int64ToStr(id),
 		table, historyLimit)
@@ -1849,18 +2063,37 @@ func GetHistory(transaction *model.DbTransaction, ecosystem int64, tableName str
 		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("rollback history")
 		return nil, err
 	}
-	for _, tx := range *txs {
+	return historyFromRollback(curVal, *txs, idRollback, func(blockID int64) (string, error) {
+		block := model.Block{}
+		ok, err := block.Get(blockID)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block time")
+			return ``, err
+		}
+		if !ok {
+			return ``, nil
+		}
+		return time.Unix(block.Time, 0).Format(`2006-01-02 15:04:05`), nil
+	})
+}
+
+// historyFromRollback restores the previous values of the row from its rollback records, the last record is first.
+// The rollback records of history tables aren't pruned so the result is the same on all nodes
+func historyFromRollback(curVal map[string]string, txs []model.RollbackTx, idRollback int64,
+	blockTime func(int64) (string, error)) ([]interface{}, error) {
+	rollbackList := []interface{}{}
+	for _, tx := range txs {
 		if len(rollbackList) > 0 {
 			prev := rollbackList[len(rollbackList)-1].(map[string]string)
 			prev[`block_id`] = converter.Int64ToStr(tx.BlockID)
 			prev[`id`] = converter.Int64ToStr(tx.ID)
-			block := model.Block{}
-			if ok, err := block.Get(tx.BlockID); ok {
-				prev[`block_time`] = time.Unix(block.Time, 0).Format(`2006-01-02 15:04:05`)
-			} else if err != nil {
-				log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting block time")
+			bt, err := blockTime(tx.BlockID)
+			if err != nil {
 				return nil, err
 			}
+			if len(bt) > 0 {
+				prev[`block_time`] = bt
+			}
 			if idRollback == tx.ID {
 				return rollbackList[len(rollbackList)-1 : len(rollbackList)], nil
 			}
@@ -1868,14 +2101,20 @@ func GetHistory(transaction *model.DbTransaction, ecosystem int64, tableName str
 		if tx.Data == "" {
 			continue
 		}
-		rollback := make(map[string]string)
-		for k, v := range curVal {
-			rollback[k] = v
-		}
-		if err := json.Unmarshal([]byte(tx.Data), &rollback); err != nil {
+		values, deleted, err := model.ParseRollbackData(tx.Data)
+		if err != nil {
 			log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling rollbackTx.Data from JSON")
 			return nil, err
 		}
+		rollback := make(map[string]string)
+		if !deleted {
+			for k, v := range curVal {
+				rollback[k] = v
+			}
+		}
+		for k, v := range values {
+			rollback[k] = v
+		}
 		rollbackList = append(rollbackList, rollback)
 		curVal = rollback
 	}
@@ -1937,5 +2176,6 @@ func BlockTime(sc *SmartContract) string {
 	if sc.BlockData != nil {
 		blockTime = sc.BlockData.Time
 	}
-	return Date(`2006-01-02 15:04:05`, blockTime)
+	date, _ := Date(defaultDateLayout, blockTime)
+	return date
 }
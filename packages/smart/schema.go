@@ -0,0 +1,620 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package smart
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf/syspar"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/model/querycost"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// systemTables are the tables of ecosystem which structure can't be changed by contracts
+var systemTables = map[string]bool{
+	"contracts":          true,
+	"keys":               true,
+	"history":            true,
+	"languages":          true,
+	"menu":               true,
+	"pages":              true,
+	"blocks":             true,
+	"signatures":         true,
+	"members":            true,
+	"roles":              true,
+	"roles_participants": true,
+	"notifications":      true,
+	"sections":           true,
+	"applications":       true,
+	"binaries":           true,
+	"parameters":         true,
+	"app_params":         true,
+	"buffer_data":        true,
+	"tables":             true,
+}
+
+// schemaTable contains the information about the table which structure is being changed
+type schemaTable struct {
+	name    string // the name of table in the registry
+	tblname string // the name of table in the database
+	columns map[string]string
+}
+
+// getSchemaTable checks the access to change the structure of the table and returns its columns
+func getSchemaTable(sc *SmartContract, funcName, tableName string, contracts ...string) (*schemaTable, error) {
+	if !accessContracts(sc, contracts...) {
+		log.WithFields(log.Fields{"type": consts.IncorrectCallingContract}).Error(funcName + " can be only called from @1" + contracts[0])
+		return nil, fmt.Errorf(`%s can be only called from %s`, funcName, contracts[0])
+	}
+	tableName = converter.EscapeSQL(strings.ToLower(tableName))
+	if systemTables[tableName] {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "table": tableName}).Error("changing structure of system table")
+		return nil, fmt.Errorf(`the structure of the table %s cannot be changed`, tableName)
+	}
+	table := &schemaTable{name: tableName, tblname: getDefTableName(sc, tableName)}
+	prefix := converter.Int64ToStr(sc.TxSmart.EcosystemID)
+	if sc.VDE {
+		prefix += `_vde`
+	}
+	tEx := &model.Table{}
+	tEx.SetTablePrefix(prefix)
+	found, err := tEx.Get(sc.DbTransaction, tableName)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting table")
+		return nil, err
+	}
+	if !found {
+		log.WithFields(log.Fields{"type": consts.NotFound, "table": tableName}).Error("table does not exists")
+		return nil, fmt.Errorf(eTableNotFound, tableName)
+	}
+	if err = json.Unmarshal([]byte(tEx.Columns), &table.columns); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling columns from json")
+		return nil, err
+	}
+	if err = sc.AccessTable(table.tblname, "new_column"); err != nil {
+		return nil, err
+	}
+	if err = AllowChangeCondition(sc, `tables`); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// checkColumn returns the escaped name of the column if it is the column of the table
+func (table *schemaTable) checkColumn(name string) (string, error) {
+	name = converter.EscapeSQL(strings.ToLower(name))
+	if _, ok := table.columns[name]; !ok {
+		log.WithFields(log.Fields{"column_name": name, "type": consts.NotFound}).Error("column does not exists")
+		return ``, fmt.Errorf(`column %s doesn't exists`, name)
+	}
+	return name, nil
+}
+
+// updateColumns writes the columns of the table to the registry
+func (table *schemaTable) updateColumns(sc *SmartContract) error {
+	permout, err := json.Marshal(table.columns)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling columns to json")
+		return err
+	}
+	_, _, err = sc.selectiveLoggingAndUpd([]string{`columns`}, []interface{}{string(permout)},
+		getDefTableName(sc, `tables`), []string{`name`}, []string{table.name}, !sc.VDE && sc.Rollback, false)
+	return err
+}
+
+// schemaRollback saves the information for the rollback of changing the table structure
+func (sc *SmartContract) schemaRollback(tblname string, data *model.SchemaRollback) error {
+	if sc.VDE || !sc.Rollback {
+		return nil
+	}
+	out, err := json.Marshal(data)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling schema rollback to json")
+		return err
+	}
+	rollbackTx := &model.RollbackTx{
+		BlockID:   sc.BlockData.BlockID,
+		TxHash:    sc.TxHash,
+		NameTable: tblname,
+		TableID:   model.RollbackSchemaID,
+		Data:      string(out),
+	}
+	if err = rollbackTx.Create(sc.DbTransaction); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating schema rollback tx")
+	}
+	return err
+}
+
+// sqlColumnType returns the definition of the column type which can be used to restore the column
+func sqlColumnType(tblname, name string) (string, string, error) {
+	colType, err := model.GetColumnType(tblname, name)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting column type")
+		return ``, ``, err
+	}
+	sqlColType, err := columnType(colType)
+	if err != nil {
+		// bytea and other types which are not supported by CreateColumn
+		sqlColType = colType
+	}
+	return colType, sqlColType, nil
+}
+
+// isSafeCast returns true if all values of the column can be converted to the new type and back without loss
+func isSafeCast(from, to string) bool {
+	if from == to {
+		return false
+	}
+	fromPrecision, fromScale, fromDecimal := parseDecimalType(from)
+	toPrecision, toScale, toDecimal := parseDecimalType(to)
+	switch from {
+	case `number`:
+		// bigint has 19 digits
+		return to == `money` || to == `varchar` || to == `text` ||
+			(toDecimal && toPrecision-toScale >= 19)
+	case `money`:
+		return to == `varchar` || to == `text` || (toDecimal && toPrecision-toScale >= 30)
+	case `character`, `uuid`:
+		return to == `varchar` || to == `text`
+	case `varchar`:
+		return to == `text`
+	case `date`:
+		return to == `datetime`
+	}
+	if fromDecimal {
+		return to == `varchar` || to == `text` ||
+			(toDecimal && toScale >= fromScale && toPrecision-toScale >= fromPrecision-fromScale)
+	}
+	return false
+}
+
+// DropColumn removes the column from the table
+func DropColumn(sc *SmartContract, tableName, name string) error {
+	table, err := getSchemaTable(sc, `DropColumn`, tableName, `DelColumn`)
+	if err != nil {
+		return err
+	}
+	if name, err = table.checkColumn(name); err != nil {
+		return err
+	}
+	indexes, err := model.GetColumnIndexes(sc.DbTransaction, table.tblname, name)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("checking index of column")
+		return err
+	}
+	for _, index := range indexes {
+		// the index of references is dropped together with the column
+		if index != referenceIndexName(table.tblname, name)+`_index` {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "column_name": name}).Error("dropping indexed column")
+			return fmt.Errorf(`column %s is used in the index`, name)
+		}
+	}
+	if err = sc.dropReferenceIndex(table.tblname, name); err != nil {
+		return err
+	}
+	_, sqlColType, err := sqlColumnType(table.tblname, name)
+	if err != nil {
+		return err
+	}
+	values, err := model.GetColumnValues(sc.DbTransaction, table.tblname, name)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting column values")
+		return err
+	}
+	if err = sc.schemaRollback(table.tblname, &model.SchemaRollback{Action: model.SchemaDropColumn,
+		Column: name, Type: sqlColType, Values: values}); err != nil {
+		return err
+	}
+	if err = model.AlterTableDropColumn(sc.DbTransaction, table.tblname, name); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("dropping column of the table")
+		return err
+	}
+	delete(table.columns, name)
+	return table.updateColumns(sc)
+}
+
+// RenameColumn changes the name of the column
+func RenameColumn(sc *SmartContract, tableName, name, newName string) error {
+	table, err := getSchemaTable(sc, `RenameColumn`, tableName, `EditColumnName`)
+	if err != nil {
+		return err
+	}
+	if name, err = table.checkColumn(name); err != nil {
+		return err
+	}
+	newName = converter.EscapeSQL(strings.ToLower(newName))
+	if err = checkColumnName(newName); err != nil {
+		return err
+	}
+	if _, ok := table.columns[newName]; ok || newName == `id` {
+		log.WithFields(log.Fields{"column_name": newName, "type": consts.Found}).Error("column exists")
+		return fmt.Errorf(`column %s exists`, newName)
+	}
+	if err = sc.schemaRollback(table.tblname, &model.SchemaRollback{Action: model.SchemaRenameColumn,
+		Column: newName, Name: name}); err != nil {
+		return err
+	}
+	if err = model.AlterTableRenameColumn(sc.DbTransaction, table.tblname, name, newName); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("renaming column of the table")
+		return err
+	}
+	table.columns[newName] = table.columns[name]
+	delete(table.columns, name)
+	return table.updateColumns(sc)
+}
+
+// AlterColumnType changes the type of the column if the values can be converted without loss
+func AlterColumnType(sc *SmartContract, tableName, name, colType string) error {
+	table, err := getSchemaTable(sc, `AlterColumnType`, tableName, `EditColumnType`)
+	if err != nil {
+		return err
+	}
+	if name, err = table.checkColumn(name); err != nil {
+		return err
+	}
+	oldType, oldSQLType, err := sqlColumnType(table.tblname, name)
+	if err != nil {
+		return err
+	}
+	if !isSafeCast(oldType, colType) {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "from": oldType, "to": colType}).Error("unsafe cast of column type")
+		return fmt.Errorf(`type %s cannot be changed to %s`, oldType, colType)
+	}
+	sqlColType, err := columnType(colType)
+	if err != nil {
+		return err
+	}
+	if err = sc.schemaRollback(table.tblname, &model.SchemaRollback{Action: model.SchemaAlterColumn,
+		Column: name, Type: oldSQLType}); err != nil {
+		return err
+	}
+	if err = model.AlterTableColumnType(sc.DbTransaction, table.tblname, name, sqlColType); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("changing type of column")
+		return err
+	}
+	return nil
+}
+
+// CreateIndex creates the index on the columns of the table, columns are separated by commas
+func CreateIndex(sc *SmartContract, tableName, name, columns string) error {
+	table, err := getSchemaTable(sc, `CreateIndex`, tableName, `NewIndex`)
+	if err != nil {
+		return err
+	}
+	name = converter.EscapeSQL(strings.ToLower(name))
+	if err = checkColumnName(name); err != nil {
+		return err
+	}
+	indexName := table.tblname + `_` + name
+	if exist, err := model.GetIndexColumns(sc.DbTransaction, indexName, table.tblname); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting index columns")
+		return err
+	} else if len(exist) > 0 {
+		log.WithFields(log.Fields{"type": consts.Found, "index": name}).Error("index exists")
+		return fmt.Errorf(`index %s exists`, name)
+	}
+	cols := make([]string, 0)
+	for _, col := range strings.Split(columns, `,`) {
+		if col, err = table.checkColumn(strings.TrimSpace(col)); err != nil {
+			return err
+		}
+		cols = append(cols, col)
+	}
+	count, err := model.NumIndexes(table.tblname)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting count of indexes")
+		return err
+	}
+	if count+len(cols) > syspar.GetMaxIndexes() {
+		log.WithFields(log.Fields{"size": count + len(cols), "max_size": syspar.GetMaxIndexes(), "type": consts.ParameterExceeded}).Error("Too many indexes")
+		return fmt.Errorf(`Too many indexes. Limit is %d`, syspar.GetMaxIndexes())
+	}
+	if err = sc.schemaRollback(table.tblname, &model.SchemaRollback{Action: model.SchemaCreateIndex,
+		Name: indexName}); err != nil {
+		return err
+	}
+	if err = model.CreateIndex(sc.DbTransaction, indexName, table.tblname,
+		`"`+strings.Join(cols, `","`)+`"`); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating index")
+		return err
+	}
+	return nil
+}
+
+// DropIndex removes the index which has been created with CreateIndex
+func DropIndex(sc *SmartContract, tableName, name string) error {
+	table, err := getSchemaTable(sc, `DropIndex`, tableName, `DelIndex`)
+	if err != nil {
+		return err
+	}
+	indexName := table.tblname + `_` + converter.EscapeSQL(strings.ToLower(name))
+	cols, err := model.GetIndexColumns(sc.DbTransaction, indexName, table.tblname)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting index columns")
+		return err
+	}
+	if len(cols) == 0 {
+		log.WithFields(log.Fields{"type": consts.NotFound, "index": name}).Error("index does not exists")
+		return fmt.Errorf(`index %s doesn't exists`, name)
+	}
+	if err = sc.schemaRollback(table.tblname, &model.SchemaRollback{Action: model.SchemaDropIndex,
+		Name: indexName, Columns: cols}); err != nil {
+		return err
+	}
+	if err = model.DropIndex(sc.DbTransaction, indexName); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("dropping index")
+		return err
+	}
+	return nil
+}
+
+// setColumnReferences checks the table referenced by the column and adds it to the column permissions
+func setColumnReferences(sc *SmartContract, tableName, colType, permissions, references string) (string, error) {
+	perm, err := getPermColumns(permissions)
+	if err != nil {
+		return ``, err
+	}
+	if len(references) > 0 {
+		perm.References = references
+	}
+	if len(perm.References) == 0 {
+		return permissions, nil
+	}
+	if colType != `number` {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "column_type": colType}).Error("column with references must be number")
+		return ``, fmt.Errorf(`column which references the table must have number type`)
+	}
+	perm.References = converter.EscapeSQL(strings.ToLower(perm.References))
+	if perm.References != strings.ToLower(tableName) {
+		isCustom, err := sc.IsCustomTable(getDefTableName(sc, perm.References))
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("checking custom table")
+			return ``, err
+		}
+		if !isCustom {
+			log.WithFields(log.Fields{"type": consts.NotFound, "table": perm.References}).Error("referenced table does not exists")
+			return ``, fmt.Errorf(eTableNotFound, perm.References)
+		}
+	}
+	out, err := json.Marshal(perm)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling column permissions to json")
+		return ``, err
+	}
+	return string(out), nil
+}
+
+// keepColumnReferences returns the new column permissions with the references of the old ones
+func keepColumnReferences(oldPermissions, permissions string) (string, error) {
+	oldPerm, err := getPermColumns(oldPermissions)
+	if err != nil {
+		return ``, err
+	}
+	perm, err := getPermColumns(permissions)
+	if err != nil {
+		return ``, err
+	}
+	if perm.References == oldPerm.References {
+		return permissions, nil
+	}
+	perm.References = oldPerm.References
+	out, err := json.Marshal(perm)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling column permissions to json")
+		return ``, err
+	}
+	return string(out), nil
+}
+
+// parseColumnReferences returns the tables referenced by the columns of the table
+func parseColumnReferences(columns string) (map[string]string, error) {
+	var cols map[string]string
+	if err := json.Unmarshal([]byte(columns), &cols); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling columns from json")
+		return nil, err
+	}
+	refs := make(map[string]string)
+	for col, cond := range cols {
+		if !strings.HasPrefix(cond, `{`) {
+			continue
+		}
+		perm, err := getPermColumns(cond)
+		if err != nil {
+			return nil, err
+		}
+		if len(perm.References) > 0 {
+			refs[col] = perm.References
+		}
+	}
+	return refs, nil
+}
+
+// referenceIndexName returns the name of the index which is created on the column with references
+func referenceIndexName(tblname, column string) string {
+	return tblname + `_` + column + `_ref`
+}
+
+// createReferenceIndex creates the index which is used to find the rows referencing the row being deleted
+func (sc *SmartContract) createReferenceIndex(tblname, column, permissions string) error {
+	perm, err := getPermColumns(permissions)
+	if err != nil || len(perm.References) == 0 {
+		return err
+	}
+	if err = model.CreateIndex(sc.DbTransaction, referenceIndexName(tblname, column), tblname,
+		`"`+column+`"`); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "column": column}).Error("creating index of references")
+		return err
+	}
+	return nil
+}
+
+// dropReferenceIndex removes the index of the column with references before the column is dropped
+func (sc *SmartContract) dropReferenceIndex(tblname, column string) error {
+	indexName := referenceIndexName(tblname, column)
+	cols, err := model.GetIndexColumns(sc.DbTransaction, indexName, tblname)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting index columns")
+		return err
+	}
+	if len(cols) == 0 {
+		return nil
+	}
+	if err = sc.schemaRollback(tblname, &model.SchemaRollback{Action: model.SchemaDropIndex,
+		Name: indexName, Columns: cols}); err != nil {
+		return err
+	}
+	if err = model.DropIndex(sc.DbTransaction, indexName); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("dropping index of references")
+		return err
+	}
+	return nil
+}
+
+// referenceQuery returns the query which finds the row referenced by the column
+func referenceQuery(prefix, ref string) string {
+	return `SELECT id FROM "` + prefix + `_` + ref + `" WHERE id = ?`
+}
+
+// dependentQuery returns the query which finds the row of the table referencing the row by the column
+func dependentQuery(prefix, table, column string) string {
+	return `SELECT id FROM "` + prefix + `_` + table + `" WHERE "` + column + `" = ? LIMIT 1`
+}
+
+// checkReferences checks that the rows referenced by the values of the columns exist.
+// It returns the cost of the queries
+func (sc *SmartContract) checkReferences(tblname string, fields []string, values []interface{}) (int64, error) {
+	prefix, name := PrefixName(tblname)
+	if len(prefix) == 0 {
+		return 0, nil
+	}
+	tables := &model.Table{}
+	tables.SetTablePrefix(prefix)
+	found, err := tables.Get(sc.DbTransaction, name)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting table columns")
+		return 0, err
+	}
+	if !found {
+		return 0, nil
+	}
+	refs, err := parseColumnReferences(tables.Columns)
+	if err != nil || len(refs) == 0 {
+		return 0, err
+	}
+	var qcost int64
+	queryCoster := querycost.GetQueryCoster(querycost.FormulaQueryCosterType)
+	for i, field := range fields {
+		field = strings.TrimSpace(strings.ToLower(field))
+		if len(field) > 0 && (field[0] == '+' || field[0] == '-') {
+			if _, ok := refs[field[1:]]; ok {
+				return 0, fmt.Errorf(`column %s references the table and cannot be changed arithmetically`, field[1:])
+			}
+			continue
+		}
+		ref, ok := refs[field]
+		if !ok || i >= len(values) {
+			continue
+		}
+		id, err := converter.ValueToInt(values[i])
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.ConversionError, "error": err, "column": field}).Error("converting reference to int")
+			return 0, err
+		}
+		if id == 0 {
+			continue
+		}
+		query := referenceQuery(prefix, ref)
+		cost, err := queryCoster.QueryCost(sc.DbTransaction, query, id)
+		if err != nil {
+			return 0, err
+		}
+		qcost += cost
+		row, err := model.GetOneRowTransaction(sc.DbTransaction, query, id).String()
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting referenced row")
+			return 0, err
+		}
+		if len(row) == 0 {
+			log.WithFields(log.Fields{"type": consts.NotFound, "table": ref, "id": id}).Error("referenced row does not exists")
+			return 0, fmt.Errorf(`item %d of the table %s referenced by column %s has not been found`, id, ref, field)
+		}
+	}
+	return qcost, nil
+}
+
+// checkDependents checks that the row of the table isn't referenced by rows of other tables.
+// It returns the cost of the queries
+func (sc *SmartContract) checkDependents(tblname string, id int64) (int64, error) {
+	prefix, name := PrefixName(tblname)
+	if len(prefix) == 0 {
+		return 0, nil
+	}
+	queryCoster := querycost.GetQueryCoster(querycost.FormulaQueryCosterType)
+	query := `SELECT name, columns FROM "` + prefix + `_tables" ORDER BY name`
+	qcost, err := queryCoster.QueryCost(sc.DbTransaction, query)
+	if err != nil {
+		return 0, err
+	}
+	tables, err := model.GetAllTx(sc.DbTransaction, query, -1)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting tables")
+		return 0, err
+	}
+	for _, table := range tables {
+		refs, err := parseColumnReferences(table[`columns`])
+		if err != nil {
+			return 0, err
+		}
+		cols := make([]string, 0, len(refs))
+		for col, ref := range refs {
+			if ref == name {
+				cols = append(cols, col)
+			}
+		}
+		sort.Strings(cols)
+		for _, col := range cols {
+			query = dependentQuery(prefix, table[`name`], col)
+			cost, err := queryCoster.QueryCost(sc.DbTransaction, query, id)
+			if err != nil {
+				return 0, err
+			}
+			qcost += cost
+			row, err := model.GetOneRowTransaction(sc.DbTransaction, query, id).String()
+			if err != nil {
+				log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting dependent row")
+				return 0, err
+			}
+			if len(row) > 0 {
+				log.WithFields(log.Fields{"type": consts.InvalidObject, "table": table[`name`], "column": col}).Error("row is referenced")
+				return 0, fmt.Errorf(`item %d of the table %s is referenced by the table %s`, id, name, table[`name`])
+			}
+		}
+	}
+	return qcost, nil
+}
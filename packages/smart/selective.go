@@ -26,6 +26,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
@@ -33,6 +34,8 @@ import (
 	"github.com/GenesisCommunity/go-genesis/packages/model"
 	"github.com/GenesisCommunity/go-genesis/packages/model/querycost"
 
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -69,6 +72,13 @@ func (sc *SmartContract) selectiveLoggingAndUpd(fields []string, ivalues []inter
 					ivalues[i] = vbyte
 				}
 			}
+		case bool:
+			ivalues[i] = strconv.FormatBool(v.(bool))
+		case []interface{}:
+			if ivalues[i], err = arrayToSQL(v.([]interface{})); err != nil {
+				logger.WithFields(log.Fields{"type": consts.ConversionError, "error": err}).Error("converting array to sql")
+				return 0, ``, err
+			}
 		}
 	}
 
@@ -308,6 +318,94 @@ func (sc *SmartContract) selectiveLoggingAndUpd(fields []string, ivalues []inter
 	return cost, tableID, nil
 }
 
+// arrayToSQL converts the array of the contract to the literal of postgres array
+func arrayToSQL(list []interface{}) (string, error) {
+	items := make([]interface{}, len(list))
+	for i, item := range list {
+		switch v := item.(type) {
+		case bool:
+			items[i] = strconv.FormatBool(v)
+		case decimal.Decimal:
+			items[i] = v.String()
+		case []interface{}, map[string]interface{}:
+			return ``, fmt.Errorf(`Type %T cannot be an element of array`, v)
+		default:
+			items[i] = v
+		}
+	}
+	value, err := pq.GenericArray{A: items}.Value()
+	if err != nil {
+		return ``, err
+	}
+	return value.(string), nil
+}
+
 func escapeSingleQuotes(val string) string {
 	return strings.Replace(val, `'`, `''`, -1)
 }
+
+// deleteAndLog deletes the record with the specified id and saves all its values for the rollback
+func (sc *SmartContract) deleteAndLog(table string, id int64, generalRollback bool) (int64, error) {
+	queryCoster := querycost.GetQueryCoster(querycost.FormulaQueryCosterType)
+	logger := sc.GetLogger()
+
+	if generalRollback && sc.BlockData == nil {
+		logger.WithFields(log.Fields{"type": consts.EmptyObject}).Error("Block is undefined")
+		return 0, fmt.Errorf(`It is impossible to write to DB when Block is undefined`)
+	}
+	tableID := converter.Int64ToStr(id)
+	where := ` WHERE id='` + tableID + `'`
+	selectQuery := `SELECT * FROM "` + table + `"` + where
+	selectCost, err := queryCoster.QueryCost(sc.DbTransaction, selectQuery)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": selectQuery}).Error("getting query total cost")
+		return 0, err
+	}
+	logData, err := model.GetOneRowTransaction(sc.DbTransaction, selectQuery).String()
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": selectQuery}).Error("getting one row transaction")
+		return 0, err
+	}
+	if len(logData) == 0 {
+		logger.WithFields(log.Fields{"type": consts.NotFound, "query": selectQuery}).Error("deleting not existing record")
+		return 0, errNotFound
+	}
+	deleteQuery := `DELETE FROM "` + table + `"` + where
+	deleteCost, err := queryCoster.QueryCost(sc.DbTransaction, deleteQuery)
+	if err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": deleteQuery}).Error("getting query total cost for delete query")
+		return 0, err
+	}
+	if err = model.Delete(sc.DbTransaction, table, where); err != nil {
+		logger.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": deleteQuery}).Error("executing delete query")
+		return 0, err
+	}
+	if generalRollback {
+		// unlike the rollback of update the data contains the whole row, so the record will be inserted again
+		rollbackInfo := make(map[string]string)
+		for k, v := range logData {
+			if converter.IsByteColumn(table, k) && v != "" {
+				rollbackInfo[k] = string(converter.BinToHex([]byte(v)))
+			} else {
+				rollbackInfo[k] = v
+			}
+		}
+		jsonRollbackInfo, err := json.Marshal(&model.DeletedRowRollback{Op: model.RollbackDelete, Row: rollbackInfo})
+		if err != nil {
+			logger.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling rollback info to json")
+			return 0, err
+		}
+		rollbackTx := &model.RollbackTx{
+			BlockID:   sc.BlockData.BlockID,
+			TxHash:    sc.TxHash,
+			NameTable: table,
+			TableID:   tableID,
+			Data:      string(jsonRollbackInfo),
+		}
+		if err = rollbackTx.Create(sc.DbTransaction); err != nil {
+			logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating rollback tx")
+			return 0, err
+		}
+	}
+	return selectCost + deleteCost, nil
+}
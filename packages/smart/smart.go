@@ -166,7 +166,11 @@ func VMRun(vm *script.VM, block *script.Block, params []interface{}, extend *map
 	rt := vm.RunInit(cost)
 	ret, err = rt.Run(block, params, extend)
 	if err != nil {
-		log.WithFields(log.Fields{"type": consts.VMError, "error": err}).Error("running block in smart vm")
+		logger := log.WithFields(log.Fields{"type": consts.VMError, "error": err})
+		if srcErr, ok := err.(*script.SourceError); ok {
+			logger = logger.WithFields(log.Fields{"func": srcErr.Func, "line": srcErr.Line})
+		}
+		logger.Error("running block in smart vm")
 	}
 	if ecost, ok := (*extend)[`txcost`]; ok && cost > ecost.(int64) {
 		extcost = cost - ecost.(int64)
@@ -358,7 +362,14 @@ func LoadContracts(transaction *model.DbTransaction) error {
 
 func LoadSysFuncs(vm *script.VM, state int) error {
 	code := `func DBFind(table string).Columns(columns string).Where(where string, params ...)
-	.WhereId(id int).Order(order string).Limit(limit int).Offset(offset int).Ecosystem(ecosystem int) array {
+	.WhereId(id int).Order(order string).Limit(limit int).Offset(offset int).Ecosystem(ecosystem int)
+	.GroupBy(groupBy string).Having(having string) array {
+   if groupBy || having || Contains(columns, "(") {
+      if id {
+         error "WhereId cannot be used with GroupBy, Having or aggregate functions"
+      }
+      return DBAggregate(table, columns, groupBy, having, order, offset, limit, ecosystem, where, params)
+   }
    return DBSelect(table, columns, id, order, offset, limit, ecosystem, where, params)
 }
 
@@ -467,6 +478,9 @@ func LoadContract(transaction *model.DbTransaction, prefix string) (err error) {
 			log.WithFields(log.Fields{"contract_name": names, "contract_id": item["id"], "contract_active": item["active"]}).Info("OK Loading Contract")
 		}
 	}
+	if err = loadContractVersions(transaction, prefix); err != nil {
+		return err
+	}
 	LoadVDEContracts(transaction, prefix)
 	return
 }
@@ -618,6 +632,10 @@ func (sc *SmartContract) AccessTablePerm(table, action string) (map[string]strin
 		logger.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting table permissions")
 		return tablePermission, err
 	}
+	if action == `delete` && len(tablePermission[action]) == 0 {
+		logger.WithFields(log.Fields{"table": table, "type": consts.AccessDenied}).Error("delete permission is not defined")
+		return tablePermission, errAccessDenied
+	}
 	if len(tablePermission[action]) > 0 {
 		ret, err := sc.EvalIf(tablePermission[action])
 		if err != nil {
@@ -821,6 +839,54 @@ func (sc *SmartContract) GetContractLimit() (ret int64) {
 	return sc.TxCost
 }
 
+// callMethods runs the functions of the contract according to the specified flags and returns its result
+func (sc *SmartContract) callMethods(flags int) (result string, err error) {
+	methods := []string{`init`, `conditions`, `action`, `rollback`}
+
+	_, nameContract := script.ParseContract(sc.TxContract.Name)
+	(*sc.TxContract.Extend)[`original_contract`] = nameContract
+	(*sc.TxContract.Extend)[`this_contract`] = nameContract
+
+	sc.TxContract.FreeRequest = false
+	for i := uint32(0); i < 4; i++ {
+		if (flags & (1 << i)) > 0 {
+			cfunc := sc.TxContract.GetFunc(methods[i])
+			if cfunc == nil {
+				continue
+			}
+			sc.TxContract.Called = 1 << i
+			if _, err = VMRun(sc.VM, cfunc, nil, sc.TxContract.Extend); err != nil {
+				return
+			}
+		}
+	}
+	if (*sc.TxContract.Extend)[`result`] != nil {
+		result = fmt.Sprint((*sc.TxContract.Extend)[`result`])
+		if len(result) > 255 {
+			result = result[:255]
+		}
+	}
+	return
+}
+
+// RunContract calls the contract functions according to the specified flags without checking
+// the signature and paying for the fuel. It is used for testing contracts.
+func (sc *SmartContract) RunContract(flags int) (string, error) {
+	sc.TxContract.Extend = sc.getExtend()
+	sc.AppendStack(sc.TxContract.Name)
+	sc.VM = GetVM()
+
+	sc.TxFuelCalls = nil
+	sc.TxFuelLimit = (*sc.TxContract.Extend)[`txcost`].(int64)
+	result, err := sc.callMethods(flags)
+	sc.TxFuel = sc.TxFuelLimit - (*sc.TxContract.Extend)[`txcost`].(int64)
+	sc.TxUsedCost = decimal.New(sc.TxFuel, 0)
+	if err != nil {
+		return ``, script.SetVMErrorInfo(err, sc.stackNames())
+	}
+	return result, nil
+}
+
 // CallContract calls the contract functions according to the specified flags
 func (sc *SmartContract) CallContract(flags int) (string, error) {
 	var (
@@ -835,14 +901,9 @@ func (sc *SmartContract) CallContract(flags int) (string, error) {
 	sc.TxContract.Extend = sc.getExtend()
 
 	retError := func(err error) (string, error) {
-		eText := err.Error()
-		if !strings.HasPrefix(eText, `{`) {
-			err = script.SetVMError(`panic`, eText)
-		}
-		return ``, err
+		return ``, script.SetVMErrorInfo(err, sc.stackNames())
 	}
 
-	methods := []string{`init`, `conditions`, `action`, `rollback`}
 	sc.AppendStack(sc.TxContract.Name)
 	sc.VM = GetVM()
 	if (flags&CallRollback) == 0 && (flags&CallAction) != 0 {
@@ -1007,6 +1068,8 @@ func (sc *SmartContract) CallContract(flags int) (string, error) {
 		}
 	}
 	before := (*sc.TxContract.Extend)[`txcost`].(int64)
+	sc.TxFuelLimit = before
+	sc.TxFuelCalls = nil
 
 	// Payment for the size
 	(*sc.TxContract.Extend)[`txcost`] = (*sc.TxContract.Extend)[`txcost`].(int64) - sizeFuel
@@ -1015,36 +1078,15 @@ func (sc *SmartContract) CallContract(flags int) (string, error) {
 		return retError(ErrCurrentBalance)
 	}
 
-	_, nameContract := script.ParseContract(sc.TxContract.Name)
-	(*sc.TxContract.Extend)[`original_contract`] = nameContract
-	(*sc.TxContract.Extend)[`this_contract`] = nameContract
-
-	sc.TxContract.FreeRequest = false
-	for i := uint32(0); i < 4; i++ {
-		if (flags & (1 << i)) > 0 {
-			cfunc := sc.TxContract.GetFunc(methods[i])
-			if cfunc == nil {
-				continue
-			}
-			sc.TxContract.Called = 1 << i
-			_, err = VMRun(sc.VM, cfunc, nil, sc.TxContract.Extend)
-			if err != nil {
-				price = 0
-				break
-			}
-		}
+	if result, err = sc.callMethods(flags); err != nil {
+		price = 0
 	}
 	sc.TxFuel = before - (*sc.TxContract.Extend)[`txcost`].(int64)
 	sc.TxUsedCost = decimal.New(sc.TxFuel+price, 0)
-	if (*sc.TxContract.Extend)[`result`] != nil {
-		result = fmt.Sprint((*sc.TxContract.Extend)[`result`])
-		if len(result) > 255 {
-			result = result[:255]
-		}
-	}
 
 	if (flags&CallRollback) == 0 && (flags&CallAction) != 0 && sc.TxSmart.EcosystemID > 0 && !sc.VDE && !conf.Config.IsPrivateBlockchain() {
 		apl := sc.TxUsedCost.Mul(fuelRate)
+		logger.WithFields(log.Fields{"limit": sc.TxFuelLimit, "used": sc.TxFuel}).Debug("fuel of contract")
 
 		wltAmount, ierr := decimal.NewFromString(payWallet.Amount)
 		if ierr != nil {
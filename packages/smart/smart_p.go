@@ -49,7 +49,11 @@ var (
 		"DBUpdate":         {},
 		"DBUpdateSysParam": {},
 		"DBUpdateExt":      {},
+		"DBDelete":         {},
 		"DBSelect":         {},
+		"DBAggregate":      {},
+		"RegexpMatch":      {},
+		"RegexpReplace":    {},
 	}
 
 	extendCostSysParams = map[string]string{
@@ -57,6 +61,17 @@ var (
 		"IdToAddress":       "extend_cost_id_to_address",
 		"NewState":          "extend_cost_new_state",
 		"Sha256":            "extend_cost_sha256",
+		"Sha3":              "extend_cost_sha3",
+		"Keccak256":         "extend_cost_keccak256",
+		"VerifySignature":   "extend_cost_verify_signature",
+		"RecoverAddress":    "extend_cost_recover_address",
+		"Date":              "extend_cost_date",
+		"ParseDate":         "extend_cost_date",
+		"DateAdd":           "extend_cost_date",
+		"DateDiff":          "extend_cost_date",
+		"RegexpMatch":       "extend_cost_regexp",
+		"RegexpReplace":     "extend_cost_regexp",
+		"FormatMoney":       "extend_cost_format_money",
 		"PubToID":           "extend_cost_pub_to_id",
 		"EcosysParam":       "extend_cost_ecosys_param",
 		"SysParamString":    "extend_cost_sys_param_string",
@@ -80,6 +95,11 @@ var (
 		"PermTable":         "extend_cost_perm_table",
 		"ColumnCondition":   "extend_cost_column_condition",
 		"CreateColumn":      "extend_cost_create_column",
+		"DropColumn":        "extend_cost_create_column",
+		"RenameColumn":      "extend_cost_create_column",
+		"AlterColumnType":   "extend_cost_create_column",
+		"CreateIndex":       "extend_cost_create_table",
+		"DropIndex":         "extend_cost_create_column",
 		"PermColumn":        "extend_cost_perm_column",
 		"JSONToMap":         "extend_cost_json_to_map",
 		"GetContractByName": "extend_cost_contract_by_name",
@@ -95,7 +115,7 @@ const (
 	nNewContract        = "NewContract"
 )
 
-//SignRes contains the data of the signature
+// SignRes contains the data of the signature
 type SignRes struct {
 	Param string `json:"name"`
 	Text  string `json:"text"`
@@ -183,6 +203,13 @@ func UpdateSysParam(sc *SmartContract, name, value, conditions string) (int64, e
 				}
 			}
 			checked = true
+		case syspar.StateRootBlockID:
+			// the state root can be scheduled only for the future blocks and can't be changed after activation
+			var blockID int64
+			if sc.BlockData != nil {
+				blockID = sc.BlockData.BlockID
+			}
+			ok = !syspar.HasStateRoot(blockID) && (ival == 0 || ival > blockID)
 		case syspar.FullNodes:
 			fnodes := []syspar.FullNode{}
 			if err := json.Unmarshal([]byte(value), &fnodes); err != nil {
@@ -243,7 +270,12 @@ func DBUpdateExt(sc *SmartContract, tblname string, column string, value interfa
 	if err = sc.AccessColumns(tblname, &columns, true); err != nil {
 		return
 	}
+	var refCost int64
+	if refCost, err = sc.checkReferences(tblname, columns, val); err != nil {
+		return
+	}
 	qcost, _, err = sc.selectiveLoggingAndUpd(columns, val, tblname, []string{column}, []string{fmt.Sprint(value)}, !sc.VDE && sc.Rollback, true)
+	qcost += refCost
 	return
 }
 
@@ -672,7 +704,7 @@ func RollbackColumn(sc *SmartContract, tableName, name string) error {
 		// if there is not such hash then NewColumn was faulty. Do nothing.
 		return nil
 	}
-	return model.AlterTableDropColumn(getDefTableName(sc, tableName), name)
+	return model.AlterTableDropColumn(sc.DbTransaction, getDefTableName(sc, tableName), name)
 }
 
 // Size returns the length of the string
@@ -771,6 +803,7 @@ func RollbackContract(sc *SmartContract, name string) error {
 			sc.VM.Children = sc.VM.Children[:id]
 		}
 		delete(sc.VM.Objects, c.Name)
+		unpinContract(sc.VM, c.Name)
 	}
 
 	return nil
@@ -850,6 +883,7 @@ func RollbackEditContract(sc *SmartContract) error {
 			log.WithFields(log.Fields{"type": consts.VMError, "error": err}).Error("flushing contract")
 			return err
 		}
+		return sc.rollbackContractVersion(owner.TableID, root.(*script.Block))
 	} else if len(fields["wallet_id"]) > 0 {
 		return SetContractWallet(sc, converter.StrToInt64(rollbackTx.TableID), sc.TxSmart.EcosystemID,
 			converter.StrToInt64(fields["wallet_id"]))
@@ -22,9 +22,23 @@
 package smart
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/model/querycost"
 	"github.com/GenesisCommunity/go-genesis/packages/script"
+
+	"github.com/stretchr/testify/require"
 )
 
 type TestSmart struct {
@@ -96,3 +110,423 @@ func TestCheckAppend(t *testing.T) {
 	_, err := Run(cfunc, nil, &map[string]interface{}{})
 	require.NoError(t, err)
 }
+
+func TestColumnType(t *testing.T) {
+	for colType, sqlType := range map[string]string{
+		`bool`:           `boolean NOT NULL DEFAULT false`,
+		`date`:           `date`,
+		`uuid`:           `uuid`,
+		`number[]`:       `bigint[] NOT NULL DEFAULT '{}'`,
+		`decimal(20,4)`:  `decimal (20, 4) NOT NULL DEFAULT '0'`,
+		`decimal(10, 0)`: `decimal (10, 0) NOT NULL DEFAULT '0'`,
+	} {
+		ret, err := columnType(colType)
+		require.NoError(t, err, colType)
+		require.Equal(t, sqlType, ret)
+	}
+	for _, colType := range []string{`boolean`, `decimal(4,5)`, `decimal(0,0)`, `decimal(101,2)`, `decimal`} {
+		_, err := columnType(colType)
+		require.Error(t, err, colType)
+	}
+}
+
+func TestArrayToSQL(t *testing.T) {
+	ret, err := arrayToSQL([]interface{}{int64(1), `two`, true})
+	require.NoError(t, err)
+	require.Equal(t, `{1,"two","true"}`, ret)
+
+	ret, err = arrayToSQL([]interface{}{})
+	require.NoError(t, err)
+	require.Equal(t, `{}`, ret)
+
+	_, err = arrayToSQL([]interface{}{[]interface{}{1}})
+	require.Error(t, err)
+}
+
+func TestIsSafeCast(t *testing.T) {
+	for _, item := range [][2]string{
+		{`number`, `money`}, {`number`, `decimal(25,6)`}, {`money`, `decimal(40,10)`},
+		{`decimal(10,2)`, `decimal(12,4)`}, {`varchar`, `text`}, {`date`, `datetime`},
+	} {
+		require.True(t, isSafeCast(item[0], item[1]), item[0]+` -> `+item[1])
+	}
+	for _, item := range [][2]string{
+		{`text`, `varchar`}, {`money`, `number`}, {`number`, `decimal(20,4)`},
+		{`decimal(10,2)`, `decimal(10,1)`}, {`datetime`, `date`}, {`varchar`, `varchar`},
+	} {
+		require.False(t, isSafeCast(item[0], item[1]), item[0]+` -> `+item[1])
+	}
+}
+
+func TestColumnReferences(t *testing.T) {
+	refs, err := parseColumnReferences(`{"name": "true", "owner": "{\"update\": \"true\", \"references\": \"members\"}"}`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{`owner`: `members`}, refs)
+
+	perm, err := keepColumnReferences(`{"update": "true", "references": "members"}`, `false`)
+	require.NoError(t, err)
+	require.Equal(t, `{"update":"false","references":"members"}`, perm)
+
+	perm, err = keepColumnReferences(`true`, `{"update": "false", "references": "members"}`)
+	require.NoError(t, err)
+	require.Equal(t, `{"update":"false"}`, perm)
+
+	// the lookups are charged by the row count of the table which is found in the query
+	table, err := querycost.SelectQueryType(strings.ToLower(referenceQuery(`1`, `members`))).GetTableName()
+	require.NoError(t, err)
+	require.Equal(t, `1_members`, table)
+	table, err = querycost.SelectQueryType(strings.ToLower(dependentQuery(`1`, `orders`, `owner`))).GetTableName()
+	require.NoError(t, err)
+	require.Equal(t, `1_orders`, table)
+	require.Equal(t, `1_orders_owner_ref`, referenceIndexName(`1_orders`, `owner`))
+}
+
+func TestParseAggregateQuery(t *testing.T) {
+	query, err := parseAggregateQuery(`category, sum(amount), count(*)`, `category`,
+		`sum(amount) > 100 and count(*)>=2`, `sum(amount) desc`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`"category"`, `sum("amount") as "sum(amount)"`, `count(*) as "count(*)"`}, query.fields)
+	require.Equal(t, `sum("amount") > 100 and count(*) >= 2`, query.having)
+	require.Equal(t, []string{`"sum(amount)" desc`}, query.order)
+	require.Equal(t, []string{`category`, `amount`}, query.columns)
+
+	for _, item := range [][4]string{
+		{`name`, ``, ``, ``},
+		{`sum(*)`, ``, ``, ``},
+		{`count(*)`, `name;drop`, ``, ``},
+		{`count(*)`, ``, `name = 1`, ``},
+		{`count(*)`, ``, `count(*) > (select 1)`, ``},
+		{`count(*)`, ``, ``, `name`},
+	} {
+		_, err = parseAggregateQuery(item[0], item[1], item[2], item[3])
+		require.Error(t, err, item)
+	}
+
+	vm := GetVM()
+	require.NoError(t, LoadSysFuncs(vm, 1))
+	root, err := VMCompileBlock(vm, `func aggregateByID() array {
+		return DBFind("amounts").Columns("sum(amount)").WhereId(1)
+	}`, &script.OwnerInfo{StateID: 1})
+	require.NoError(t, err)
+	_, err = VMRun(vm, root.Children[0], nil, &map[string]interface{}{`txcost`: script.CostDefault})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `WhereId cannot be used`)
+}
+
+func TestContractVersion(t *testing.T) {
+	for input, want := range map[string]string{`2`: `2.0`, `3.14`: `3.14`, ` 1.0 `: `1.0`} {
+		ver, err := parseVersion(input)
+		require.NoError(t, err)
+		require.Equal(t, want, ver.String())
+	}
+	for _, input := range []string{``, `0.1`, `1.2.3`, `a.1`, `1.-1`} {
+		_, err := parseVersion(input)
+		require.Error(t, err, input)
+	}
+
+	compile := func(data string) *script.ContractInfo {
+		root, err := VMCompileBlock(GetVM(), `contract Versioned { data {`+data+`} action {} }`,
+			&script.OwnerInfo{StateID: 1})
+		require.NoError(t, err)
+		return root.Children[0].Info.(*script.ContractInfo)
+	}
+	prev := compile(`Name string
+		Amount money`)
+	cur := contractVersion{major: 1, minor: 2}
+	for _, item := range []struct {
+		data string
+		want string
+	}{
+		{"Name string\nAmount money", `1.3`},
+		{"Name string\nAmount money\nComment string \"optional\"", `1.3`},
+		{"Name string\nAmount money\nComment string", `2.0`},
+		{"Name string", `2.0`},
+		{"Name int\nAmount money", `2.0`},
+	} {
+		next, err := nextContractVersion(cur, ``, prev, compile(item.data))
+		require.NoError(t, err)
+		require.Equal(t, item.want, next.String(), item.data)
+	}
+	next, err := nextContractVersion(cur, `5`, prev, prev)
+	require.NoError(t, err)
+	require.Equal(t, `5.0`, next.String())
+	_, err = nextContractVersion(cur, `1.2`, prev, prev)
+	require.Error(t, err)
+
+	vm := script.NewVM()
+	block := &script.Block{Type: script.ObjContract}
+	pinContract(vm, `@1Versioned`, 1, block)
+	pinContract(vm, `@1Versioned`, 2, block)
+	pinContract(vm, `@1VersionedOther`, 1, block)
+	require.Equal(t, block, vm.Objects[`@1Versioned:2`].Value)
+	unpinContract(vm, `@1Versioned`)
+	require.NotContains(t, vm.Objects, `@1Versioned:1`)
+	require.NotContains(t, vm.Objects, `@1Versioned:2`)
+	require.Contains(t, vm.Objects, `@1VersionedOther:1`)
+}
+
+func TestFuelInfo(t *testing.T) {
+	sc := &SmartContract{TxFuelLimit: 1000, TxFuel: 300}
+	sc.RecordFuel(`@1Inner`, 200, 150)
+	sc.RecordFuel(`@1Other`, 0, 50)
+	require.Equal(t, &FuelInfo{Limit: 1000, Used: 300, Calls: []FuelCall{
+		{Contract: `@1Inner`, Limit: 200, Used: 150},
+		{Contract: `@1Other`, Used: 50},
+	}}, sc.FuelInfo())
+
+	sc = &SmartContract{TxFuelLimit: 100, TxFuel: 120}
+	require.Equal(t, &FuelInfo{Limit: 100, Used: 120}, sc.FuelInfo())
+}
+
+func TestCryptoFuncs(t *testing.T) {
+	digest, err := Keccak256(`abc`)
+	require.NoError(t, err)
+	require.Equal(t, `4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45`, digest)
+	digest, err = Sha3([]byte{})
+	require.NoError(t, err)
+	require.Equal(t, `a7ffc6f8bf1ed76651c14756a061d662f580ff4de43b49fa82d80a4b80f8434a`, digest)
+	_, err = Sha3(int64(1))
+	require.Error(t, err)
+
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pub := hex.EncodeToString(append(converter.FillLeft(priv.X.Bytes()), converter.FillLeft(priv.Y.Bytes())...))
+	_, other, err := crypto.GenHexKeys()
+	require.NoError(t, err)
+	hash := sha256.Sum256([]byte(`voucher`))
+	r, s, err := ecdsa.Sign(rand.Reader, priv, hash[:])
+	require.NoError(t, err)
+	signature := hex.EncodeToString(append(converter.FillLeft(r.Bytes()), converter.FillLeft(s.Bytes())...))
+
+	ok, err := VerifySignature(pub, `voucher`, signature)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = VerifySignature(`04`+pub, `voucher`, signature)
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = VerifySignature(pub, `voucher2`, signature)
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, err = VerifySignature(pub[2:], `voucher`, signature)
+	require.Error(t, err)
+
+	key, err := hex.DecodeString(pub)
+	require.NoError(t, err)
+	id, err := RecoverAddress(`voucher`, signature, other, pub)
+	require.NoError(t, err)
+	require.Equal(t, crypto.Address(key), id)
+	id, err = RecoverAddress(`voucher`, signature, other)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), id)
+	keys := make([]interface{}, maxRecoverKeys+1)
+	_, err = RecoverAddress(`voucher`, signature, keys...)
+	require.Error(t, err)
+
+	encrypted, err := EncryptForKey(pub, `secret`)
+	require.NoError(t, err)
+	data, err := hex.DecodeString(encrypted)
+	require.NoError(t, err)
+	decrypted, err := crypto.SharedDecrypt(converter.FillLeft(priv.D.Bytes()), data)
+	require.NoError(t, err)
+	require.Equal(t, `secret`, string(decrypted))
+	_, err = EncryptForKey(`00`, `secret`)
+	require.Error(t, err)
+}
+
+func TestTextFuncs(t *testing.T) {
+	cost, ok, err := RegexpMatch(`^[a-z]+-\d{3}$`, `abc-123`)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, cost > 0)
+	_, ok, err = RegexpMatch(`^[a-z]+-\d{3}$`, `abc-12`)
+	require.NoError(t, err)
+	require.False(t, ok)
+	longCost, _, err := RegexpMatch(`^[a-z]+-\d{3}$`, strings.Repeat(`a`, 10*regexpCostChunk))
+	require.NoError(t, err)
+	require.True(t, longCost > cost)
+	_, _, err = RegexpMatch(`(a`, `a`)
+	require.Error(t, err)
+	_, _, err = RegexpMatch(`(a)\1`, `aa`)
+	require.Error(t, err)
+
+	_, out, err := RegexpReplace(`(\w+)@(\w+)`, `john@example`, `$2:$1`)
+	require.NoError(t, err)
+	require.Equal(t, `example:john`, out)
+	_, _, err = RegexpReplace(``, strings.Repeat(`a`, 1024), strings.Repeat(`b`, 1024))
+	require.Error(t, err)
+	for _, item := range []struct {
+		pattern, text, replace, want string
+	}{
+		{`(\w+)@(\w+)`, `a@b c@d`, `$2$$1${1}x$`, `b$1ax$ d$1cx$`},
+		{`a*`, `baaac`, `<$0>`, `<>b<aaa>c<>`},
+		{`(?P<first>\w+) (?P<last>\w+)`, `John Smith`, `${last}, $first`, `Smith, John`},
+		{`x`, `abc`, `$1`, `abc`},
+	} {
+		_, out, err = RegexpReplace(item.pattern, item.text, item.replace)
+		require.NoError(t, err)
+		require.Equal(t, item.want, out, item.replace)
+		require.Equal(t, regexp.MustCompile(item.pattern).ReplaceAllString(item.text, item.replace), out)
+	}
+	// the submatches expand the result up to the limit
+	half := strings.Repeat(`a`, maxRegexpResult/2)
+	_, out, err = RegexpReplace(`^(a+)$`, half, `$1$1`)
+	require.NoError(t, err)
+	require.Equal(t, maxRegexpResult, len(out))
+	_, _, err = RegexpReplace(`^(a+)$`, half, `$1${1}b`)
+	require.Error(t, err)
+	_, _, err = RegexpReplace(`(a+)`, strings.Repeat(`a`, 1024), strings.Repeat(`$1`, 2048))
+	require.Error(t, err)
+
+	for _, item := range []struct {
+		amount interface{}
+		digits int64
+		locale string
+		want   string
+	}{
+		{`123456789`, 2, `en`, `1,234,567.89`},
+		{`123456789`, 2, `de-DE`, `1.234.567,89`},
+		{int64(-1234500), 3, `ru`, `-1 234,500`},
+		{`5`, 2, ``, `0.05`},
+		{int64(999), 0, `ch`, `999`},
+	} {
+		out, err := FormatMoney(item.amount, item.digits, item.locale)
+		require.NoError(t, err)
+		require.Equal(t, item.want, out)
+	}
+	_, err = FormatMoney(`100`, 2, `xx`)
+	require.Error(t, err)
+	_, err = FormatMoney(true, 2, `en`)
+	require.Error(t, err)
+}
+
+func TestHistoryAfterPruning(t *testing.T) {
+	curVal := map[string]string{`id`: `5`, `value`: `c`}
+	blockTime := func(blockID int64) (string, error) {
+		return converter.Int64ToStr(blockID), nil
+	}
+	history := func(table string, horizon int64) []interface{} {
+		txs := []model.RollbackTx{
+			{ID: 3, BlockID: 30, NameTable: table, TableID: `5`, Data: `{"value":"b"}`},
+			{ID: 2, BlockID: 20, NameTable: table, TableID: `5`, Data: `{"value":"a"}`},
+			{ID: 1, BlockID: 10, NameTable: table, TableID: `5`, Data: ``},
+		}
+		// the same condition as in model.DeleteRollbackTxTill
+		kept := make([]model.RollbackTx, 0, len(txs))
+		for _, tx := range txs {
+			if tx.BlockID > horizon || model.IsHistoryTable(tx.NameTable) {
+				kept = append(kept, tx)
+			}
+		}
+		list, err := historyFromRollback(curVal, kept, 0, blockTime)
+		require.NoError(t, err)
+		return list
+	}
+
+	full := history(`1_pages`, 0)
+	require.Len(t, full, 2)
+	require.Equal(t, `a`, full[1].(map[string]string)[`value`])
+	require.Equal(t, `10`, full[1].(map[string]string)[`block_id`])
+	for _, table := range []string{`1_pages`, `2_menu`, `1_blocks`, `10_contracts`} {
+		require.Equal(t, full, history(table, 25), table)
+	}
+	require.NotEqual(t, history(`1_keys`, 0), history(`1_keys`, 25))
+	require.False(t, model.IsHistoryTable(`1_pages_old`))
+}
+
+func TestEncryptForKeyOnlyInVDE(t *testing.T) {
+	code := `func encrypted(pub string) string {
+			return EncryptForKey(pub, "secret")
+		}`
+	_, err := VMCompileBlock(GetVM(), code, &script.OwnerInfo{StateID: 1})
+	require.Error(t, err)
+
+	vde := script.NewVM()
+	EmbedFuncs(vde, script.VMTypeVDE)
+	_, err = VMCompileBlock(vde, code, &script.OwnerInfo{StateID: 1})
+	require.NoError(t, err)
+}
+
+func TestFormatFuncsInVM(t *testing.T) {
+	vde := script.NewVM()
+	EmbedFuncs(vde, script.VMTypeVDE)
+	for _, vm := range []*script.VM{GetVM(), vde} {
+		root, err := VMCompileBlock(vm, `func formatted() string {
+			var id string
+			id = "1234500"
+			if !RegexpMatch("^[0-9]+$", id) {
+				return "wrong"
+			}
+			return FormatMoney(RegexpReplace("0+$", id, ""), 2, "en") + " " +
+				Date("", DateAdd(ParseDate("", "2018-01-31 10:00:00"), 1, "day"))
+		}`, &script.OwnerInfo{StateID: 1})
+		require.NoError(t, err)
+		extend := map[string]interface{}{`txcost`: script.CostDefault}
+		ret, err := VMRun(vm, root.Children[0], nil, &extend)
+		require.NoError(t, err)
+		require.Equal(t, `123.45 2018-02-01 10:00:00`, ret[0])
+	}
+}
+
+func TestTimeFuncs(t *testing.T) {
+	ts, err := ParseDate(``, `2018-01-31 10:00:00`)
+	require.NoError(t, err)
+	require.Equal(t, int64(1517392800), ts)
+	local, err := ParseDate(`2006-01-02 15:04`, `2018-01-31 13:00`, `+03:00`)
+	require.NoError(t, err)
+	require.Equal(t, ts, local)
+	_, err = ParseDate(``, `2018-01-31`)
+	require.Error(t, err)
+	_, err = ParseDate(``, `2018-01-31 10:00:00`, `Europe/Moscow`)
+	require.Error(t, err)
+
+	// the old call with two parameters formats the date in UTC regardless of the time zone of the node
+	defer func(loc *time.Location) { time.Local = loc }(time.Local)
+	time.Local = time.FixedZone(`node`, 3*3600)
+	date, err := Date(`02.01.2006 15:04`, ts)
+	require.NoError(t, err)
+	require.Equal(t, `31.01.2018 10:00`, date)
+	vde := script.NewVM()
+	EmbedFuncs(vde, script.VMTypeVDE)
+	root, err := VMCompileBlock(vde, `func formatted() string {
+			return Date("02.01.2006 15:04", 1517392800)
+		}`, &script.OwnerInfo{StateID: 1})
+	require.NoError(t, err)
+	extend := map[string]interface{}{`txcost`: script.CostDefault}
+	ret, err := VMRun(vde, root.Children[0], nil, &extend)
+	require.NoError(t, err)
+	require.Equal(t, `31.01.2018 10:00`, ret[0])
+
+	date, err = Date(`2006-01-02 15:04 -07:00`, ts, `-0530`)
+	require.NoError(t, err)
+	require.Equal(t, `2018-01-31 04:30 -05:30`, date)
+
+	next, err := DateAdd(ts, 1, `months`)
+	require.NoError(t, err)
+	date, _ = Date(``, next)
+	require.Equal(t, `2018-03-03 10:00:00`, date)
+	next, err = DateAdd(ts, -2, `day`)
+	require.NoError(t, err)
+	require.Equal(t, ts-2*86400, next)
+	_, err = DateAdd(ts, 1, `fortnight`)
+	require.Error(t, err)
+
+	for _, item := range []struct {
+		to   string
+		unit string
+		want int64
+	}{
+		{`2018-01-31 11:30:00`, `hours`, 1},
+		{`2018-01-30 10:00:01`, `day`, 0},
+		{`2018-02-28 10:00:00`, `month`, 0},
+		{`2018-03-31 10:00:00`, `month`, 2},
+		{`2017-12-31 10:00:00`, `month`, -1},
+		{`2019-01-31 09:59:59`, `year`, 0},
+		{`2020-02-01 00:00:00`, `years`, 2},
+	} {
+		to, err := ParseDate(``, item.to)
+		require.NoError(t, err)
+		diff, err := DateDiff(ts, to, item.unit)
+		require.NoError(t, err)
+		require.Equal(t, item.want, diff, item.to)
+	}
+}
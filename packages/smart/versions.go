@@ -0,0 +1,373 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package smart
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/script"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// migrateFunc is the function of the contract which is called once after its upgrade
+	migrateFunc = `migrate`
+	// pinSeparator separates the name of the contract and the major version in the pinned name.
+	// CallContract("Name:2", params) calls the last version of the contract with the major version 2
+	pinSeparator = `:`
+	// contractVersions is the table of the version history of contracts
+	contractVersions = `contract_versions`
+)
+
+// contractVersion is the version of the contract in the format major.minor
+type contractVersion struct {
+	major int64
+	minor int64
+}
+
+func (ver contractVersion) String() string {
+	return fmt.Sprintf(`%d.%d`, ver.major, ver.minor)
+}
+
+func (ver contractVersion) less(other contractVersion) bool {
+	return ver.major < other.major || (ver.major == other.major && ver.minor < other.minor)
+}
+
+// parseVersion parses the version like 2 or 2.1
+func parseVersion(input string) (ver contractVersion, err error) {
+	parts := strings.Split(strings.TrimSpace(input), `.`)
+	if len(parts) > 2 {
+		return ver, fmt.Errorf(eVersion, input)
+	}
+	if ver.major, err = strconv.ParseInt(parts[0], 10, 64); err != nil || ver.major < 1 {
+		return ver, fmt.Errorf(eVersion, input)
+	}
+	if len(parts) == 2 {
+		if ver.minor, err = strconv.ParseInt(parts[1], 10, 64); err != nil || ver.minor < 0 {
+			return ver, fmt.Errorf(eVersion, input)
+		}
+	}
+	return ver, nil
+}
+
+// pinnedName returns the name of the contract pinned to the major version
+func pinnedName(name string, major int64) string {
+	return name + pinSeparator + converter.Int64ToStr(major)
+}
+
+// pinContract makes the block of the contract available by the pinned name
+func pinContract(vm *script.VM, name string, major int64, block *script.Block) {
+	vm.Objects[pinnedName(name, major)] = &script.ObjInfo{Type: script.ObjContract, Value: block}
+}
+
+// unpinContract removes all pinned names of the contract
+func unpinContract(vm *script.VM, name string) {
+	for key := range vm.Objects {
+		if strings.HasPrefix(key, name+pinSeparator) {
+			delete(vm.Objects, key)
+		}
+	}
+}
+
+// isBreakingChange returns true if the callers of the previous version cannot call the new version
+// with the same parameters
+func isBreakingChange(prev, cur *script.ContractInfo) bool {
+	fields := func(info *script.ContractInfo) map[string]*script.FieldInfo {
+		ret := make(map[string]*script.FieldInfo)
+		if info.Tx != nil {
+			for _, field := range *info.Tx {
+				ret[field.Name] = field
+			}
+		}
+		return ret
+	}
+	prevFields, curFields := fields(prev), fields(cur)
+	for name, field := range prevFields {
+		if curField, ok := curFields[name]; !ok || curField.Type != field.Type {
+			return true
+		}
+	}
+	for name, field := range curFields {
+		if _, ok := prevFields[name]; !ok && !field.ContainsTag(`optional`) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sc *SmartContract) newContractVersion() *model.ContractVersion {
+	ver := &model.ContractVersion{}
+	ver.SetTablePrefix(converter.Int64ToStr(sc.TxSmart.EcosystemID))
+	return ver
+}
+
+// insertContractVersion adds the record to the version history of the contract
+func (sc *SmartContract) insertContractVersion(id int64, name string, ver contractVersion, value string,
+	keyID, blockID, migrated int64) error {
+	hash, err := crypto.Hash([]byte(value))
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.CryptoError, "error": err}).Error("getting hash of contract source")
+		return err
+	}
+	_, _, err = sc.selectiveLoggingAndUpd([]string{`contract_id`, `name`, `major`, `minor`, `value`, `hash`,
+		`key_id`, `block_id`, `migrated`}, []interface{}{id, name, ver.major, ver.minor, value,
+		hex.EncodeToString(hash), keyID, blockID, migrated},
+		getDefTableName(sc, contractVersions), nil, nil, !sc.VDE && sc.Rollback, false)
+	return err
+}
+
+// lastContractVersion returns the current version of the contract. If the contract has been created
+// before the versioning then its source is saved as the version 1.0
+func (sc *SmartContract) lastContractVersion(id int64, name, value string) (ver contractVersion, err error) {
+	last := sc.newContractVersion()
+	found, err := last.GetLast(sc.DbTransaction, id)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting last contract version")
+		return
+	}
+	if !found {
+		ver = contractVersion{major: 1}
+		err = sc.insertContractVersion(id, name, ver, value, 0, 0, 0)
+		return
+	}
+	return contractVersion{major: last.Major, minor: last.Minor}, nil
+}
+
+// nextContractVersion returns the version of the new source of the contract. If the version is not
+// specified then the major version is increased for the breaking changes of the parameters and
+// the minor version otherwise
+func nextContractVersion(cur contractVersion, version string, prev, next *script.ContractInfo) (contractVersion, error) {
+	if len(version) > 0 {
+		ver, err := parseVersion(version)
+		if err != nil {
+			return ver, err
+		}
+		if !cur.less(ver) {
+			return ver, fmt.Errorf(eVersionLess, ver, cur)
+		}
+		return ver, nil
+	}
+	if prev != nil && isBreakingChange(prev, next) {
+		return contractVersion{major: cur.major + 1}, nil
+	}
+	return contractVersion{major: cur.major, minor: cur.minor + 1}, nil
+}
+
+func (sc *SmartContract) blockID() int64 {
+	if sc.BlockData == nil {
+		return 0
+	}
+	return sc.BlockData.BlockID
+}
+
+// upgradeContract adds the new source of the contract to the version history and calls
+// its migrate function. It is called before the new source is loaded into the virtual machine
+func (sc *SmartContract) upgradeContract(id int64, value string, root *script.Block, version string) error {
+	if len(root.Children) != 1 || root.Children[0].Type != script.ObjContract {
+		// FlushContract returns the error
+		return nil
+	}
+	block := root.Children[0]
+	info := block.Info.(*script.ContractInfo)
+	_, name := script.ParseContract(info.Name)
+
+	row, err := model.GetOneRowTransaction(sc.DbTransaction, `select value from "`+
+		getDefTableName(sc, `contracts`)+`" where id=?`, id).String()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting contract source")
+		return err
+	}
+	cur, err := sc.lastContractVersion(id, name, row[`value`])
+	if err != nil {
+		return err
+	}
+	var prevInfo *script.ContractInfo
+	if prev := VMGetContract(sc.VM, info.Name, 0); prev != nil {
+		prevInfo = prev.Block.Info.(*script.ContractInfo)
+	}
+	next, err := nextContractVersion(cur, version, prevInfo, info)
+	if err != nil {
+		return err
+	}
+	var migrated int64
+	if obj, ok := block.Objects[migrateFunc]; ok && obj.Type == script.ObjFunc {
+		if err = sc.migrateContract(info.Name, obj.Value.(*script.Block), cur); err != nil {
+			return err
+		}
+		migrated = 1
+	}
+	return sc.insertContractVersion(id, name, next, value, sc.TxSmart.KeyID, sc.blockID(), migrated)
+}
+
+// pinLastVersion pins the loaded contract to its current major version
+func (sc *SmartContract) pinLastVersion(id int64, root *script.Block) {
+	last := sc.newContractVersion()
+	if found, err := last.GetLast(sc.DbTransaction, id); err != nil || !found {
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting last contract version")
+		}
+		return
+	}
+	for _, block := range root.Children {
+		if block.Type == script.ObjContract {
+			pinContract(sc.VM, block.Info.(*script.ContractInfo).Name, last.Major, block)
+		}
+	}
+}
+
+// rollbackContractVersion pins the restored contract to its previous major version. The version record
+// and the changes of the migrate function are rolled back with the other changes of the transaction
+func (sc *SmartContract) rollbackContractVersion(id int64, root *script.Block) error {
+	if sc.VDE {
+		return nil
+	}
+	rollbackTx := &model.RollbackTx{}
+	found, err := rollbackTx.Get(sc.DbTransaction, sc.TxHash, getDefTableName(sc, contractVersions))
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting contract version from rollback table")
+		return err
+	}
+	if !found {
+		return nil
+	}
+	last := sc.newContractVersion()
+	if found, err = last.GetLast(sc.DbTransaction, id); err != nil || !found {
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting last contract version")
+		}
+		return err
+	}
+	major := int64(1)
+	prev := sc.newContractVersion()
+	if found, err = prev.GetPrevious(sc.DbTransaction, id, last.ID); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting previous contract version")
+		return err
+	} else if found {
+		major = prev.Major
+	}
+	if last.Migrated == 1 {
+		log.WithFields(log.Fields{"contract": last.Name, "version": contractVersion{last.Major, last.Minor}.String()}).
+			Info("rolling back migrated contract")
+	}
+	for _, block := range root.Children {
+		if block.Type == script.ObjContract {
+			name := block.Info.(*script.ContractInfo).Name
+			if last.Major != major {
+				delete(sc.VM.Objects, pinnedName(name, last.Major))
+			}
+			pinContract(sc.VM, name, major, block)
+		}
+	}
+	return nil
+}
+
+// migrateContract calls the migrate function of the new version of the contract. The function
+// gets the previous version in $migrate_from and is executed as the called contract
+func (sc *SmartContract) migrateContract(name string, block *script.Block, from contractVersion) error {
+	if len(block.Info.(*script.FuncInfo).Params) > 0 {
+		return errMigrateParams
+	}
+	if err := sc.AppendStack(name); err != nil {
+		return err
+	}
+	defer sc.AppendStack(``)
+
+	vars := make(map[string]interface{}, len(*sc.TxContract.Extend)+1)
+	for key, val := range *sc.TxContract.Extend {
+		vars[key] = val
+	}
+	_, vars[`this_contract`] = script.ParseContract(name)
+	vars[`migrate_from`] = from.String()
+	_, err := VMRun(sc.VM, block, nil, &vars)
+	(*sc.TxContract.Extend)[`txcost`] = vars[`txcost`]
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.VMError, "contract": name, "error": err}).Error("migrating contract")
+	}
+	return err
+}
+
+// loadContractVersions pins the contracts of the ecosystem to their major versions
+func loadContractVersions(transaction *model.DbTransaction, prefix string) error {
+	if !model.IsTable(prefix + `_` + contractVersions) {
+		return nil
+	}
+	state := uint32(converter.StrToInt64(prefix))
+	ver := &model.ContractVersion{}
+	ver.SetTablePrefix(prefix)
+	versions, err := ver.GetLastMajors(transaction)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("selecting contract versions")
+		return err
+	}
+	current := make(map[int64]int64)
+	for _, item := range versions {
+		if item.Major > current[item.ContractID] {
+			current[item.ContractID] = item.Major
+		}
+	}
+	for _, item := range versions {
+		contract := VMGetContract(smartVM, item.Name, state)
+		if contract == nil {
+			continue
+		}
+		if item.Major == current[item.ContractID] {
+			pinContract(smartVM, contract.Name, item.Major, contract.Block)
+			continue
+		}
+		info := contract.Block.Info.(*script.ContractInfo)
+		root, err := VMCompileBlock(smartVM, item.Value, info.Owner)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.EvalError, "contract": item.Name, "version": item.Major,
+				"error": err}).Error("compiling pinned contract")
+			continue
+		}
+		for _, child := range root.Children {
+			if child.Type == script.ObjContract {
+				child.Parent = &smartVM.Block
+				child.Info.(*script.ContractInfo).ID = info.ID
+				pinContract(smartVM, contract.Name, item.Major, child)
+			}
+		}
+	}
+	// the contracts which have not been edited since the versioning have the version 1.0
+	for _, block := range smartVM.Children {
+		if block == nil || block.Type != script.ObjContract {
+			continue
+		}
+		info := block.Info.(*script.ContractInfo)
+		if info.Owner.StateID != state {
+			continue
+		}
+		if _, ok := current[info.Owner.TableID]; !ok {
+			pinContract(smartVM, info.Name, 1, block)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/converter"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/utils"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var regexpIndexSchema = regexp.MustCompile(`^CREATE (UNIQUE )?INDEX [^;]+ ON (public\.)?"?([^"\s;]+)"? USING [^;]+$`)
+
+// the local data which are cleared because the blockchain continues from the snapshot,
+// the first block is kept because it is used for the calculation of block time
+var clearQueries = []string{
+	`DELETE FROM "block_chain" WHERE id > 1`,
+	`DELETE FROM "rollback_tx"`,
+	`DELETE FROM "info_block"`,
+	`DELETE FROM "queue_blocks"`,
+	`DELETE FROM "confirmations"`,
+	`DELETE FROM "archive_checkpoints"`,
+}
+
+// Restore replaces the state with the snapshot. The chunks are received with getChunk
+// and checked with their hashes in the manifest.
+func Restore(manifest *Manifest, getChunk func(index int) ([]byte, error)) error {
+	header, err := utils.ParseBlockHeader(bytes.NewBuffer(manifest.Block.Data), false)
+	if err != nil {
+		return err
+	}
+
+	transaction, err := model.StartTransaction()
+	if err != nil {
+		return err
+	}
+	if err = restore(transaction, manifest, getChunk); err != nil {
+		transaction.Rollback()
+		return err
+	}
+
+	for _, query := range clearQueries {
+		if err = model.GetDB(transaction).Exec(query).Error; err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": query}).Error("clearing local data")
+			transaction.Rollback()
+			return err
+		}
+	}
+	if err = manifest.Block.Create(transaction); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating block")
+		transaction.Rollback()
+		return err
+	}
+	infoBlock := &model.InfoBlock{
+		Hash:           manifest.Block.Hash,
+		BlockID:        manifest.Block.ID,
+		Time:           manifest.Block.Time,
+		EcosystemID:    manifest.Block.EcosystemID,
+		KeyID:          manifest.Block.KeyID,
+		NodePosition:   converter.Int64ToStr(manifest.Block.NodePosition),
+		CurrentVersion: fmt.Sprintf("%d", header.Version),
+		// the blocks before the snapshot can't be rolled back and served to other nodes
+		PrunedBlockID: manifest.Block.ID,
+		PrunedBodyID:  manifest.Block.ID - 1,
+	}
+	if err = infoBlock.Create(transaction); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("creating info block")
+		transaction.Rollback()
+		return err
+	}
+	return transaction.Commit()
+}
+
+func restore(transaction *model.DbTransaction, manifest *Manifest, getChunk func(index int) ([]byte, error)) error {
+	tables, err := model.GetStateTables(transaction)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting state tables")
+		return err
+	}
+	for _, table := range tables {
+		if err = model.DropTable(transaction, table); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("dropping table")
+			return err
+		}
+	}
+
+	restored := make(map[string]bool)
+	for _, table := range manifest.Tables {
+		if err = checkSchema(table); err != nil {
+			log.WithFields(log.Fields{"type": consts.InvalidObject, "error": err, "table": table.Name}).Error("checking table schema")
+			return err
+		}
+		restored[table.Name] = true
+		for _, query := range table.Schema {
+			if err = model.GetDB(transaction).Exec(query).Error; err != nil {
+				log.WithFields(log.Fields{"type": consts.DBError, "error": err, "query": query}).Error("creating table")
+				return err
+			}
+		}
+	}
+
+	for i, chunk := range manifest.Chunks {
+		if !restored[chunk.Table] {
+			return fmt.Errorf("unknown table %s of chunk %d", chunk.Table, i)
+		}
+		data, err := getChunk(i)
+		if err != nil {
+			return err
+		}
+		if err = manifest.CheckChunk(i, data); err != nil {
+			return err
+		}
+		if err = model.GetDB(transaction).Exec(fmt.Sprintf(`INSERT INTO "%[1]s"
+			SELECT * FROM json_populate_recordset(NULL::"%[1]s", ?)`, chunk.Table), string(data)).Error; err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": chunk.Table}).Error("inserting snapshot rows")
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSchema checks that the schema statements only create the table and its indexes
+func checkSchema(table Table) error {
+	if !model.IsStateTable(table.Name) {
+		return fmt.Errorf("table %s can't be restored from snapshot", table.Name)
+	}
+	for i, query := range table.Schema {
+		if i == 0 {
+			if !strings.HasPrefix(query, `CREATE TABLE "`+table.Name+`" (`) || strings.Contains(query, `;`) {
+				return fmt.Errorf("wrong schema of table %s", table.Name)
+			}
+			continue
+		}
+		if match := regexpIndexSchema.FindStringSubmatch(query); len(match) == 0 || match[3] != table.Name {
+			return fmt.Errorf("wrong index of table %s", table.Name)
+		}
+	}
+	if len(table.Schema) == 0 {
+		return fmt.Errorf("empty schema of table %s", table.Name)
+	}
+	return nil
+}
@@ -0,0 +1,324 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/GenesisCommunity/go-genesis/packages/conf"
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ChunkSize is the max size of the chunk of table rows
+	ChunkSize = 1 << 20
+
+	manifestFilename = "manifest.json"
+	defaultKeep      = 2
+)
+
+// ErrNotFound is returned if the snapshot does not exist
+var ErrNotFound = errors.New("snapshot not found")
+
+var creating int32
+
+// Table contains the schema of the table which is stored in the snapshot
+type Table struct {
+	Name   string
+	Schema []string // the statements which create the table and its indexes
+}
+
+// Chunk is the part of rows of the table
+type Chunk struct {
+	Table string
+	Hash  []byte
+}
+
+// Manifest describes the snapshot of the state after the block
+type Manifest struct {
+	BlockID int64
+	Block   model.Block
+	Tables  []Table
+	Chunks  []Chunk
+}
+
+// Hash returns the state hash of the snapshot
+func (m *Manifest) Hash() ([]byte, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling snapshot manifest")
+		return nil, err
+	}
+	return crypto.Hash(data)
+}
+
+// CheckChunk checks the data of the chunk with its hash in the manifest
+func (m *Manifest) CheckChunk(index int, data []byte) error {
+	if index < 0 || index >= len(m.Chunks) {
+		return fmt.Errorf("wrong chunk %d of snapshot %d", index, m.BlockID)
+	}
+	hash, err := crypto.Hash(data)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, m.Chunks[index].Hash) {
+		log.WithFields(log.Fields{"type": consts.InvalidObject, "block_id": m.BlockID, "chunk": index}).Error("wrong hash of snapshot chunk")
+		return fmt.Errorf("wrong hash of chunk %d of snapshot %d", index, m.BlockID)
+	}
+	return nil
+}
+
+// IsTime returns true if the snapshot must be created after the block
+func IsTime(blockID int64) bool {
+	return conf.Config.Snapshot.Interval > 0 && blockID%conf.Config.Snapshot.Interval == 0
+}
+
+// Create starts creating the snapshot of the state after the block. It must be called when the block
+// has been committed and the next block hasn't been played yet, the data are written in the background.
+func Create(blockID int64) error {
+	if !atomic.CompareAndSwapInt32(&creating, 0, 1) {
+		log.WithFields(log.Fields{"block_id": blockID}).Warning("previous snapshot is being created")
+		return nil
+	}
+	transaction, err := model.StartSnapshotTransaction()
+	if err != nil {
+		atomic.StoreInt32(&creating, 0)
+		return err
+	}
+	go func() {
+		defer func() {
+			transaction.Rollback()
+			atomic.StoreInt32(&creating, 0)
+		}()
+		if err := create(transaction, blockID); err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err, "block_id": blockID}).Error("creating snapshot")
+			return
+		}
+		log.WithFields(log.Fields{"block_id": blockID}).Info("snapshot has been created")
+		removeOld()
+	}()
+	return nil
+}
+
+func create(transaction *model.DbTransaction, blockID int64) error {
+	dir := snapshotDir(blockID)
+	tmpDir := dir + ".tmp"
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpDir, 0775); err != nil {
+		return err
+	}
+
+	manifest := &Manifest{BlockID: blockID}
+	if err := model.GetDB(transaction).Where("id = ?", blockID).First(&manifest.Block).Error; err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": blockID}).Error("getting block")
+		return err
+	}
+	tables, err := model.GetStateTables(transaction)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err}).Error("getting state tables")
+		return err
+	}
+	for _, table := range tables {
+		schema, err := model.GetTableSchema(transaction, table)
+		if err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("getting table schema")
+			return err
+		}
+		manifest.Tables = append(manifest.Tables, Table{Name: table, Schema: schema})
+		if err = writeChunks(transaction, tmpDir, table, manifest); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling snapshot manifest")
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(tmpDir, manifestFilename), data, 0644); err != nil {
+		return err
+	}
+	if err = os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.Rename(tmpDir, dir)
+}
+
+// writeChunks writes the rows of the table in the deterministic order as json arrays
+func writeChunks(transaction *model.DbTransaction, dir, table string, manifest *Manifest) error {
+	var where string
+	if table == `log_transactions` {
+		// the log of expired transactions can be pruned by nodes, so it isn't a part of the snapshot
+		where = fmt.Sprintf(`WHERE time >= %d`, model.LogTxExpiredTime(manifest.Block.Time))
+	}
+	rows, err := model.GetDB(transaction).Raw(fmt.Sprintf(`SELECT row_to_json(t)::text FROM "%s" t %s
+		ORDER BY row_to_json(t)::text COLLATE "C"`, table, where)).Rows()
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("selecting table rows")
+		return err
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	flush := func() error {
+		if buf.Len() == 0 {
+			return nil
+		}
+		buf.WriteByte(']')
+		hash, err := crypto.Hash(buf.Bytes())
+		if err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(chunkFile(dir, len(manifest.Chunks)), buf.Bytes(), 0644); err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("writing snapshot chunk")
+			return err
+		}
+		manifest.Chunks = append(manifest.Chunks, Chunk{Table: table, Hash: hash})
+		buf.Reset()
+		return nil
+	}
+
+	for rows.Next() {
+		var row []byte
+		if err = rows.Scan(&row); err != nil {
+			log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("scanning table row")
+			return err
+		}
+		if buf.Len() == 0 {
+			buf.WriteByte('[')
+		} else {
+			buf.WriteByte(',')
+		}
+		buf.Write(row)
+		if buf.Len() >= ChunkSize {
+			if err = flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err = rows.Err(); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "table": table}).Error("selecting table rows")
+		return err
+	}
+	return flush()
+}
+
+// List returns the sorted block ids of the stored snapshots
+func List() ([]int64, error) {
+	files, err := ioutil.ReadDir(snapshotsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err}).Error("reading snapshots directory")
+		return nil, err
+	}
+	list := make([]int64, 0, len(files))
+	for _, file := range files {
+		if blockID, err := strconv.ParseInt(file.Name(), 10, 64); err == nil && file.IsDir() {
+			list = append(list, blockID)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list, nil
+}
+
+// GetManifest returns the manifest of the snapshot and its json data, the last snapshot is returned if blockID is 0
+func GetManifest(blockID int64) (*Manifest, []byte, error) {
+	if blockID == 0 {
+		list, err := List()
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(list) == 0 {
+			return nil, nil, ErrNotFound
+		}
+		blockID = list[len(list)-1]
+	}
+	data, err := ioutil.ReadFile(filepath.Join(snapshotDir(blockID), manifestFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrNotFound
+		}
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "block_id": blockID}).Error("reading snapshot manifest")
+		return nil, nil, err
+	}
+	manifest := &Manifest{}
+	if err = json.Unmarshal(data, manifest); err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err, "block_id": blockID}).Error("unmarshalling snapshot manifest")
+		return nil, nil, err
+	}
+	return manifest, data, nil
+}
+
+// ReadChunk returns the data of the chunk of the snapshot
+func ReadChunk(blockID int64, index int) ([]byte, error) {
+	data, err := ioutil.ReadFile(chunkFile(snapshotDir(blockID), index))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// removeOld removes the snapshots besides the last ones
+func removeOld() {
+	keep := conf.Config.Snapshot.Keep
+	if keep <= 0 {
+		keep = defaultKeep
+	}
+	list, err := List()
+	if err != nil {
+		return
+	}
+	for i := 0; i < len(list)-keep; i++ {
+		if err = os.RemoveAll(snapshotDir(list[i])); err != nil {
+			log.WithFields(log.Fields{"type": consts.IOError, "error": err, "block_id": list[i]}).Error("removing snapshot")
+		}
+	}
+}
+
+func snapshotsDir() string {
+	return filepath.Join(conf.Config.DataDir, consts.SnapshotsDirName)
+}
+
+func snapshotDir(blockID int64) string {
+	return filepath.Join(snapshotsDir(), strconv.FormatInt(blockID, 10))
+}
+
+func chunkFile(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("chunk_%d", index))
+}
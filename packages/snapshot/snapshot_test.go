@@ -0,0 +1,55 @@
+package snapshot
+
+import (
+	"testing"
+
+	"github.com/GenesisCommunity/go-genesis/packages/crypto"
+)
+
+func TestCheckSchema(t *testing.T) {
+	cases := []struct {
+		table Table
+		ok    bool
+	}{
+		{Table{"1_keys", []string{`CREATE TABLE "1_keys" ("id" bigint NOT NULL DEFAULT '0'::bigint, CONSTRAINT "1_keys_pkey" PRIMARY KEY (id))`}}, true},
+		{Table{"1_keys", []string{`CREATE TABLE "1_keys" ("id" bigint)`,
+			`CREATE INDEX "1_keys_index" ON public."1_keys" USING btree (amount)`}}, true},
+		{Table{"1_keys", []string{`CREATE TABLE "1_keys" ("id" bigint); DROP TABLE "my_node_keys"`}}, false},
+		{Table{"1_keys", []string{`CREATE TABLE "1_keys" ("id" bigint)`,
+			`CREATE INDEX "my_index" ON public."my_node_keys" USING btree (id)`}}, false},
+		{Table{"my_node_keys", []string{`CREATE TABLE "my_node_keys" ("id" bigint)`}}, false},
+		{Table{"1_keys", nil}, false},
+	}
+	for i, item := range cases {
+		if err := checkSchema(item.table); (err == nil) != item.ok {
+			t.Errorf("case %d: wrong result %v", i, err)
+		}
+	}
+}
+
+func TestCheckChunk(t *testing.T) {
+	data := []byte(`[{"id":1,"amount":"100"}]`)
+	hash, err := crypto.Hash(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := &Manifest{BlockID: 10, Chunks: []Chunk{{Table: "1_keys", Hash: hash}}}
+	if err = manifest.CheckChunk(0, data); err != nil {
+		t.Error(err)
+	}
+	if err = manifest.CheckChunk(0, []byte(`[{"id":1,"amount":"1000"}]`)); err == nil {
+		t.Error("modified chunk must be rejected")
+	}
+	if err = manifest.CheckChunk(1, data); err == nil {
+		t.Error("unknown chunk must be rejected")
+	}
+
+	stateHash, err := manifest.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Chunks[0].Table = "2_keys"
+	if other, _ := manifest.Hash(); string(other) == string(stateHash) {
+		t.Error("state hash must depend on the manifest")
+	}
+}
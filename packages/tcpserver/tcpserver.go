@@ -97,6 +97,44 @@ func HandleTCPRequest(rw net.Conn) {
 
 	case RequestTypeMaxBlock:
 		response, err = Type10()
+
+	case RequestTypeBlockHeaders:
+		req := &GetBlocksRangeRequest{}
+		err = ReadRequest(req, rw)
+		if err == nil {
+			err = Type11(req, rw)
+		}
+
+	case RequestTypeBlockBodies:
+		req := &GetBlocksRangeRequest{}
+		err = ReadRequest(req, rw)
+		if err == nil {
+			err = Type12(req, rw)
+		}
+
+	case RequestTypeSnapshot:
+		req := &GetSnapshotRequest{}
+		err = ReadRequest(req, rw)
+		if err == nil {
+			response, err = Type13(req)
+		}
+
+	case RequestTypeSnapshotChunk:
+		req := &GetSnapshotChunkRequest{}
+		err = ReadRequest(req, rw)
+		if err == nil {
+			response, err = Type14(req)
+		}
+
+	case RequestTypePrunedBlock:
+		response, err = Type15()
+
+	case RequestTypePeers:
+		req := &PeersRequest{}
+		err = ReadRequest(req, rw)
+		if err == nil {
+			response, err = Type16(req, rw)
+		}
 	}
 
 	if err != nil || response == nil {
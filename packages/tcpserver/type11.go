@@ -0,0 +1,84 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package tcpserver
+
+import (
+	"net"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/protocol"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Type11 writes the headers of the blocks starting from the specified block
+// blocksCollection daemon sends this request to verify the headers before downloading the bodies
+func Type11(request *GetBlocksRangeRequest, w net.Conn) error {
+	blocks, err := getBlocksRange(request, w)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range blocks {
+		header, mrklRoot, err := protocol.SplitBlockHeader(b.Data)
+		if err != nil {
+			return err
+		}
+		if err := SendRequest(&GetHeaderResponse{Header: header, MrklRoot: mrklRoot}, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getBlocksRange returns the requested blocks and sends the count of them
+func getBlocksRange(request *GetBlocksRangeRequest, w net.Conn) ([]model.Block, error) {
+	count := int32(request.Count)
+	if count <= 0 || count > BlocksPerRequest {
+		count = BlocksPerRequest
+	}
+
+	block := &model.Block{}
+	blocks, err := block.GetBlocksFrom(int64(request.BlockID)-1, "ASC", count)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": request.BlockID}).Error("Error getting blocks from block_id")
+		return nil, err
+	}
+	blocks = withBodies(blocks)
+
+	if err = SendRequest(&BlocksCountResponse{Count: uint32(len(blocks))}, w); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+// withBodies returns the blocks till the first block whose body has been pruned
+func withBodies(blocks []model.Block) []model.Block {
+	for i, b := range blocks {
+		if len(b.Data) == 0 {
+			return blocks[:i]
+		}
+	}
+	return blocks
+}
@@ -0,0 +1,56 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package tcpserver
+
+import (
+	"bytes"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/snapshot"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Type13 writes the manifest of the state snapshot
+// The request is sent by the node which restores the state from the snapshot
+func Type13(request *GetSnapshotRequest) (*SnapshotResponse, error) {
+	resp := &SnapshotResponse{}
+	manifest, data, err := snapshot.GetManifest(int64(request.BlockID))
+	if err != nil {
+		if err == snapshot.ErrNotFound {
+			return resp, nil
+		}
+		return nil, err
+	}
+
+	// the snapshot is not sent if its block has been replaced because of the fork
+	block := &model.Block{}
+	if _, err = block.Get(manifest.BlockID); err != nil {
+		log.WithFields(log.Fields{"type": consts.DBError, "error": err, "block_id": manifest.BlockID}).Error("Getting block")
+		return nil, err
+	}
+	if bytes.Equal(block.Hash, manifest.Block.Hash) {
+		resp.Data = data
+	}
+	return resp, nil
+}
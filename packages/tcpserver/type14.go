@@ -0,0 +1,43 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package tcpserver
+
+import (
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/snapshot"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Type14 writes the chunk of the state snapshot
+// The request is sent by the node which restores the state from the snapshot
+func Type14(request *GetSnapshotChunkRequest) (*SnapshotResponse, error) {
+	data, err := snapshot.ReadChunk(int64(request.BlockID), int(request.Chunk))
+	if err != nil {
+		if err == snapshot.ErrNotFound {
+			return &SnapshotResponse{}, nil
+		}
+		log.WithFields(log.Fields{"type": consts.IOError, "error": err, "block_id": request.BlockID}).Error("Reading snapshot chunk")
+		return nil, err
+	}
+	return &SnapshotResponse{Data: data}, nil
+}
@@ -0,0 +1,55 @@
+// MIT License
+//
+// Copyright (c) 2016 GenesisCommunity
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package tcpserver
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/GenesisCommunity/go-genesis/packages/consts"
+	"github.com/GenesisCommunity/go-genesis/packages/peers"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Type16 adds the record of the requesting node into the address book and sends the records of known peers
+// PeerExchange daemon sends this request to discover the nodes which aren't in the list of full nodes
+func Type16(request *PeersRequest, w net.Conn) (*PeersResponse, error) {
+	if len(request.Data) > 0 {
+		var record peers.Record
+		if err := json.Unmarshal(request.Data, &record); err != nil {
+			log.WithFields(log.Fields{"type": consts.JSONUnmarshallError, "error": err}).Error("unmarshalling peer record")
+			return nil, err
+		}
+		peers.Add(w.RemoteAddr().String(), []peers.Record{record})
+	}
+	records, err := peers.Records()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		log.WithFields(log.Fields{"type": consts.JSONMarshallError, "error": err}).Error("marshalling peer records")
+		return nil, err
+	}
+	return &PeersResponse{Data: data}, nil
+}
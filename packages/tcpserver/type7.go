@@ -55,7 +55,7 @@ func Type7(request *GetBodiesRequest, w net.Conn) error {
 		return err
 	}
 
-	for _, b := range blocks {
+	for _, b := range withBodies(blocks) {
 		if err := SendRequest(&GetBodyResponse{Data: b.Data}, w); err != nil {
 			return err
 		}
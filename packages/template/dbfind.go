@@ -26,6 +26,14 @@ func dbfindExpressionLongText(column string) string {
 		CASE WHEN length(%[1]s)>%[2]d THEN md5(%[1]s) END) "%[1]s"`, column, substringLength)
 }
 
+func dbfindExpressionDate(column string) string {
+	return fmt.Sprintf(`to_char(%s, 'YYYY-MM-DD') "%[1]s"`, column)
+}
+
+func dbfindExpressionArray(column string) string {
+	return fmt.Sprintf(`array_to_json(%s) "%[1]s"`, column)
+}
+
 type valueLink struct {
 	title string
 
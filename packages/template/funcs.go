@@ -635,6 +635,12 @@ func dbfindTag(par parFunc) string {
 				queryColumns[i] = dbfindExpressionLongText(col)
 			}
 			break
+		case "date":
+			queryColumns[i] = dbfindExpressionDate(col)
+			break
+		case "ARRAY":
+			queryColumns[i] = dbfindExpressionArray(col)
+			break
 		}
 	}
 	for i, field := range queryColumns {
@@ -6,6 +6,7 @@ import (
 	"github.com/GenesisCommunity/go-genesis/packages/consts"
 	"github.com/GenesisCommunity/go-genesis/packages/crypto"
 	"github.com/GenesisCommunity/go-genesis/packages/model"
+	"github.com/GenesisCommunity/go-genesis/packages/script"
 	"github.com/GenesisCommunity/go-genesis/packages/utils"
 
 	log "github.com/sirupsen/logrus"
@@ -13,6 +14,9 @@ import (
 
 var ErrDuplicatedTx = errors.New("Duplicated transaction")
 
+// maxErrorLength is the maximum length of the error text saved in the transaction status
+const maxErrorLength = 4096
+
 // InsertInLogTx is inserting tx in log
 func InsertInLogTx(transaction *model.DbTransaction, binaryTx []byte, time int64) error {
 	txHash, err := crypto.Hash(binaryTx)
@@ -99,9 +103,7 @@ func MarkTransactionBad(dbTransaction *model.DbTransaction, hash []byte, errText
 		return nil
 	}
 	model.MarkTransactionUsed(dbTransaction, hash)
-	if len(errText) > 255 {
-		errText = errText[:255]
-	}
+	errText = script.TruncateVMError(errText, maxErrorLength)
 
 	// set loglevel as error because default level setups to "error"
 	log.WithFields(log.Fields{"type": consts.BadTxError, "tx_hash": string(hash), "error": errText}).Error("tx marked as bad")
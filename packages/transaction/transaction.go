@@ -37,6 +37,7 @@ type Transaction struct {
 	TxCost        int64 // Maximum cost of executing contract
 	TxFuel        int64
 	TxUsedCost    decimal.Decimal // Used cost of CPU resources
+	TxFuelInfo    *smart.FuelInfo // The breakdown of the fuel of the contract
 	TxPtr         interface{}     // Pointer to the corresponding struct in consts/struct.go
 	TxData        map[string]interface{}
 	TxSmart       *tx.SmartContract
@@ -397,6 +398,7 @@ func (t *Transaction) CallContract(flags int) (resultContract string, err error)
 	}
 	resultContract, err = sc.CallContract(flags)
 	t.SysUpdate = sc.SysUpdate
+	t.TxFuelInfo = sc.FuelInfo()
 	return
 }
 
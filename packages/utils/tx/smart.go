@@ -32,10 +32,15 @@ type SmartContract struct {
 	PayOver        string
 	SignedBy       int64
 	Data           []byte
+	Replaces       []byte `msgpack:",omitempty"` // the hash of the pending transaction which is replaced by this one
 }
 
 // ForSign is converting SmartContract to string
 func (s SmartContract) ForSign() string {
-	return fmt.Sprintf("%s,%d,%d,%d,%d,%d,%s,%s,%d", s.RequestID, s.Type, s.Time, s.KeyID, s.EcosystemID,
+	forSign := fmt.Sprintf("%s,%d,%d,%d,%d,%d,%s,%s,%d", s.RequestID, s.Type, s.Time, s.KeyID, s.EcosystemID,
 		s.TokenEcosystem, s.MaxSum, s.PayOver, s.SignedBy)
+	if len(s.Replaces) > 0 {
+		forSign += fmt.Sprintf(",%x", s.Replaces)
+	}
+	return forSign
 }